@@ -0,0 +1,19 @@
+package app
+
+import (
+	handler "github.com/demkowo/booking/handlers"
+	middleware "github.com/demkowo/booking/middlewares"
+	log "github.com/sirupsen/logrus"
+)
+
+// adminRoutes always require a token of an account with the admin role,
+// whether auth is enabled for the API or not.
+func adminRoutes(auth middleware.Auth, rateLimit middleware.RateLimit, h handler.LogLevel) {
+	log.Trace()
+
+	admin := router.Group("/admin", auth.Handle, rateLimit.Handle, middleware.NewRole("admin").Handle)
+	{
+		admin.GET("/log-levels", h.Get)
+		admin.PUT("/log-levels", h.Set)
+	}
+}
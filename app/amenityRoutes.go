@@ -0,0 +1,23 @@
+package app
+
+import (
+	handler "github.com/demkowo/booking/handlers"
+	middleware "github.com/demkowo/booking/middlewares"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// amenityRoutes leave changing the catalog, which all properties share, to
+// accounts that aren't limited to one property.
+func amenityRoutes(api *gin.RouterGroup, h handler.Amenity, tenant middleware.Tenant) {
+	log.Trace()
+
+	amenities := api.Group("/amenities")
+	{
+		amenities.POST("/add", tenant.Global, h.Add)
+		amenities.GET("/", h.Find)
+		amenities.GET("/:amenity_id", h.GetById)
+		amenities.PUT("/:amenity_id", tenant.Global, h.Update)
+		amenities.DELETE("/:amenity_id", tenant.Global, h.Delete)
+	}
+}
@@ -1,54 +1,293 @@
 package app
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/demkowo/booking/config"
 	handler "github.com/demkowo/booking/handlers"
+	middleware "github.com/demkowo/booking/middlewares"
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/repositories/memory"
 	"github.com/demkowo/booking/repositories/postgres"
+	"github.com/demkowo/booking/repositories/sqlite"
 	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/deadline"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/health"
 	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/metrics"
+	"github.com/demkowo/booking/utils/ratelimit"
+	"github.com/demkowo/booking/utils/resp"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+	"github.com/demkowo/booking/utils/tracing"
+	worker "github.com/demkowo/booking/workers"
 	"github.com/gin-gonic/gin"
 )
 
-const (
-	portNumber = ":5000"
-)
-
 var (
-	router       = gin.Default()
-	dbConnection string
+	router = gin.New()
 )
 
 func init() {
 	logger.Start.BasicConfig()
-	dbConnection = os.Getenv("DB_DELMAJK")
+
+	router.Use(middleware.NewMetrics().Handle, middleware.NewTracing().Handle, middleware.NewRequestLog().Handle, gin.CustomRecovery(recovery))
+	router.NoRoute(noRoute)
+	router.NoMethod(noMethod)
+	router.HandleMethodNotAllowed = true
 }
 
 func Start() {
-	db, err := sql.Open("postgres", dbConnection)
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
 	if err != nil {
-		log.Panicf("sql.Open failed\n[%s]\n", err)
+		log.Panicf("config.Load failed\n[%s]\n", err)
 	}
-	defer db.Close()
+	logger.Start.YamlConfig()
 
-	if err = db.Ping(); err != nil {
-		log.Panicf("db.Ping failed\n[%s]\n", err)
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
+	if err != nil {
+		log.Panicf("tracing.Setup failed\n[%s]\n", err)
 	}
 
-	roomRepo := postgres.NewRoom(db)
-	roomService := service.NewRoom(roomRepo)
-	roomHandler := handler.NewRoom(roomService)
-	roomRoutes(roomHandler)
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Panicf("router.SetTrustedProxies failed\n[%s]\n", err)
+	}
+	if err := resp.SetErrorFormat(cfg.Server.ErrorFormat); err != nil {
+		log.Panicf("resp.SetErrorFormat failed\n[%s]\n", err)
+	}
+	if err := handler.RegisterValidators(); err != nil {
+		log.Panicf("handler.RegisterValidators failed\n[%s]\n", err)
+	}
+	deadline.SetDefault(cfg.Database.QueryTimeout)
+	for operation, timeout := range cfg.Database.QueryTimeouts {
+		deadline.Set(operation, timeout)
+	}
+
+	var (
+		db              sqlclient.SqlClient
+		propertyRepo    service.PropertyRepo
+		roomRepo        service.RoomRepo
+		reservationRepo service.ReservationRepo
+		amenityRepo     service.AmenityRepo
+		idempotencyRepo service.IdempotencyRepo
+		uow             service.UnitOfWork
+	)
+
+	switch cfg.Database.Driver {
+	case config.DB_DRIVER_MEMORY:
+		store := memory.NewStore()
+		propertyRepo = memory.NewProperty(store)
+		roomRepo = memory.NewRoom(store)
+		reservationRepo = memory.NewReservation(store)
+		amenityRepo = memory.NewAmenity(store)
+		idempotencyRepo = memory.NewIdempotency(store)
+		uow = memory.NewUnitOfWork(store)
+	case config.DB_DRIVER_SQLITE:
+		db = openDB(sqlite.DRIVER_NAME, sqlite.DSN(cfg.Database.DSN), cfg.Database)
+		registerDBStats(config.DB_DRIVER_SQLITE, db)
+		health.Register("database", db.PingContext)
+		health.Register("migrations", func(ctx context.Context) error { return sqlite.CheckSchema(ctx, db) })
+
+		propertyRepo = sqlite.NewProperty(db)
+		roomRepo = sqlite.NewRoom(db)
+		reservationRepo = sqlite.NewReservation(db)
+		amenityRepo = sqlite.NewAmenity(db)
+		idempotencyRepo = sqlite.NewIdempotency(db)
+		uow = sqlite.NewUnitOfWork(db)
+	default:
+		db = openDB("postgres", cfg.Database.DSN, cfg.Database)
+		registerDBStats(config.DB_DRIVER_POSTGRES, db)
+		health.Register("database", db.PingContext)
+		health.Register("migrations", func(ctx context.Context) error { return postgres.CheckSchema(ctx, db) })
+
+		propertyRepo = postgres.NewProperty(db)
+		roomRepo = postgres.NewRoom(db)
+		reservationRepo = postgres.NewReservation(db)
+		amenityRepo = postgres.NewAmenity(db)
+		idempotencyRepo = postgres.NewIdempotency(db)
+		uow = postgres.NewUnitOfWork(db)
+	}
+
+	healthHandler := handler.NewHealth()
+	healthRoutes(healthHandler)
+	metricsRoutes()
+
+	idempotencyService := service.NewIdempotency(idempotencyRepo, cfg.Idempotency.TTL)
+	idempotency := middleware.NewIdempotency(idempotencyService, cfg.Idempotency.MaxBodyBytes)
+	auth := middleware.NewAuth(cfg.Auth.Enabled, []byte(cfg.Auth.JWTSecret))
+	rateLimitStore := ratelimit.NewMemoryStore()
 
-	reservationRepo := postgres.NewReservation(db)
-	reservationService := service.NewReservation(reservationRepo)
+	problemRoutes(handler.NewProblem())
+	adminRoutes(middleware.NewAuth(true, []byte(cfg.Auth.JWTSecret)), newRateLimit(rateLimitStore, cfg.RateLimit, ratelimit.GROUP_ADMIN), handler.NewLogLevel())
+
+	propertyService := service.NewProperty(propertyRepo, uow)
+	tenant := middleware.NewTenant(propertyService)
+
+	api := router.Group(API_PREFIX, middleware.NewCORS(cfg.CORS).Handle, auth.Handle, newRateLimit(rateLimitStore, cfg.RateLimit, ratelimit.GROUP_API).Handle, tenant.Handle, idempotency.Handle)
+	api.OPTIONS("/*path", preflight)
+
+	propertyHandler := handler.NewProperty(propertyService)
+	amenityService := service.NewAmenity(amenityRepo, uow)
+	amenityHandler := handler.NewAmenity(amenityService)
+	roomService := service.NewRoom(roomRepo, reservationRepo, amenityRepo, uow)
+	roomHandler := handler.NewRoom(roomService)
+	reservationService := service.NewReservation(reservationRepo, roomRepo, uow)
 	reservationHandler := handler.NewReservation(reservationService)
-	reservationRoutes(reservationHandler)
+	search := newRateLimit(rateLimitStore, cfg.RateLimit, ratelimit.GROUP_SEARCH)
+	apiRoutes(api, tenant, search, propertyHandler, amenityHandler, roomHandler, reservationHandler)
+
+	openAPIRoutes(handler.NewOpenAPI(apiSpec()))
 
+	propertyHandler.CreateTableProperties()
 	roomHandler.CreateTableRooms()
 	reservationHandler.CreateTableReservations()
+	amenityHandler.CreateTableAmenities()
+	log.Println(idempotencyService.CreateTableIdempotencyKeys(context.Background()))
+
+	retention := worker.NewRetention(reservationService, propertyService, cfg.Retention.Period, cfg.Retention.Interval, cfg.Retention.Mode)
+	retention.Start()
+	health.Register("retention", func(ctx context.Context) error {
+		if !retention.Running() {
+			return errors.New("retention worker is not running")
+		}
+		return nil
+	})
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler: router,
+	}
+	serve(server, healthHandler, cfg.Server)
+
+	retention.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("shutdownTracing failed\n[%s]\n", err)
+	}
+
+	if db != nil {
+		db.Close()
+	}
+	log.Println("shutdown complete")
+	logger.Start.Close()
+}
+
+// apiRoutes registers the routes of the API. Every one of them needs an entry
+// in apiSpec, which app/openapi_test.go checks.
+func apiRoutes(api *gin.RouterGroup, tenant middleware.Tenant, search middleware.RateLimit, propertyHandler handler.Property, amenityHandler handler.Amenity, roomHandler handler.Room, reservationHandler handler.Reservation) {
+	property := api.Group("/properties/:property_id")
+
+	propertyRoutes(api, propertyHandler, tenant)
+	amenityRoutes(api, amenityHandler, tenant)
+	roomRoutes(api, roomHandler, search)
+	roomRoutes(property, roomHandler, search)
+	reservationRoutes(api, reservationHandler)
+	reservationRoutes(property, reservationHandler)
+}
+
+// serve runs the server until SIGINT or SIGTERM. On a signal it reports not
+// ready, waits ShutdownDelay for load balancers to notice, and then gives
+// in-flight requests up to ShutdownTimeout to finish. A second signal stops
+// the process immediately.
+func serve(server *http.Server, healthHandler handler.Health, cfg model.ServerConfig) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Panicf("net.Listen failed\n[%s]\n", err)
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+	healthHandler.SetReady(true)
+
+	select {
+	case err := <-serverErr:
+		log.Panicf("server.Serve failed\n[%s]\n", err)
+	case <-ctx.Done():
+	}
+	stop()
+
+	log.Println("shutting down")
+	healthHandler.SetReady(false)
+	time.Sleep(cfg.ShutdownDelay)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("server.Shutdown failed, closing remaining connections\n[%s]\n", err)
+		server.Close()
+	}
+}
+
+func openDB(driver string, dsn string, pool model.DatabaseConfig) sqlclient.SqlClient {
+	db, err := sqlclient.Open(driver, dsn)
+	if err != nil {
+		log.Panicf("sqlclient.Open failed\n[%s]\n", err)
+	}
+
+	db.SetMaxOpenConns(pool.MaxOpenConns)
+	db.SetMaxIdleConns(pool.MaxIdleConns)
+	db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+
+	if err = db.PingContext(context.Background()); err != nil {
+		log.Panicf("db.Ping failed\n[%s]\n", err)
+	}
+
+	return db
+}
+
+// newRateLimit limits a route group with its configured rule. Groups without
+// a rule, or all of them when rate limiting is disabled, aren't limited.
+func newRateLimit(store ratelimit.Store, cfg model.RateLimitConfig, group string) middleware.RateLimit {
+	rule := cfg.Limits[group]
+	if !cfg.Enabled {
+		rule = model.RateLimitRule{}
+	}
+
+	return middleware.NewRateLimit(store, group, rule, cfg.APIKeyHeader)
+}
+
+func registerDBStats(dbName string, db sqlclient.SqlClient) {
+	if err := metrics.RegisterDBStats(dbName, db.Stats); err != nil {
+		log.Panicf("metrics.RegisterDBStats failed\n[%s]\n", err)
+	}
+}
+
+func recovery(c *gin.Context, err any) {
+	log.Printf("panic recovered: %v\n", err)
+	resp.Fail(c, errs.NewError("Internal server error", 500, "Internal Server Error", nil))
+}
+
+// preflight answers OPTIONS requests the CORS middleware let through, the
+// ones without an Origin header.
+func preflight(c *gin.Context) {
+	c.Status(http.StatusNoContent)
+}
+
+func noRoute(c *gin.Context) {
+	resp.Fail(c, errs.NewError("Route not found", 404, "Not Found", nil))
+}
 
-	router.Run(portNumber)
+func noMethod(c *gin.Context) {
+	resp.Fail(c, errs.NewError("Method not allowed", 405, "Method Not Allowed", nil))
 }
@@ -0,0 +1,14 @@
+package app
+
+import (
+	handler "github.com/demkowo/booking/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+func healthRoutes(h handler.Health) {
+	log.Trace()
+
+	router.GET("/healthz", h.Live)
+	router.GET("/readyz", h.Ready)
+	router.GET("/version", h.Version)
+}
@@ -0,0 +1,13 @@
+package app
+
+import (
+	"github.com/demkowo/booking/utils/metrics"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func metricsRoutes() {
+	log.Trace()
+
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+}
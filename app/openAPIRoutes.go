@@ -0,0 +1,13 @@
+package app
+
+import (
+	handler "github.com/demkowo/booking/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+func openAPIRoutes(h handler.OpenAPI) {
+	log.Trace()
+
+	router.GET("/openapi.json", h.Spec)
+	router.GET("/docs/*filepath", h.UI)
+}
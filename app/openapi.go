@@ -0,0 +1,147 @@
+package app
+
+import (
+	"net/http"
+
+	handler "github.com/demkowo/booking/handlers"
+	middleware "github.com/demkowo/booking/middlewares"
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/openapi"
+	"github.com/demkowo/booking/utils/version"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	API_PREFIX = "/api/v1"
+)
+
+var (
+	idempotencyKey = openapi.Parameter{Name: middleware.IDEMPOTENCY_KEY_HEADER, In: "header", Description: "Replays the response of an earlier request with the same key and body.", Schema: &openapi.Schema{Type: "string"}}
+	ifMatch        = openapi.Parameter{Name: "If-Match", In: "header", Required: true, Description: "ETag of the version being updated, or *.", Schema: &openapi.Schema{Type: "string"}}
+	reassignTo     = openapi.Parameter{Name: "reassign_to", In: "query", Description: "Room the future reservations are moved to.", Schema: &openapi.Schema{Type: "string", Format: "uuid"}}
+	deleted        = openapi.Parameter{Name: "deleted", In: "query", Description: "List deleted reservations instead.", Schema: &openapi.Schema{Type: "boolean"}}
+
+	apiErrors         = []int{http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusGatewayTimeout}
+	idempotencyErrors = []int{http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity}
+	updateErrors      = []int{http.StatusNotFound, http.StatusConflict, http.StatusPreconditionFailed, http.StatusUnprocessableEntity, http.StatusPreconditionRequired}
+)
+
+// apiSpec describes the routes of /api/v1. Every route registered there
+// needs an endpoint here, Start refuses to run otherwise. Room and
+// reservation routes are described once more under /properties/:property_id.
+func apiSpec() *openapi.Document {
+	log.Trace()
+
+	doc := openapi.New("Booking API", version.Get().Version, "Rooms and their reservations, grouped by property. Errors are RFC 7807 problem documents or the response envelope, depending on the Accept header.")
+
+	for _, e := range []openapi.Endpoint{
+		{Method: http.MethodPost, Path: "/properties/add", OperationID: "addProperty", Summary: "Add a property", Tag: "Properties",
+			Description: "Fails with 403 for tokens limited to one property.",
+			Parameters:  []openapi.Parameter{idempotencyKey}, Body: handler.PropertyInput{}, Data: model.Property{}, ETag: true,
+			Errors: append([]int{http.StatusBadRequest, http.StatusForbidden}, idempotencyErrors...)},
+		{Method: http.MethodGet, Path: "/properties/", OperationID: "findProperties", Summary: "List the properties", Tag: "Properties",
+			Description: "Fails with 403 for tokens limited to one property.",
+			Data:        []model.Property{},
+			Errors:      []int{http.StatusForbidden}},
+		{Method: http.MethodGet, Path: "/properties/:property_id", OperationID: "getProperty", Summary: "Get a property", Tag: "Properties",
+			Data: model.Property{}, ETag: true,
+			Errors: []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/properties/:property_id", OperationID: "updateProperty", Summary: "Rename a property", Tag: "Properties",
+			Parameters: []openapi.Parameter{ifMatch}, Body: handler.PropertyInput{}, Data: model.Property{}, ETag: true,
+			Errors: append([]int{http.StatusBadRequest, http.StatusForbidden}, updateErrors...)},
+
+		{Method: http.MethodPost, Path: "/rooms/add", OperationID: "addRoom", Summary: "Add a room", Tag: "Rooms",
+			Parameters: []openapi.Parameter{idempotencyKey}, Body: handler.RoomInput{}, Data: model.Room{}, ETag: true,
+			Errors: append([]int{http.StatusBadRequest}, idempotencyErrors...)},
+		{Method: http.MethodGet, Path: "/rooms/", OperationID: "findRooms", Summary: "List the rooms", Tag: "Rooms",
+			Data: []model.Room{}},
+		{Method: http.MethodPost, Path: "/rooms/find-available", OperationID: "findAvailableRooms", Summary: "List the rooms free for the dates", Tag: "Rooms",
+			Description: "Rooms too small for the adults and children or without every must have amenity are left out, rooms with more nice to have amenities come first. Limited by the search rate limit.",
+			Body:        handler.AvailabilitySearchInput{}, Data: []model.Room{},
+			Errors: []int{http.StatusBadRequest, http.StatusUnprocessableEntity}},
+		{Method: http.MethodGet, Path: "/rooms/:room_id", OperationID: "getRoom", Summary: "Get a room", Tag: "Rooms",
+			Data: model.Room{}, ETag: true,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{Method: http.MethodPost, Path: "/rooms/:room_id/availability-check", OperationID: "checkRoomAvailability", Summary: "Check whether a room is free for the dates", Tag: "Rooms",
+			Description: "Limited by the search rate limit.",
+			Body:        handler.DateRangeInput{}, Data: handler.Availability{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity}},
+		{Method: http.MethodPut, Path: "/rooms/:room_id", OperationID: "updateRoom", Summary: "Rename a room or change its capacity", Tag: "Rooms",
+			Description: "Fails with 409 when future reservations no longer fit the room.",
+			Parameters:  []openapi.Parameter{ifMatch}, Body: handler.RoomInput{}, Data: model.Room{}, ETag: true,
+			Errors: append([]int{http.StatusBadRequest}, updateErrors...)},
+		{Method: http.MethodDelete, Path: "/rooms/:room_id", OperationID: "archiveRoom", Summary: "Archive a room", Tag: "Rooms",
+			Description: "Fails with 409 while the room has future reservations, unless reassign_to names a room to move them to.",
+			Parameters:  []openapi.Parameter{reassignTo}, Data: handler.MovedReservations{},
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict}},
+		{Method: http.MethodPost, Path: "/rooms/:room_id/move-reservations", OperationID: "moveReservations", Summary: "Move the future reservations to another room", Tag: "Rooms",
+			Description: "Fails with 409 when the target room is booked or too small for any of them.",
+			Parameters:  []openapi.Parameter{idempotencyKey}, Body: handler.MoveReservationsInput{}, Data: handler.MovedReservations{},
+			Errors: append([]int{http.StatusBadRequest, http.StatusNotFound}, idempotencyErrors...)},
+		{Method: http.MethodPut, Path: "/rooms/:room_id/amenities", OperationID: "setRoomAmenities", Summary: "Replace the amenities of a room", Tag: "Rooms",
+			Description: "Values are booleans, numbers or strings matching the amenity type.",
+			Body:        handler.RoomAmenitiesInput{}, Data: model.Room{}, ETag: true,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound, http.StatusUnprocessableEntity}},
+
+		{Method: http.MethodPost, Path: "/amenities/add", OperationID: "addAmenity", Summary: "Add an amenity to the catalog", Tag: "Amenities",
+			Description: "Fails with 409 when the key is taken and with 403 for tokens limited to one property.",
+			Parameters:  []openapi.Parameter{idempotencyKey}, Body: handler.AmenityInput{}, Data: model.Amenity{}, ETag: true,
+			Errors: append([]int{http.StatusBadRequest, http.StatusForbidden}, idempotencyErrors...)},
+		{Method: http.MethodGet, Path: "/amenities/", OperationID: "findAmenities", Summary: "List the amenities", Tag: "Amenities",
+			Data: []model.Amenity{}},
+		{Method: http.MethodGet, Path: "/amenities/:amenity_id", OperationID: "getAmenity", Summary: "Get an amenity", Tag: "Amenities",
+			Data: model.Amenity{}, ETag: true,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/amenities/:amenity_id", OperationID: "updateAmenity", Summary: "Rename an amenity or add enum values", Tag: "Amenities",
+			Description: "Key and type can't change, and enum values can't be removed. Fails with 403 for tokens limited to one property.",
+			Parameters:  []openapi.Parameter{ifMatch}, Body: handler.AmenityUpdateInput{}, Data: model.Amenity{}, ETag: true,
+			Errors: append([]int{http.StatusBadRequest, http.StatusForbidden}, updateErrors...)},
+		{Method: http.MethodDelete, Path: "/amenities/:amenity_id", OperationID: "deleteAmenity", Summary: "Delete an amenity", Tag: "Amenities",
+			Description: "The amenity is removed from every room too. Fails with 403 for tokens limited to one property.",
+			Errors:      []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}},
+
+		{Method: http.MethodPost, Path: "/reservations/add", OperationID: "addReservation", Summary: "Book a room", Tag: "Reservations",
+			Description: "Fails with 422 when the adults and children exceed the room capacity.",
+			Parameters:  []openapi.Parameter{idempotencyKey}, Body: handler.ReservationInput{}, Data: model.Reservation{}, ETag: true,
+			Errors: append([]int{http.StatusBadRequest, http.StatusNotFound}, idempotencyErrors...)},
+		{Method: http.MethodDelete, Path: "/reservations/:reservation_id", OperationID: "deleteReservation", Summary: "Delete a reservation", Tag: "Reservations",
+			Description: "The reservation can be restored until the retention period ends.",
+			Errors:      []int{http.StatusBadRequest, http.StatusNotFound}},
+		{Method: http.MethodGet, Path: "/reservations/", OperationID: "findReservations", Summary: "List the reservations", Tag: "Reservations",
+			Parameters: []openapi.Parameter{deleted}, Data: []model.Reservation{},
+			Errors: []int{http.StatusBadRequest}},
+		{Method: http.MethodGet, Path: "/reservations/find/:room_id", OperationID: "findRoomReservations", Summary: "List the reservations of a room", Tag: "Reservations",
+			Data:   []model.Reservation{},
+			Errors: []int{http.StatusBadRequest}},
+		{Method: http.MethodGet, Path: "/reservations/:reservation_id", OperationID: "getReservation", Summary: "Get a reservation", Tag: "Reservations",
+			Data: model.Reservation{}, ETag: true,
+			Errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+		{Method: http.MethodPut, Path: "/reservations/:reservation_id", OperationID: "updateReservation", Summary: "Change the dates or room of a reservation", Tag: "Reservations",
+			Description: "The status of the reservation is kept.",
+			Parameters:  []openapi.Parameter{ifMatch}, Body: handler.ReservationUpdateInput{}, Data: model.Reservation{}, ETag: true,
+			Errors: append([]int{http.StatusBadRequest}, updateErrors...)},
+		{Method: http.MethodPost, Path: "/reservations/:reservation_id/restore", OperationID: "restoreReservation", Summary: "Restore a deleted reservation", Tag: "Reservations",
+			Parameters: []openapi.Parameter{idempotencyKey}, Data: model.Reservation{}, ETag: true,
+			Errors: append([]int{http.StatusBadRequest, http.StatusNotFound}, idempotencyErrors...)},
+	} {
+		e.Errors = append(e.Errors, apiErrors...)
+		if e.Tag == "Rooms" || e.Tag == "Reservations" {
+			scoped := e
+			scoped.Path = API_PREFIX + "/properties/:property_id" + e.Path
+			scoped.OperationID = e.OperationID + "ForProperty"
+			scoped.Errors = append([]int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound}, e.Errors...)
+			doc.Describe(scoped)
+		}
+
+		e.Path = API_PREFIX + e.Path
+		doc.Describe(e)
+	}
+
+	doc.Describe(openapi.Endpoint{Method: http.MethodGet, Path: API_PREFIX + "/problems/", OperationID: "findProblemTypes", Summary: "List the problem types", Tag: "Problems",
+		Data: []errs.ProblemType{}})
+	doc.Describe(openapi.Endpoint{Method: http.MethodGet, Path: API_PREFIX + "/problems/:kind", OperationID: "getProblemType", Summary: "Get a problem type", Tag: "Problems",
+		Data: errs.ProblemType{}, Errors: []int{http.StatusNotFound}})
+
+	return doc
+}
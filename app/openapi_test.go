@@ -0,0 +1,44 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	handler "github.com/demkowo/booking/handlers"
+	middleware "github.com/demkowo/booking/middlewares"
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/repositories/memory"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/ratelimit"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := memory.NewStore()
+	uow := memory.NewUnitOfWork(store)
+	roomRepo := memory.NewRoom(store)
+	reservationRepo := memory.NewReservation(store)
+	amenityRepo := memory.NewAmenity(store)
+	propertyService := service.NewProperty(memory.NewProperty(store), uow)
+
+	router := gin.New()
+	api := router.Group(API_PREFIX)
+	apiRoutes(api,
+		middleware.NewTenant(propertyService),
+		middleware.NewRateLimit(ratelimit.NewMemoryStore(), ratelimit.GROUP_SEARCH, model.RateLimitRule{}, ""),
+		handler.NewProperty(propertyService),
+		handler.NewAmenity(service.NewAmenity(amenityRepo, uow)),
+		handler.NewRoom(service.NewRoom(roomRepo, reservationRepo, amenityRepo, uow)),
+		handler.NewReservation(service.NewReservation(reservationRepo, roomRepo, uow)),
+	)
+
+	if len(router.Routes()) == 0 {
+		t.Fatal("no routes were registered")
+	}
+	if missing := apiSpec().Undocumented(router.Routes(), API_PREFIX); len(missing) > 0 {
+		t.Errorf("routes without an OpenAPI entry in app/openapi.go: %s", strings.Join(missing, ", "))
+	}
+}
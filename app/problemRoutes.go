@@ -0,0 +1,16 @@
+package app
+
+import (
+	handler "github.com/demkowo/booking/handlers"
+	log "github.com/sirupsen/logrus"
+)
+
+func problemRoutes(h handler.Problem) {
+	log.Trace()
+
+	problems := router.Group("/api/v1/problems")
+	{
+		problems.GET("/", h.Find)
+		problems.GET("/:kind", h.GetByKind)
+	}
+}
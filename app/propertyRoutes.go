@@ -0,0 +1,22 @@
+package app
+
+import (
+	handler "github.com/demkowo/booking/handlers"
+	middleware "github.com/demkowo/booking/middlewares"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// propertyRoutes leave creating and listing properties to accounts that
+// aren't limited to one property.
+func propertyRoutes(api *gin.RouterGroup, h handler.Property, tenant middleware.Tenant) {
+	log.Trace()
+
+	properties := api.Group("/properties")
+	{
+		properties.POST("/add", tenant.Global, h.Add)
+		properties.GET("/", tenant.Global, h.Find)
+		properties.GET("/:property_id", h.GetById)
+		properties.PUT("/:property_id", h.Update)
+	}
+}
@@ -1,20 +1,22 @@
-package app
-
-import (
-	handler "github.com/demkowo/booking/handlers"
-	log "github.com/sirupsen/logrus"
-)
-
-func reservationRoutes(h handler.Reservation) {
-	log.Trace()
-
-	reservations := router.Group("/api/v1/reservations")
-	{
-		reservations.POST("/add", h.Add)
-		reservations.DELETE("/:reservation_id", h.Delete)
-		reservations.GET("/", h.Find)
-		reservations.GET("/find/:room_id", h.FindByRoomID)
-		reservations.GET("/:reservation_id", h.GetById)
-		reservations.PUT("/:reservation_id", h.Update)
-	}
-}
+package app
+
+import (
+	handler "github.com/demkowo/booking/handlers"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+func reservationRoutes(api *gin.RouterGroup, h handler.Reservation) {
+	log.Trace()
+
+	reservations := api.Group("/reservations")
+	{
+		reservations.POST("/add", h.Add)
+		reservations.DELETE("/:reservation_id", h.Delete)
+		reservations.GET("/", h.Find)
+		reservations.GET("/find/:room_id", h.FindByRoomID)
+		reservations.GET("/:reservation_id", h.GetById)
+		reservations.PUT("/:reservation_id", h.Update)
+		reservations.POST("/:reservation_id/restore", h.Restore)
+	}
+}
@@ -1,20 +1,27 @@
-package app
-
-import (
-	handler "github.com/demkowo/booking/handlers"
-	log "github.com/sirupsen/logrus"
-)
-
-func roomRoutes(h handler.Room) {
-	log.Trace()
-
-	rooms := router.Group("/api/v1/rooms")
-	{
-		rooms.POST("/add", h.Add)
-		rooms.GET("/", h.Find)
-		rooms.POST("/find-available", h.FindAvailable)
-		rooms.GET("/:room_id", h.GetById)
-		rooms.POST("/:room_id/availability-check", h.CheckIfAvailableById)
-		rooms.PUT("/:room_id", h.Update)
-	}
-}
+package app
+
+import (
+	handler "github.com/demkowo/booking/handlers"
+	middleware "github.com/demkowo/booking/middlewares"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+// roomRoutes limit the availability searches with search on top of the
+// limit of the API.
+func roomRoutes(api *gin.RouterGroup, h handler.Room, search middleware.RateLimit) {
+	log.Trace()
+
+	rooms := api.Group("/rooms")
+	{
+		rooms.POST("/add", h.Add)
+		rooms.GET("/", h.Find)
+		rooms.POST("/find-available", search.Handle, h.FindAvailable)
+		rooms.GET("/:room_id", h.GetById)
+		rooms.POST("/:room_id/availability-check", search.Handle, h.CheckIfAvailableById)
+		rooms.PUT("/:room_id", h.Update)
+		rooms.DELETE("/:room_id", h.Archive)
+		rooms.POST("/:room_id/move-reservations", h.MoveReservations)
+		rooms.PUT("/:room_id/amenities", h.SetAmenities)
+	}
+}
@@ -0,0 +1,148 @@
+package app
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+
+	handler "github.com/demkowo/booking/handlers"
+	middleware "github.com/demkowo/booking/middlewares"
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/repositories/memory"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/ratelimit"
+)
+
+var testSecret = []byte("test-secret")
+
+func TestPropertyScopedAccounts(t *testing.T) {
+	router := newTestAPI(t)
+	admin := token(t, uuid.Nil)
+
+	var amenity model.Amenity
+	call(t, router, admin, http.MethodPost, API_PREFIX+"/amenities/add", `{"key":"sea_view","name":"Sea view","type":"boolean"}`, 200, &amenity)
+	var property model.Property
+	call(t, router, admin, http.MethodPost, API_PREFIX+"/properties/add", `{"name":"Seaside"}`, 200, &property)
+
+	var own, other model.Room
+	call(t, router, admin, http.MethodPost, API_PREFIX+"/properties/"+property.Id.String()+"/rooms/add", `{"name":"Blue"}`, 200, &own)
+	call(t, router, admin, http.MethodPost, API_PREFIX+"/rooms/add", `{"name":"Red"}`, 200, &other)
+	for _, room := range []model.Room{own, other} {
+		call(t, router, admin, http.MethodPut, API_PREFIX+"/properties/"+room.PropertyID.String()+"/rooms/"+room.Id.String()+"/amenities", `{"amenities":[{"key":"sea_view","value":true}]}`, 200, nil)
+	}
+
+	scoped := token(t, property.Id)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{name: "own room", method: http.MethodGet, path: "/rooms/" + own.Id.String(), code: 200},
+		{name: "room of another property", method: http.MethodGet, path: "/rooms/" + other.Id.String(), code: 404},
+		{name: "room of another property by path", method: http.MethodGet, path: "/properties/" + model.DEFAULT_PROPERTY.String() + "/rooms/" + other.Id.String(), code: 403},
+		{name: "amenities of a room of another property", method: http.MethodPut, path: "/rooms/" + other.Id.String() + "/amenities", body: `{"amenities":[]}`, code: 404},
+		{name: "list properties", method: http.MethodGet, path: "/properties/", code: 403},
+		{name: "list amenities", method: http.MethodGet, path: "/amenities/", code: 200},
+		{name: "get amenity", method: http.MethodGet, path: "/amenities/" + amenity.Id.String(), code: 200},
+		{name: "add amenity", method: http.MethodPost, path: "/amenities/add", body: `{"key":"balcony","name":"Balcony","type":"boolean"}`, code: 403},
+		{name: "update amenity", method: http.MethodPut, path: "/amenities/" + amenity.Id.String(), body: `{"name":"View"}`, code: 403},
+		{name: "delete amenity", method: http.MethodDelete, path: "/amenities/" + amenity.Id.String(), code: 403},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call(t, router, scoped, tt.method, API_PREFIX+tt.path, tt.body, tt.code, nil)
+		})
+	}
+
+	var room struct{ Amenities []model.RoomAmenity }
+	call(t, router, admin, http.MethodGet, API_PREFIX+"/rooms/"+other.Id.String(), "", 200, &room)
+	if len(room.Amenities) != 1 || room.Amenities[0].Key != "sea_view" {
+		t.Fatalf("the room of the other property lost its amenities: %+v", room.Amenities)
+	}
+
+	call(t, router, admin, http.MethodDelete, API_PREFIX+"/amenities/"+amenity.Id.String(), "", 200, nil)
+	call(t, router, admin, http.MethodGet, API_PREFIX+"/rooms/"+other.Id.String(), "", 200, &room)
+	if len(room.Amenities) != 0 {
+		t.Fatalf("expected the deleted amenity to be removed from the room, got %+v", room.Amenities)
+	}
+}
+
+func newTestAPI(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if err := handler.RegisterValidators(); err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.NewStore()
+	uow := memory.NewUnitOfWork(store)
+	roomRepo := memory.NewRoom(store)
+	reservationRepo := memory.NewReservation(store)
+	amenityRepo := memory.NewAmenity(store)
+	propertyService := service.NewProperty(memory.NewProperty(store), uow)
+	tenant := middleware.NewTenant(propertyService)
+
+	router := gin.New()
+	api := router.Group(API_PREFIX, middleware.NewAuth(true, testSecret).Handle, tenant.Handle)
+	apiRoutes(api,
+		tenant,
+		middleware.NewRateLimit(ratelimit.NewMemoryStore(), ratelimit.GROUP_SEARCH, model.RateLimitRule{}, ""),
+		handler.NewProperty(propertyService),
+		handler.NewAmenity(service.NewAmenity(amenityRepo, uow)),
+		handler.NewRoom(service.NewRoom(roomRepo, reservationRepo, amenityRepo, uow)),
+		handler.NewReservation(service.NewReservation(reservationRepo, roomRepo, uow)),
+	)
+
+	return router
+}
+
+// token signs a token for an account limited to property, or for one that
+// can use every property when property is uuid.Nil.
+func token(t *testing.T, property uuid.UUID) string {
+	t.Helper()
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &model.Account{ID: uuid.New(), Property: property}).SignedString(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+// call sends a request and checks its status. The data of the response is
+// decoded into data unless it's nil.
+func call(t *testing.T, router *gin.Engine, token string, method string, path string, body string, code int, data any) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != code {
+		t.Fatalf("%s %s: expected %d, got %d %s", method, path, code, w.Code, w.Body.String())
+	}
+	if data == nil {
+		return
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(envelope.Data, data); err != nil {
+		t.Fatalf("%s %s: unexpected data %s", method, path, envelope.Data)
+	}
+}
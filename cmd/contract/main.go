@@ -0,0 +1,95 @@
+// Command contract runs the repository contract cases against a storage
+// backend and exits with a non-zero status when any of them fails.
+//
+//	go run ./cmd/contract -driver memory
+//	go run ./cmd/contract -driver postgres -dsn "$DB_CONNECTION"
+//	go run ./cmd/contract -driver sqlite -dsn booking.db
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/demkowo/booking/repositories/contract"
+	"github.com/demkowo/booking/repositories/memory"
+	"github.com/demkowo/booking/repositories/postgres"
+	"github.com/demkowo/booking/repositories/sqlite"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+)
+
+func main() {
+	driver := flag.String("driver", "memory", "storage backend: memory, postgres or sqlite")
+	dsn := flag.String("dsn", os.Getenv("DB_CONNECTION"), "database connection string")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	var backend contract.Backend
+	switch *driver {
+	case "memory":
+		store := memory.NewStore()
+		backend = contract.Backend{
+			Properties:   memory.NewProperty(store),
+			Rooms:        memory.NewRoom(store),
+			Reservations: memory.NewReservation(store),
+			Amenities:    memory.NewAmenity(store),
+			UnitOfWork:   memory.NewUnitOfWork(store),
+		}
+	case "postgres":
+		db, err := sqlclient.Open("postgres", *dsn)
+		if err != nil {
+			log.Fatalf("sqlclient.Open failed: %v", err)
+		}
+		defer db.Close()
+
+		backend = contract.Backend{
+			Properties:   postgres.NewProperty(db),
+			Rooms:        postgres.NewRoom(db),
+			Reservations: postgres.NewReservation(db),
+			Amenities:    postgres.NewAmenity(db),
+			UnitOfWork:   postgres.NewUnitOfWork(db),
+		}
+		backend.Properties.CreateTableProperties(ctx)
+		backend.Rooms.CreateTableRooms(ctx)
+		backend.Reservations.CreateTableReservations(ctx)
+		backend.Amenities.CreateTableAmenities(ctx)
+	case "sqlite":
+		db, err := sqlclient.Open(sqlite.DRIVER_NAME, sqlite.DSN(*dsn))
+		if err != nil {
+			log.Fatalf("sqlclient.Open failed: %v", err)
+		}
+		defer db.Close()
+
+		backend = contract.Backend{
+			Properties:   sqlite.NewProperty(db),
+			Rooms:        sqlite.NewRoom(db),
+			Reservations: sqlite.NewReservation(db),
+			Amenities:    sqlite.NewAmenity(db),
+			UnitOfWork:   sqlite.NewUnitOfWork(db),
+		}
+		backend.Properties.CreateTableProperties(ctx)
+		backend.Rooms.CreateTableRooms(ctx)
+		backend.Reservations.CreateTableReservations(ctx)
+		backend.Amenities.CreateTableAmenities(ctx)
+	default:
+		log.Fatalf("unknown driver %q", *driver)
+	}
+
+	failed := 0
+	for _, result := range contract.Run(ctx, backend) {
+		if result.Err != nil {
+			failed++
+			fmt.Printf("FAIL %s: %v\n", result.Name, result.Err)
+			continue
+		}
+		fmt.Printf("ok   %s\n", result.Name)
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d of %d cases failed\n", failed, len(contract.Cases()))
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,36 @@
+// Command problemsdoc writes the catalog of API error types defined in
+// utils/errs as a Markdown document.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/demkowo/booking/utils/errs"
+)
+
+func main() {
+	output := flag.String("o", "docs/problems.md", "output file")
+	flag.Parse()
+
+	var doc bytes.Buffer
+	doc.WriteString("# Error Types\n\n")
+	doc.WriteString("<!-- Code generated by cmd/problemsdoc from utils/errs.Catalog. DO NOT EDIT. -->\n\n")
+	doc.WriteString("Errors are returned as RFC 7807 `application/problem+json` documents when the client accepts them, ")
+	doc.WriteString("otherwise as the JSON response envelope. The `type` member of a problem document is one of the URIs below.\n\n")
+	doc.WriteString("| Type | Status | Title | Description |\n")
+	doc.WriteString("|------|--------|-------|-------------|\n")
+
+	for _, problemType := range errs.Catalog {
+		fmt.Fprintf(&doc, "| `%s` | %d | %s | %s |\n", problemType.Type, problemType.Status, problemType.Title, problemType.Description)
+	}
+
+	doc.WriteString("\nStatuses without an entry are reported with the `about:blank` type.\n")
+
+	if err := os.WriteFile(*output, doc.Bytes(), 0644); err != nil {
+		log.Fatalf("writing %s failed: %v", *output, err)
+	}
+}
@@ -0,0 +1,529 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/deadline"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/ratelimit"
+	"github.com/demkowo/booking/utils/resp"
+	"github.com/demkowo/booking/utils/tracing"
+	worker "github.com/demkowo/booking/workers"
+)
+
+const (
+	DB_DRIVER_MEMORY   = "memory"
+	DB_DRIVER_POSTGRES = "postgres"
+	DB_DRIVER_SQLITE   = "sqlite"
+
+	DEFAULT_CONFIG_FILE = "config.yaml"
+	DEFAULT_SQLITE_PATH = "booking.db"
+)
+
+// setting is one value that can be overridden from the environment and, when
+// flag is set, from the command line.
+type setting struct {
+	env   string
+	flag  string
+	usage string
+	set   func(*model.ConfigStruct, string) error
+}
+
+var settings = []setting{
+	{"PORT", "port", "HTTP port", func(c *model.ConfigStruct, v string) error { return parseInt(v, &c.Server.Port) }},
+	{"ERROR_FORMAT", "error-format", "error body: envelope, problem or negotiate", func(c *model.ConfigStruct, v string) error { c.Server.ErrorFormat = v; return nil }},
+	{"SHUTDOWN_DELAY", "shutdown-delay", "how long the service reports not ready before it stops accepting requests", func(c *model.ConfigStruct, v string) error { return parseDuration(v, &c.Server.ShutdownDelay) }},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long in-flight requests may take to finish on shutdown", func(c *model.ConfigStruct, v string) error { return parseDuration(v, &c.Server.ShutdownTimeout) }},
+	{"TRUSTED_PROXIES", "trusted-proxies", "comma separated proxy addresses or CIDRs whose X-Forwarded-For is trusted", func(c *model.ConfigStruct, v string) error { c.Server.TrustedProxies = splitList(v); return nil }},
+
+	{"DB_DRIVER", "db-driver", "storage backend: postgres, sqlite or memory", func(c *model.ConfigStruct, v string) error { c.Database.Driver = v; return nil }},
+	{"DB_CONNECTION", "db-dsn", "database connection string, or file path for sqlite", func(c *model.ConfigStruct, v string) error { c.Database.DSN = v; return nil }},
+	{"DB_MAX_OPEN_CONNS", "db-max-open-conns", "maximum open database connections, 0 is unlimited", func(c *model.ConfigStruct, v string) error { return parseInt(v, &c.Database.MaxOpenConns) }},
+	{"DB_MAX_IDLE_CONNS", "db-max-idle-conns", "maximum idle database connections", func(c *model.ConfigStruct, v string) error { return parseInt(v, &c.Database.MaxIdleConns) }},
+	{"DB_CONN_MAX_LIFETIME", "db-conn-max-lifetime", "how long a database connection may be reused, 0 is forever", func(c *model.ConfigStruct, v string) error { return parseDuration(v, &c.Database.ConnMaxLifetime) }},
+	{"QUERY_TIMEOUT", "query-timeout", "default database operation timeout", func(c *model.ConfigStruct, v string) error { return parseDuration(v, &c.Database.QueryTimeout) }},
+	{"QUERY_TIMEOUTS", "query-timeouts", "per operation timeouts, op=duration,...", func(c *model.ConfigStruct, v string) error {
+		timeouts, err := deadline.Parse(v)
+		if err != nil {
+			return err
+		}
+		c.Database.QueryTimeouts = timeouts
+		return nil
+	}},
+
+	{"LOG_LEVEL", "log-level", "log level, 0 (panic) to 6 (trace) or its name", func(c *model.ConfigStruct, v string) error { return parseLevel(v, &c.Logrus.Level) }},
+	{"LOG_FORMAT", "log-format", "log format: text, json or custom", func(c *model.ConfigStruct, v string) error { c.Logrus.Format = v; return nil }},
+	{"LOG_OUTPUT", "log-output", "comma separated log outputs: stdout, file", func(c *model.ConfigStruct, v string) error { c.Logrus.Output = splitList(v); return nil }},
+	{"LOG_PATH", "log-path", "log file path", func(c *model.ConfigStruct, v string) error { c.Logrus.Path = v; return nil }},
+	{"LOG_REPORTER", "log-reporter", "report the calling function in log entries", func(c *model.ConfigStruct, v string) error { return parseBool(v, &c.Logrus.Reporter) }},
+	{"LOG_LEVELS", "log-levels", "per package log levels, package=level,...", func(c *model.ConfigStruct, v string) error { return parseMap(v, &c.Logrus.Levels) }},
+	{"LOG_MAX_SIZE_MB", "log-max-size-mb", "size in megabytes at which the log file is rotated", func(c *model.ConfigStruct, v string) error { return parseInt(v, &c.Logrus.MaxSizeMB) }},
+	{"LOG_MAX_AGE_DAYS", "log-max-age-days", "days rotated log files are kept, 0 keeps them", func(c *model.ConfigStruct, v string) error { return parseInt(v, &c.Logrus.MaxAgeDays) }},
+	{"LOG_MAX_BACKUPS", "log-max-backups", "rotated log files kept, 0 keeps all", func(c *model.ConfigStruct, v string) error { return parseInt(v, &c.Logrus.MaxBackups) }},
+	{"LOG_COMPRESS", "log-compress", "gzip rotated log files", func(c *model.ConfigStruct, v string) error { return parseBool(v, &c.Logrus.Compress) }},
+	{"LOG_ROTATE_EVERY", "log-rotate-every", "rotate the log file this often regardless of its size, 0 disables", func(c *model.ConfigStruct, v string) error { return parseDuration(v, &c.Logrus.RotateEvery) }},
+	{"LOG_REDACT", "log-redact", "comma separated field names whose values are hidden in logs", func(c *model.ConfigStruct, v string) error { c.Logrus.Redact = splitList(v); return nil }},
+
+	{"AUTH_ENABLED", "auth-enabled", "require a signed JWT on API requests", func(c *model.ConfigStruct, v string) error { return parseBool(v, &c.Auth.Enabled) }},
+	{"JWT_SECRET", "", "", func(c *model.ConfigStruct, v string) error { c.Auth.JWTSecret = v; return nil }},
+
+	{"RETENTION_PERIOD", "retention-period", "how long deleted reservations are kept", func(c *model.ConfigStruct, v string) error { return parseDuration(v, &c.Retention.Period) }},
+	{"RETENTION_INTERVAL", "retention-interval", "how often the retention job runs", func(c *model.ConfigStruct, v string) error { return parseDuration(v, &c.Retention.Interval) }},
+	{"RETENTION_MODE", "retention-mode", "anonymize or delete", func(c *model.ConfigStruct, v string) error { c.Retention.Mode = v; return nil }},
+
+	{"IDEMPOTENCY_TTL", "idempotency-ttl", "how long idempotent responses are replayed", func(c *model.ConfigStruct, v string) error { return parseDuration(v, &c.Idempotency.TTL) }},
+	{"IDEMPOTENCY_MAX_BODY_BYTES", "idempotency-max-body-bytes", "largest request body accepted with an Idempotency-Key", func(c *model.ConfigStruct, v string) error { return parseInt(v, &c.Idempotency.MaxBodyBytes) }},
+
+	{"RATE_LIMIT_ENABLED", "rate-limit-enabled", "limit the requests of each client", func(c *model.ConfigStruct, v string) error { return parseBool(v, &c.RateLimit.Enabled) }},
+	{"RATE_LIMIT_API_KEY_HEADER", "rate-limit-api-key-header", "header identifying API key clients, empty limits them by address", func(c *model.ConfigStruct, v string) error { c.RateLimit.APIKeyHeader = v; return nil }},
+	{"RATE_LIMITS", "rate-limits", "per route group limits, group=requests/period,...", func(c *model.ConfigStruct, v string) error {
+		limits, err := ratelimit.Parse(v)
+		if err != nil {
+			return err
+		}
+		for group, rule := range limits {
+			c.RateLimit.Limits[group] = rule
+		}
+		return nil
+	}},
+
+	{"CORS_ALLOW_ORIGINS", "cors-allow-origins", "comma separated origins allowed to call the API from a browser, * wildcards allowed", func(c *model.ConfigStruct, v string) error { c.CORS.AllowOrigins = splitList(v); return nil }},
+	{"CORS_ALLOW_METHODS", "cors-allow-methods", "comma separated methods allowed in cross-origin requests", func(c *model.ConfigStruct, v string) error { c.CORS.AllowMethods = splitList(v); return nil }},
+	{"CORS_ALLOW_HEADERS", "cors-allow-headers", "comma separated headers allowed in cross-origin requests", func(c *model.ConfigStruct, v string) error { c.CORS.AllowHeaders = splitList(v); return nil }},
+	{"CORS_EXPOSE_HEADERS", "cors-expose-headers", "comma separated response headers readable by the browser", func(c *model.ConfigStruct, v string) error { c.CORS.ExposeHeaders = splitList(v); return nil }},
+	{"CORS_ALLOW_CREDENTIALS", "cors-allow-credentials", "allow cookies and Authorization in cross-origin requests", func(c *model.ConfigStruct, v string) error { return parseBool(v, &c.CORS.AllowCredentials) }},
+	{"CORS_MAX_AGE", "cors-max-age", "how long browsers cache preflight responses", func(c *model.ConfigStruct, v string) error { return parseDuration(v, &c.CORS.MaxAge) }},
+
+	{"TRACING_EXPORTER", "tracing-exporter", "trace exporter: none, otlp, file or stdout", func(c *model.ConfigStruct, v string) error { c.Tracing.Exporter = v; return nil }},
+	{"TRACING_ENDPOINT", "tracing-endpoint", "OTLP/HTTP traces URL the otlp exporter sends spans to", func(c *model.ConfigStruct, v string) error { c.Tracing.Endpoint = v; return nil }},
+	{"TRACING_PATH", "tracing-path", "file the file exporter writes spans to, as OTLP JSON lines", func(c *model.ConfigStruct, v string) error { c.Tracing.Path = v; return nil }},
+	{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "share of new traces that are recorded, 0 to 1", func(c *model.ConfigStruct, v string) error { return parseFloat(v, &c.Tracing.SampleRatio) }},
+	{"TRACING_SERVICE_NAME", "tracing-service-name", "service.name resource attribute of the spans", func(c *model.ConfigStruct, v string) error { c.Tracing.ServiceName = v; return nil }},
+}
+
+func Default() *model.ConfigStruct {
+	return &model.ConfigStruct{
+		Server: model.ServerConfig{
+			Port:            5000,
+			ErrorFormat:     resp.ERROR_FORMAT_NEGOTIATE,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: model.DatabaseConfig{
+			Driver:          DB_DRIVER_POSTGRES,
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			QueryTimeout:    deadline.DEFAULT_TIMEOUT,
+		},
+		Logrus: model.LogrusConfig{
+			Output:      []string{"stdout", "file"},
+			Reporter:    true,
+			Format:      "custom",
+			Path:        "log.log",
+			Level:       int(log.TraceLevel),
+			MaxSizeMB:   100,
+			MaxAgeDays:  30,
+			MaxBackups:  10,
+			Compress:    true,
+			RotateEvery: 24 * time.Hour,
+			Redact:      logger.DEFAULT_REDACT,
+		},
+		Retention: model.RetentionConfig{
+			Period:   90 * 24 * time.Hour,
+			Interval: time.Hour,
+			Mode:     worker.RETENTION_ANONYMIZE,
+		},
+		Idempotency: model.IdempotencyConfig{
+			TTL:          24 * time.Hour,
+			MaxBodyBytes: 1 << 20,
+		},
+		Tracing: model.TracingConfig{
+			Exporter:    tracing.EXPORTER_NONE,
+			Endpoint:    "http://localhost:4318/v1/traces",
+			Path:        "traces.jsonl",
+			SampleRatio: 1,
+			ServiceName: "booking",
+		},
+		RateLimit: model.RateLimitConfig{
+			Enabled: true,
+			Limits: map[string]model.RateLimitRule{
+				ratelimit.GROUP_API:    {Requests: 300, Period: time.Minute},
+				ratelimit.GROUP_SEARCH: {Requests: 30, Period: time.Minute, Burst: 10},
+				ratelimit.GROUP_ADMIN:  {Requests: 30, Period: time.Minute},
+			},
+		},
+		CORS: model.CORSConfig{
+			AllowMethods:  []string{"GET", "POST", "PUT", "DELETE"},
+			AllowHeaders:  []string{"Authorization", "Content-Type", "If-Match", "Idempotency-Key", "X-Request-ID"},
+			ExposeHeaders: []string{"ETag", "Location", "X-Request-ID", "Idempotent-Replayed", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After"},
+			MaxAge:        12 * time.Hour,
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, the YAML file, the
+// environment and the command line flags in args, each overriding the one
+// before. The result is validated and stored in model.Config and Values.
+func Load(args []string) (*model.ConfigStruct, error) {
+	log.Trace()
+
+	flags := flag.NewFlagSet("booking", flag.ContinueOnError)
+	file := flags.String("config", "", "YAML configuration file, "+DEFAULT_CONFIG_FILE+" when present")
+	values := map[string]*string{}
+	for _, s := range settings {
+		if s.flag != "" {
+			values[s.flag] = flags.String(s.flag, "", s.usage+" ("+s.env+")")
+		}
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+
+	if err := readFile(cfg, *file); err != nil {
+		return nil, err
+	}
+
+	if dsn, exist := os.LookupEnv("DB_DELMAJK"); exist {
+		log.Warn("DB_DELMAJK is deprecated, use DB_CONNECTION")
+		cfg.Database.DSN = dsn
+	}
+	for _, s := range settings {
+		if value, exist := os.LookupEnv(s.env); exist {
+			if err := s.set(cfg, value); err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", s.env, value, err)
+			}
+		}
+	}
+
+	var flagErr error
+	flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				if err := s.set(cfg, *values[f.Name]); err != nil {
+					flagErr = fmt.Errorf("invalid -%s %q: %w", f.Name, f.Value, err)
+				}
+			}
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if cfg.Database.Driver == DB_DRIVER_SQLITE && cfg.Database.DSN == "" {
+		cfg.Database.DSN = DEFAULT_SQLITE_PATH
+	}
+
+	if err := Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	model.Config.Set(cfg)
+	Values.Set(conf{
+		InProduction: os.Getenv("ENV") == "prod",
+		JWTSecret:    []byte(cfg.Auth.JWTSecret),
+	})
+
+	return cfg, nil
+}
+
+// Validate reports every invalid setting at once.
+func Validate(cfg *model.ConfigStruct) error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if cfg.Server.Port < 1 || cfg.Server.Port > 65535 {
+		add("server.port %d is out of range", cfg.Server.Port)
+	}
+	switch cfg.Server.ErrorFormat {
+	case resp.ERROR_FORMAT_ENVELOPE, resp.ERROR_FORMAT_PROBLEM, resp.ERROR_FORMAT_NEGOTIATE:
+	default:
+		add("server.error_format %q, expected %q, %q or %q", cfg.Server.ErrorFormat, resp.ERROR_FORMAT_ENVELOPE, resp.ERROR_FORMAT_PROBLEM, resp.ERROR_FORMAT_NEGOTIATE)
+	}
+	if cfg.Server.ShutdownDelay < 0 {
+		add("server.shutdown_delay must not be negative")
+	}
+	if cfg.Server.ShutdownTimeout <= 0 {
+		add("server.shutdown_timeout must be positive")
+	}
+	for _, proxy := range cfg.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			add("server.trusted_proxies %q, expected an IP address or CIDR", proxy)
+		}
+	}
+
+	switch cfg.Database.Driver {
+	case DB_DRIVER_POSTGRES:
+		if cfg.Database.DSN == "" {
+			add("database.dsn is required for the %s driver", DB_DRIVER_POSTGRES)
+		}
+	case DB_DRIVER_SQLITE, DB_DRIVER_MEMORY:
+	default:
+		add("database.driver %q, expected %q, %q or %q", cfg.Database.Driver, DB_DRIVER_POSTGRES, DB_DRIVER_SQLITE, DB_DRIVER_MEMORY)
+	}
+	if cfg.Database.MaxOpenConns < 0 {
+		add("database.max_open_conns must not be negative")
+	}
+	if cfg.Database.MaxIdleConns < 0 {
+		add("database.max_idle_conns must not be negative")
+	}
+	if cfg.Database.ConnMaxLifetime < 0 {
+		add("database.conn_max_lifetime must not be negative")
+	}
+	if cfg.Database.QueryTimeout <= 0 {
+		add("database.query_timeout must be positive")
+	}
+	for operation, timeout := range cfg.Database.QueryTimeouts {
+		if timeout <= 0 {
+			add("database.query_timeouts.%s must be positive", operation)
+		}
+	}
+
+	if cfg.Logrus.Level < int(log.PanicLevel) || cfg.Logrus.Level > int(log.TraceLevel) {
+		add("logrus.level %d, expected 0 to 6", cfg.Logrus.Level)
+	}
+	switch cfg.Logrus.Format {
+	case "text", "json":
+	case "custom":
+		if !cfg.Logrus.Reporter {
+			add("logrus.format custom needs logrus.reporter")
+		}
+	default:
+		add("logrus.format %q, expected text, json or custom", cfg.Logrus.Format)
+	}
+	for _, output := range cfg.Logrus.Output {
+		switch output {
+		case "stdout":
+		case "file":
+			if cfg.Logrus.Path == "" {
+				add("logrus.path is required for the file output")
+			}
+		default:
+			add("logrus.output %q, expected stdout or file", output)
+		}
+	}
+	for pkg, level := range cfg.Logrus.Levels {
+		var parsed int
+		if err := parseLevel(level, &parsed); err != nil || parsed < int(log.PanicLevel) || parsed > int(log.TraceLevel) {
+			add("logrus.levels.%s %q, expected 0 to 6 or a level name", pkg, level)
+		}
+	}
+	if len(cfg.Logrus.Levels) > 0 && !cfg.Logrus.Reporter {
+		add("logrus.levels needs logrus.reporter")
+	}
+	if cfg.Logrus.MaxSizeMB <= 0 {
+		add("logrus.max_size_mb must be positive")
+	}
+	if cfg.Logrus.MaxAgeDays < 0 {
+		add("logrus.max_age_days must not be negative")
+	}
+	if cfg.Logrus.MaxBackups < 0 {
+		add("logrus.max_backups must not be negative")
+	}
+	if cfg.Logrus.RotateEvery < 0 {
+		add("logrus.rotate_every must not be negative")
+	}
+
+	if cfg.Auth.Enabled && len(cfg.Auth.JWTSecret) < 32 {
+		add("auth.jwt_secret must be at least 32 characters when auth is enabled")
+	}
+
+	if cfg.Retention.Period <= 0 {
+		add("retention.period must be positive")
+	}
+	if cfg.Retention.Interval <= 0 {
+		add("retention.interval must be positive")
+	}
+	if cfg.Retention.Mode != worker.RETENTION_ANONYMIZE && cfg.Retention.Mode != worker.RETENTION_DELETE {
+		add("retention.mode %q, expected %q or %q", cfg.Retention.Mode, worker.RETENTION_ANONYMIZE, worker.RETENTION_DELETE)
+	}
+
+	if cfg.Idempotency.TTL <= 0 {
+		add("idempotency.ttl must be positive")
+	}
+	if cfg.Idempotency.MaxBodyBytes <= 0 {
+		add("idempotency.max_body_bytes must be positive")
+	}
+
+	switch cfg.Tracing.Exporter {
+	case tracing.EXPORTER_NONE, tracing.EXPORTER_STDOUT:
+	case tracing.EXPORTER_OTLP:
+		if u, err := url.Parse(cfg.Tracing.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("tracing.endpoint %q, expected an http or https URL for the %s exporter", cfg.Tracing.Endpoint, tracing.EXPORTER_OTLP)
+		}
+	case tracing.EXPORTER_FILE:
+		if cfg.Tracing.Path == "" {
+			add("tracing.path is required for the %s exporter", tracing.EXPORTER_FILE)
+		}
+	default:
+		add("tracing.exporter %q, expected %q, %q, %q or %q", cfg.Tracing.Exporter, tracing.EXPORTER_NONE, tracing.EXPORTER_OTLP, tracing.EXPORTER_FILE, tracing.EXPORTER_STDOUT)
+	}
+	if cfg.Tracing.SampleRatio < 0 || cfg.Tracing.SampleRatio > 1 {
+		add("tracing.sample_ratio %g, expected 0 to 1", cfg.Tracing.SampleRatio)
+	}
+
+	for group, rule := range cfg.RateLimit.Limits {
+		if !slices.Contains(ratelimit.Groups, group) {
+			add("rate_limit.limits.%s, expected one of %s", group, strings.Join(ratelimit.Groups, ", "))
+		}
+		if rule.Requests < 0 {
+			add("rate_limit.limits.%s.requests must not be negative", group)
+		}
+		if rule.Requests > 0 && rule.Period <= 0 {
+			add("rate_limit.limits.%s.period must be positive", group)
+		}
+		if rule.Burst < 0 {
+			add("rate_limit.limits.%s.burst must not be negative", group)
+		}
+	}
+
+	for _, origin := range cfg.CORS.AllowOrigins {
+		switch {
+		case origin == "*":
+			if cfg.CORS.AllowCredentials {
+				add("cors.allow_origins * can't be combined with cors.allow_credentials")
+			}
+		case strings.Count(origin, "*") > 1:
+			add("cors.allow_origins %q, only one * is allowed", origin)
+		case !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://"):
+			add("cors.allow_origins %q, expected http:// or https://", origin)
+		}
+	}
+	if cfg.CORS.MaxAge < 0 {
+		add("cors.max_age must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration:\n  " + strings.Join(problems, "\n  "))
+	}
+
+	return nil
+}
+
+// readFile applies the YAML file at path. Without an explicit path the
+// CONFIG_FILE variable and then config.yaml are tried, and a missing default
+// file is not an error.
+func readFile(cfg *model.ConfigStruct, path string) error {
+	explicit := path != ""
+	if !explicit {
+		path, explicit = os.LookupEnv("CONFIG_FILE")
+	}
+	if path == "" {
+		path = DEFAULT_CONFIG_FILE
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return nil
+		}
+		return fmt.Errorf("reading %s failed: %w", path, err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing %s failed: %w", path, err)
+	}
+
+	return nil
+}
+
+func parseInt(value string, target *int) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	*target = n
+
+	return nil
+}
+
+func parseBool(value string, target *bool) error {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	*target = b
+
+	return nil
+}
+
+func parseFloat(value string, target *float64) error {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return err
+	}
+	*target = f
+
+	return nil
+}
+
+// parseMap reads "key=value" pairs separated by commas.
+func parseMap(value string, target *map[string]string) error {
+	parsed := map[string]string{}
+	for _, pair := range splitList(value) {
+		key, v, found := strings.Cut(pair, "=")
+		if !found {
+			return fmt.Errorf("invalid pair %q, expected key=value", pair)
+		}
+		parsed[strings.TrimSpace(key)] = strings.TrimSpace(v)
+	}
+	*target = parsed
+
+	return nil
+}
+
+func parseDuration(value string, target *time.Duration) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*target = d
+
+	return nil
+}
+
+func parseLevel(value string, target *int) error {
+	if n, err := strconv.Atoi(value); err == nil {
+		*target = n
+		return nil
+	}
+
+	level, err := log.ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	*target = int(level)
+
+	return nil
+}
+
+func splitList(value string) []string {
+	list := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
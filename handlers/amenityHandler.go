@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	model "github.com/demkowo/booking/models"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/resp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type Amenity interface {
+	CreateTableAmenities()
+
+	Add(*gin.Context)
+	Delete(*gin.Context)
+	Find(*gin.Context)
+	GetById(*gin.Context)
+	Update(*gin.Context)
+}
+
+type amenity struct {
+	service service.Amenity
+}
+
+// AmenityInput values list the options of an enum amenity.
+type AmenityInput struct {
+	Key    string   `json:"key" binding:"required,amenity_key,max=64"`
+	Name   string   `json:"name" binding:"required,notblank,max=255"`
+	Type   string   `json:"type" binding:"required,amenity_type"`
+	Values []string `json:"values" binding:"max=100,dive,notblank,max=64"`
+}
+
+type AmenityUpdateInput struct {
+	Name   string   `json:"name" binding:"required,notblank,max=255"`
+	Values []string `json:"values" binding:"max=100,dive,notblank,max=64"`
+}
+
+func NewAmenity(service service.Amenity) Amenity {
+	log.Trace()
+
+	return &amenity{
+		service: service,
+	}
+}
+
+func (h *amenity) CreateTableAmenities() {
+	log.Trace()
+
+	res := h.service.CreateTableAmenities(context.Background())
+	log.Info(res)
+}
+
+func (h *amenity) Add(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	var input AmenityInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	amenity := &model.Amenity{
+		Id:      uuid.New(),
+		Key:     input.Key,
+		Name:    input.Name,
+		Type:    input.Type,
+		Values:  values(input.Values),
+		Created: time.Now(),
+		Updated: time.Now(),
+	}
+
+	if err := h.service.Add(c.Request.Context(), amenity); err != nil {
+		logFor(c).Errorf("Failed to add amenity: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, amenity.Version)
+	resp.Send(c, http.StatusOK, "amenity created", amenity)
+}
+
+func (h *amenity) Delete(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("amenity_id", c.Param("amenity_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		logFor(c).Errorf("Failed to delete amenity: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "amenity deleted", nil)
+}
+
+func (h *amenity) Find(c *gin.Context) {
+	logFor(c).Trace()
+
+	amenities, err := h.service.Find(c.Request.Context())
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "amenities found", amenities)
+}
+
+func (h *amenity) GetById(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("amenity_id", c.Param("amenity_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	amenity, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, amenity.Version)
+	resp.Send(c, http.StatusOK, "amenity found", amenity)
+}
+
+func (h *amenity) Update(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("amenity_id", c.Param("amenity_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	version, err := ifMatch(c, func() (int, *errs.Error) {
+		stored, err := h.service.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return 0, err
+		}
+		return stored.Version, nil
+	})
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	var input AmenityUpdateInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	amenity := &model.Amenity{
+		Id:      id,
+		Name:    input.Name,
+		Values:  values(input.Values),
+		Updated: time.Now(),
+		Version: version,
+	}
+
+	if err := h.service.Update(c.Request.Context(), amenity); err != nil {
+		logFor(c).Errorf("Failed to update amenity: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, amenity.Version)
+	resp.Send(c, http.StatusOK, "amenity updated", amenity)
+}
+
+func values(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+
+	return list
+}
+
+// scalar formats a JSON boolean, number or string the way amenity values
+// are compared, or returns false for other JSON values.
+func scalar(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case string:
+		return v, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/repositories/memory"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+// testPropertyHeader scopes requests to another property than the default
+// one, in place of the Tenant middleware.
+const testPropertyHeader = "X-Test-Property"
+
+type envelope struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Code    int             `json:"code"`
+	Status  string          `json:"status"`
+	Data    json.RawMessage `json:"data"`
+	Causes  []errs.Cause    `json:"causes"`
+}
+
+func TestResponseEnvelope(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		code    int
+		status  string
+		members []string
+	}{
+		{name: "success", method: http.MethodPost, path: "/rooms/add", body: `{"name":"Blue"}`, code: 200, status: "OK",
+			members: []string{"success", "message", "code", "status", "data"}},
+		{name: "error", method: http.MethodGet, path: "/rooms/00000000-0000-0000-0000-0000000000ff", code: 404, status: "Not Found",
+			members: []string{"success", "message", "code", "status"}},
+		{name: "error with causes", method: http.MethodGet, path: "/rooms/x", code: 400, status: "Bad Request",
+			members: []string{"success", "message", "code", "status", "causes"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := send(t, router, tt.method, tt.path, tt.body)
+			if w.Code != tt.code || body.Code != tt.code || body.Status != tt.status || body.Success != (tt.code == 200) || body.Message == "" {
+				t.Fatalf("expected %d %s, got %d %+v", tt.code, tt.status, w.Code, body)
+			}
+			if got := w.Header().Get("Content-Type"); !strings.HasPrefix(got, "application/json") {
+				t.Fatalf("expected a JSON response, got %q", got)
+			}
+
+			var members map[string]json.RawMessage
+			if err := json.Unmarshal(w.Body.Bytes(), &members); err != nil {
+				t.Fatal(err)
+			}
+			if len(members) != len(tt.members) {
+				t.Fatalf("expected the members %v, got %s", tt.members, w.Body.String())
+			}
+			for _, member := range tt.members {
+				if _, exist := members[member]; !exist {
+					t.Fatalf("expected the member %q, got %s", member, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if err := RegisterValidators(); err != nil {
+		t.Fatal(err)
+	}
+
+	store := memory.NewStore()
+	uow := memory.NewUnitOfWork(store)
+	roomRepo := memory.NewRoom(store)
+	reservationRepo := memory.NewReservation(store)
+	amenityRepo := memory.NewAmenity(store)
+	amenities := NewAmenity(service.NewAmenity(amenityRepo, uow))
+	rooms := NewRoom(service.NewRoom(roomRepo, reservationRepo, amenityRepo, uow))
+	reservations := NewReservation(service.NewReservation(reservationRepo, roomRepo, uow))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		property := model.DEFAULT_PROPERTY
+		if header := c.GetHeader(testPropertyHeader); header != "" {
+			property = uuid.MustParse(header)
+		}
+		c.Request = c.Request.WithContext(tenant.WithProperty(c.Request.Context(), property))
+		c.Next()
+	})
+	router.POST("/rooms/add", rooms.Add)
+	router.GET("/rooms/:room_id", rooms.GetById)
+	router.PUT("/rooms/:room_id", rooms.Update)
+	router.PUT("/rooms/:room_id/amenities", rooms.SetAmenities)
+	router.POST("/rooms/:room_id/availability-check", rooms.CheckIfAvailableById)
+	router.POST("/amenities/add", amenities.Add)
+	router.DELETE("/amenities/:amenity_id", amenities.Delete)
+	router.POST("/reservations/add", reservations.Add)
+	router.GET("/reservations/:reservation_id", reservations.GetById)
+	router.PUT("/reservations/:reservation_id", reservations.Update)
+
+	return router
+}
+
+func send(t *testing.T, router *gin.Engine, method string, path string, body string) (*httptest.ResponseRecorder, envelope) {
+	t.Helper()
+
+	return sendWithHeaders(t, router, method, path, body, nil)
+}
+
+func sendWithHeaders(t *testing.T, router *gin.Engine, method string, path string, body string, headers map[string]string) (*httptest.ResponseRecorder, envelope) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var res envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("%s %s: response isn't a single JSON envelope %q: %v", method, path, w.Body.String(), err)
+	}
+
+	return w, res
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/demkowo/booking/utils/errs"
+)
+
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", fmt.Sprintf("%q", strconv.Itoa(version)))
+}
+
+// ifMatch returns the version the client expects to overwrite, or 0 when
+// the If-Match header is "*" and any version may be replaced. If-Match uses
+// the strong comparison, so weak tags never match. When the header lists more
+// than one tag, current is asked for the stored version to pick the matching
+// one.
+func ifMatch(c *gin.Context, current func() (int, *errs.Error)) (int, *errs.Error) {
+	logFor(c).Trace()
+
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return 0, errs.NewError("If-Match header is required", 428, "Precondition Required", nil)
+	}
+
+	if header == "*" {
+		return 0, nil
+	}
+
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		if version, ok := strongVersion(tag); ok {
+			versions = append(versions, version)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		return 0, errs.NewError("If-Match header doesn't match any version", 412, "Precondition Failed", nil)
+	case 1:
+		return versions[0], nil
+	}
+
+	version, err := current()
+	if err != nil {
+		return 0, err
+	}
+	if !slices.Contains(versions, version) {
+		return 0, errs.NewError("If-Match header doesn't match any version", 412, "Precondition Failed", nil)
+	}
+
+	return version, nil
+}
+
+func strongVersion(tag string) (int, bool) {
+	tag = strings.TrimSpace(tag)
+	if len(tag) < 2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+)
+
+func TestIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	current := func() (int, *errs.Error) { return 3, nil }
+	tests := []struct {
+		name    string
+		header  string
+		version int
+		code    int
+	}{
+		{name: "missing", header: "", code: 428},
+		{name: "any", header: "*", version: 0},
+		{name: "strong", header: `"2"`, version: 2},
+		{name: "weak", header: `W/"2"`, code: 412},
+		{name: "unquoted", header: "2", code: 412},
+		{name: "list with current", header: `"1", "3"`, version: 3},
+		{name: "list with weak current", header: `"1", W/"3"`, version: 1},
+		{name: "list without current", header: `"1", "2"`, code: 412},
+		{name: "garbage", header: `"x"`, code: 412},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+			if tt.header != "" {
+				c.Request.Header.Set("If-Match", tt.header)
+			}
+
+			version, err := ifMatch(c, current)
+			if tt.code != 0 {
+				if err == nil || err.Code != tt.code {
+					t.Fatalf("expected %d, got version %d and error %+v", tt.code, version, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %+v", err)
+			}
+			if version != tt.version {
+				t.Fatalf("expected version %d, got %d", tt.version, version)
+			}
+		})
+	}
+}
+
+func TestRoomUpdateChecksIfMatch(t *testing.T) {
+	router := newTestRouter(t)
+
+	w, body := send(t, router, http.MethodPost, "/rooms/add", `{"name":"Blue"}`)
+	if got := w.Header().Get("ETag"); got != `"1"` {
+		t.Fatalf("Add: expected ETag \"1\", got %q", got)
+	}
+	var room model.Room
+	if err := json.Unmarshal(body.Data, &room); err != nil {
+		t.Fatal(err)
+	}
+	path := "/rooms/" + room.Id.String()
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		code    int
+		etag    string
+	}{
+		{name: "without If-Match", code: 428},
+		{name: "weak tag", ifMatch: `W/"1"`, code: 412},
+		{name: "current version", ifMatch: `"1"`, code: 200, etag: `"2"`},
+		{name: "stale version", ifMatch: `"1"`, code: 412},
+		{name: "list with the current version", ifMatch: `"1", "2"`, code: 200, etag: `"3"`},
+		{name: "any version", ifMatch: "*", code: 200, etag: `"4"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.ifMatch != "" {
+				headers["If-Match"] = tt.ifMatch
+			}
+			w, body := sendWithHeaders(t, router, http.MethodPut, path, `{"name":"Red"}`, headers)
+			if w.Code != tt.code {
+				t.Fatalf("expected %d, got %d %+v", tt.code, w.Code, body)
+			}
+			if got := w.Header().Get("ETag"); got != tt.etag {
+				t.Fatalf("expected ETag %q, got %q", tt.etag, got)
+			}
+		})
+	}
+}
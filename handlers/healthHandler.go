@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/health"
+	"github.com/demkowo/booking/utils/resp"
+	"github.com/demkowo/booking/utils/version"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type Health interface {
+	Live(*gin.Context)
+	Ready(*gin.Context)
+	SetReady(bool)
+	Version(*gin.Context)
+}
+
+type healthHandler struct {
+	ready atomic.Bool
+}
+
+func NewHealth() Health {
+	log.Trace()
+
+	return &healthHandler{}
+}
+
+func (h *healthHandler) Live(c *gin.Context) {
+	logFor(c).Trace()
+
+	resp.Send(c, http.StatusOK, "service is alive", nil)
+}
+
+// Ready runs the registered checks. A failed check is reported in causes
+// together with the checks that passed.
+func (h *healthHandler) Ready(c *gin.Context) {
+	logFor(c).Trace()
+
+	if !h.ready.Load() {
+		resp.Fail(c, errs.NewError("service is not ready", 503, "Service Unavailable", nil))
+		return
+	}
+
+	report := health.Run(c.Request.Context())
+	if report.Status != health.STATUS_UP {
+		causes := []interface{}{}
+		for _, check := range report.Checks {
+			causes = append(causes, check)
+		}
+		resp.Fail(c, errs.NewError("service is not ready", 503, "Service Unavailable", causes))
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "service is ready", report)
+}
+
+// SetReady switches what Ready reports. The service is not ready until it
+// has started and stops being ready as soon as it starts shutting down, so
+// load balancers stop sending new requests before the server closes.
+func (h *healthHandler) SetReady(ready bool) {
+	log.Trace()
+
+	h.ready.Store(ready)
+}
+
+func (h *healthHandler) Version(c *gin.Context) {
+	logFor(c).Trace()
+
+	resp.Send(c, http.StatusOK, "version found", version.Get())
+}
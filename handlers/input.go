@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+
+	"github.com/demkowo/booking/utils/errs"
+)
+
+const dateLayout = "2006-01-02"
+
+// fields collects validation causes while parsing the request input, so
+// that all invalid fields are reported in a single response. The response
+// is 400 when a field is missing or can't be read, 422 when all of them
+// can but some break a rule.
+type fields struct {
+	causes    []errs.Cause
+	malformed bool
+}
+
+// bind reads the JSON body into input and validates it against its tags.
+// A body that can't be decoded is reported as a whole, otherwise every
+// failing field is.
+func (f *fields) bind(c *gin.Context, input interface{}) {
+	err := c.ShouldBindJSON(input)
+	if err == nil {
+		return
+	}
+
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &validationErrors):
+		for _, fe := range validationErrors {
+			f.add(fieldPath(fe), validationMessage(fe), formatTags[fe.Tag()])
+		}
+	case errors.As(err, &typeError):
+		if typeError.Field == "" {
+			f.add("body", "must be a JSON object", true)
+			break
+		}
+		f.add(typeError.Field, "must be "+jsonType(typeError.Type), true)
+	default:
+		logFor(c).Debugf("Failed to bind JSON input: %v", err)
+		f.add("body", "must be a JSON object", true)
+	}
+}
+
+func (f *fields) add(field string, message string, malformed bool) {
+	f.causes = append(f.causes, errs.Cause{Field: field, Message: message})
+	f.malformed = f.malformed || malformed
+}
+
+func (f *fields) uuid(field string, value string) uuid.UUID {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		f.add(field, "must be a valid UUID", true)
+	}
+
+	return id
+}
+
+func (f *fields) err(c *gin.Context) *errs.Error {
+	if len(f.causes) == 0 {
+		return nil
+	}
+
+	logFor(c).Infof("Invalid input: %v", f.causes)
+	if f.malformed {
+		return errs.NewValidationError("Invalid input", f.causes...)
+	}
+
+	return errs.NewUnprocessableError("Invalid input", f.causes...)
+}
+
+// fieldPath is the JSON path of a failing field, e.g.
+// amenities.must_have[0].key, without the input and embedded structs.
+func fieldPath(fe validator.FieldError) string {
+	names := strings.Split(fe.Namespace(), ".")
+	structNames := strings.Split(fe.StructNamespace(), ".")
+
+	path := []string{}
+	for i := 1; i < len(names); i++ {
+		embedded := i < len(structNames) && names[i] == structNames[i]
+		if embedded && i < len(names)-1 {
+			continue
+		}
+		path = append(path, names[i])
+	}
+
+	return strings.Join(path, ".")
+}
+
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+)
+
+func TestInputValidation(t *testing.T) {
+	router := newTestRouter(t)
+
+	_, body := send(t, router, http.MethodPost, "/rooms/add", `{"name":"Blue"}`)
+	var room model.Room
+	if err := json.Unmarshal(body.Data, &room); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		code   int
+		causes []errs.Cause
+	}{
+		{name: "invalid id", method: http.MethodGet, path: "/rooms/x", code: 400, causes: []errs.Cause{{Field: "room_id", Message: "must be a valid UUID"}}},
+		{name: "malformed body", method: http.MethodPost, path: "/rooms/add", body: `{`, code: 400, causes: []errs.Cause{{Field: "body", Message: "must be a JSON object"}}},
+		{name: "array body", method: http.MethodPost, path: "/rooms/add", body: `[]`, code: 400, causes: []errs.Cause{{Field: "body", Message: "must be a JSON object"}}},
+		{name: "wrong type", method: http.MethodPost, path: "/rooms/add", body: `{"name":1}`, code: 400, causes: []errs.Cause{{Field: "name", Message: "must be a string"}}},
+		{name: "blank field", method: http.MethodPost, path: "/rooms/add", body: `{"name":" "}`, code: 400, causes: []errs.Cause{{Field: "name", Message: "is required"}}},
+		{
+			name: "reversed dates", method: http.MethodPost, path: "/rooms/" + room.Id.String() + "/availability-check",
+			body: `{"start_date":"2030-01-03","end_date":"2030-01-01"}`, code: 422, causes: []errs.Cause{{Field: "end_date", Message: "must be after start_date"}},
+		},
+		{
+			name: "missing fields", method: http.MethodPost, path: "/reservations/add", body: `{}`, code: 400,
+			causes: []errs.Cause{
+				{Field: "user_id", Message: "is required"},
+				{Field: "room_id", Message: "is required"},
+				{Field: "start_date", Message: "is required"},
+				{Field: "end_date", Message: "is required"},
+			},
+		},
+		{
+			name: "broken rules", method: http.MethodPost, path: "/reservations/add", code: 422,
+			body: `{"user_id":"00000000-0000-0000-0000-0000000000aa","room_id":"00000000-0000-0000-0000-0000000000bb","start_date":"2030-01-03","end_date":"2030-01-01","status":9}`,
+			causes: []errs.Cause{
+				{Field: "status", Message: "must be one of 0 (available), 1 (blocked), 2 (book_request), 3 (reservation), 4 (rent)"},
+				{Field: "end_date", Message: "must be after start_date"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := send(t, router, tt.method, tt.path, tt.body)
+			if w.Code != tt.code || body.Code != tt.code || body.Success {
+				t.Fatalf("expected %d, got %d %+v", tt.code, w.Code, body)
+			}
+			if len(body.Causes) != len(tt.causes) {
+				t.Fatalf("expected causes %v, got %v", tt.causes, body.Causes)
+			}
+			for i, cause := range tt.causes {
+				if body.Causes[i] != cause {
+					t.Fatalf("expected cause %v, got %v", cause, body.Causes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestInputErrorsAreNotLoggedAsErrors(t *testing.T) {
+	router := newTestRouter(t)
+
+	logs, hook := test.NewNullLogger()
+	logs.SetLevel(log.TraceLevel)
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logger.WithEntry(c.Request.Context(), log.NewEntry(logs)))
+		c.Next()
+	})
+	engine.Any("/*path", func(c *gin.Context) { router.HandleContext(c) })
+
+	for _, body := range []string{`{`, `[]`, `{"name":" "}`} {
+		send(t, engine, http.MethodPost, "/rooms/add", body)
+	}
+
+	invalid := 0
+	for _, entry := range hook.AllEntries() {
+		if entry.Level <= log.WarnLevel {
+			t.Errorf("client input error logged at %s: %s", entry.Level, entry.Message)
+		}
+		if strings.HasPrefix(entry.Message, "Invalid input") {
+			invalid++
+		}
+	}
+	if invalid != 3 {
+		t.Fatalf("expected every invalid input to be logged, got %d", invalid)
+	}
+}
@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/demkowo/booking/utils/logger"
+)
+
+// logFor returns the log entry of the request, carrying its request ID.
+func logFor(c *gin.Context) *log.Entry {
+	return logger.FromContext(c.Request.Context())
+}
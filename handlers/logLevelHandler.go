@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/resp"
+)
+
+type LogLevel interface {
+	Get(*gin.Context)
+	Set(*gin.Context)
+}
+
+type logLevel struct {
+}
+
+type logLevels struct {
+	Level    string            `json:"level"`
+	Packages map[string]string `json:"packages"`
+}
+
+func NewLogLevel() LogLevel {
+	log.Trace()
+
+	return &logLevel{}
+}
+
+func (h *logLevel) Get(c *gin.Context) {
+	logFor(c).Trace()
+
+	resp.Send(c, http.StatusOK, "log levels found", currentLevels())
+}
+
+// Set changes the levels until the service restarts. An empty level keeps the
+// global level, and packages replaces all package levels when it is sent.
+func (h *logLevel) Set(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	var input struct {
+		Level    string             `json:"level"`
+		Packages *map[string]string `json:"packages"`
+	}
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	global, packages := logger.Levels()
+
+	if input.Level != "" {
+		level, err := logger.ParseLevel(input.Level)
+		if err != nil {
+			f.add("level", "must be 0 to 6 or a level name", true)
+		}
+		global = level
+	}
+
+	if input.Packages != nil {
+		packages = map[string]log.Level{}
+		for pkg, name := range *input.Packages {
+			level, err := logger.ParseLevel(name)
+			if err != nil {
+				f.add("packages."+pkg, "must be 0 to 6 or a level name", true)
+			}
+			packages[pkg] = level
+		}
+		if len(packages) > 0 && !log.StandardLogger().ReportCaller {
+			f.add("packages", "package levels need logrus.reporter enabled", false)
+		}
+	}
+
+	sort.Slice(f.causes, func(i, j int) bool { return f.causes[i].Field < f.causes[j].Field })
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	logger.SetLevels(global, packages)
+	logFor(c).Warnf("log levels changed to %+v", currentLevels())
+
+	resp.Send(c, http.StatusOK, "log levels updated", currentLevels())
+}
+
+func currentLevels() logLevels {
+	global, packages := logger.Levels()
+
+	levels := logLevels{Level: global.String(), Packages: map[string]string{}}
+	for pkg, level := range packages {
+		levels.Packages[pkg] = level.String()
+	}
+
+	return levels
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	swaggerFiles "github.com/swaggo/files/v2"
+
+	"github.com/demkowo/booking/utils/openapi"
+)
+
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    layout: "StandaloneLayout"
+  });
+};
+`
+
+type OpenAPI interface {
+	Spec(*gin.Context)
+	UI(*gin.Context)
+}
+
+type openAPI struct {
+	doc *openapi.Document
+	ui  http.FileSystem
+}
+
+func NewOpenAPI(doc *openapi.Document) OpenAPI {
+	log.Trace()
+
+	return &openAPI{
+		doc: doc,
+		ui:  http.FS(swaggerFiles.FS),
+	}
+}
+
+func (h *openAPI) Spec(c *gin.Context) {
+	logFor(c).Trace()
+
+	c.JSON(http.StatusOK, h.doc)
+}
+
+// UI serves the embedded Swagger UI, set up to load /openapi.json.
+func (h *openAPI) UI(c *gin.Context) {
+	logFor(c).Trace()
+
+	file := c.Param("filepath")
+	if file == "/swagger-initializer.js" {
+		c.Data(http.StatusOK, "application/javascript; charset=utf-8", []byte(swaggerInitializer))
+		return
+	}
+
+	c.FileFromFS(file, h.ui)
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/resp"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type Problem interface {
+	Find(*gin.Context)
+	GetByKind(*gin.Context)
+}
+
+type problem struct {
+}
+
+func NewProblem() Problem {
+	log.Trace()
+
+	return &problem{}
+}
+
+func (h *problem) Find(c *gin.Context) {
+	logFor(c).Trace()
+
+	resp.Send(c, http.StatusOK, "problem types found", errs.Catalog)
+}
+
+func (h *problem) GetByKind(c *gin.Context) {
+	logFor(c).Trace()
+
+	problemType, err := errs.GetProblemType(c.Param("kind"))
+	if err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "problem type found", problemType)
+}
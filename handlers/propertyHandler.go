@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	model "github.com/demkowo/booking/models"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/resp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type Property interface {
+	CreateTableProperties()
+
+	Add(*gin.Context)
+	Find(*gin.Context)
+	GetById(*gin.Context)
+	Update(*gin.Context)
+}
+
+type property struct {
+	service service.Property
+}
+
+type PropertyInput struct {
+	Name string `json:"name" binding:"required,notblank,max=255"`
+}
+
+func NewProperty(service service.Property) Property {
+	log.Trace()
+
+	return &property{
+		service: service,
+	}
+}
+
+func (h *property) CreateTableProperties() {
+	log.Trace()
+
+	res := h.service.CreateTableProperties(context.Background())
+	log.Info(res)
+}
+
+func (h *property) Add(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	var input PropertyInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	property := &model.Property{
+		Id:      uuid.New(),
+		Name:    input.Name,
+		Created: time.Now(),
+		Updated: time.Now(),
+	}
+
+	if err := h.service.Add(c.Request.Context(), property); err != nil {
+		logFor(c).Errorf("Failed to add property: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, property.Version)
+	resp.Send(c, http.StatusOK, "property created", property)
+}
+
+func (h *property) Find(c *gin.Context) {
+	logFor(c).Trace()
+
+	properties, err := h.service.Find(c.Request.Context())
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "properties found", properties)
+}
+
+func (h *property) GetById(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("property_id", c.Param("property_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	property, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, property.Version)
+	resp.Send(c, http.StatusOK, "property found", property)
+}
+
+func (h *property) Update(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("property_id", c.Param("property_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	version, err := ifMatch(c, func() (int, *errs.Error) {
+		stored, err := h.service.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return 0, err
+		}
+		return stored.Version, nil
+	})
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	var input PropertyInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	property := &model.Property{
+		Id:      id,
+		Name:    input.Name,
+		Updated: time.Now(),
+		Version: version,
+	}
+
+	if err := h.service.Update(c.Request.Context(), property); err != nil {
+		logFor(c).Errorf("Failed to update property: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, property.Version)
+	resp.Send(c, http.StatusOK, "property updated", property)
+}
@@ -1,274 +1,261 @@
-package handler
-
-import (
-	"net/http"
-	"time"
-
-	model "github.com/demkowo/booking/models"
-	service "github.com/demkowo/booking/services"
-	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	log "github.com/sirupsen/logrus"
-)
-
-type Reservation interface {
-	CreateTableReservations()
-
-	Add(*gin.Context)
-	Delete(*gin.Context)
-	Find(*gin.Context)
-	FindByRoomID(*gin.Context)
-	GetById(*gin.Context)
-	Update(*gin.Context)
-}
-
-type reservation struct {
-	service service.Reservation
-}
-
-func NewReservation(service service.Reservation) Reservation {
-	log.Trace()
-
-	return &reservation{
-		service: service,
-	}
-}
-
-func (h *reservation) CreateTableReservations() {
-	log.Trace()
-
-	h.service.CreateTableReservations()
-}
-
-func (h *reservation) Add(c *gin.Context) {
-	log.Trace()
-
-	var input struct {
-		UserId    string `json:"user_id"`
-		StartDate string `json:"start_date"`
-		EndDate   string `json:"end_date"`
-		RoomID    string `json:"room_id"`
-		Status    int    `json:"status"`
-	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Errorf("Failed to bind JSON input: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input",
-		})
-		return
-	}
-
-	userId, err := uuid.Parse(input.UserId)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid user id",
-		})
-		return
-	}
-
-	startDate, err := time.Parse("2006-01-02", input.StartDate)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid start date",
-		})
-		return
-	}
-
-	endDate, err := time.Parse("2006-01-02", input.EndDate)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid end date",
-		})
-		return
-	}
-
-	roomId, err := uuid.Parse(input.RoomID)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid room id",
-		})
-		return
-	}
-
-	reservation := &model.Reservation{
-		Id:        uuid.New(),
-		UserId:    userId,
-		StartDate: startDate,
-		EndDate:   endDate,
-		RoomID:    roomId,
-		Status:    input.Status,
-		Created:   time.Now(),
-		Updated:   time.Now(),
-		Deleted:   false,
-	}
-
-	if err := h.service.Add(reservation); err != nil {
-		log.Errorf("Failed to create reservation: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to create reservation",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"reservation": reservation})
-}
-
-func (h *reservation) Delete(c *gin.Context) {
-	log.Trace()
-
-	if err := h.service.Delete(c.Param("reservation_id")); err != nil {
-		log.Errorf("Failed to delete reservation: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to delete reservation",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"message": "Reservation deleted successfully"})
-}
-
-func (h *reservation) Find(c *gin.Context) {
-	log.Trace()
-
-	reservations, err := h.service.Find()
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "list reservation failed",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"reservations": reservations})
-}
-
-func (h *reservation) FindByRoomID(c *gin.Context) {
-	log.Trace()
-
-	roomId, e := uuid.Parse(c.Param("room_id"))
-	if e != nil {
-		log.Error(e)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid room id",
-		})
-		return
-	}
-
-	rooms, err := h.service.FindByRoomID(roomId)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "finding rooms by id failed",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"rooms": rooms})
-}
-
-func (h *reservation) GetById(c *gin.Context) {
-	log.Trace()
-
-	idStr := c.Param("reservation_id")
-
-	if idStr == "" {
-		log.Error("empty reservation ID")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "reservation ID can't be empty",
-		})
-		return
-	}
-
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid reservation ID",
-		})
-		return
-	}
-
-	reservation, e := h.service.GetByID(id)
-	if e != nil {
-		log.Error(e)
-		c.JSON(http.StatusBadRequest, gin.H{"error": e.Message})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"reservation": reservation})
-}
-
-func (h *reservation) Update(c *gin.Context) {
-	log.Trace()
-
-	idStr := c.Param("reservation_id")
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		log.Errorf("Invalid reservation id: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reservation ID"})
-		return
-	}
-
-	var input struct {
-		StartDate string `json:"start_date"`
-		EndDate   string `json:"end_date"`
-		RoomID    string `json:"room_id"`
-	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Errorf("Failed to bind JSON input: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input",
-		})
-		return
-	}
-
-	startDate, err := time.Parse("2006-01-02", input.StartDate)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid start date",
-		})
-		return
-	}
-
-	endDate, err := time.Parse("2006-01-02", input.EndDate)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid end date",
-		})
-		return
-	}
-
-	roomId, err := uuid.Parse(input.RoomID)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid room id",
-		})
-		return
-	}
-
-	reservation := &model.Reservation{
-		Id:        id,
-		StartDate: startDate,
-		EndDate:   endDate,
-		RoomID:    roomId,
-	}
-
-	if err := h.service.Update(reservation); err != nil {
-		log.Errorf("Failed to update reservation: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update reservation",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"reservation": reservation})
-}
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	model "github.com/demkowo/booking/models"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/resp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type Reservation interface {
+	CreateTableReservations()
+
+	Add(*gin.Context)
+	Delete(*gin.Context)
+	Find(*gin.Context)
+	FindByRoomID(*gin.Context)
+	GetById(*gin.Context)
+	Restore(*gin.Context)
+	Update(*gin.Context)
+}
+
+type reservation struct {
+	service service.Reservation
+}
+
+type ReservationInput struct {
+	UserId string `json:"user_id" binding:"required,uuid" format:"uuid"`
+	RoomID string `json:"room_id" binding:"required,uuid" format:"uuid"`
+	Status int    `json:"status" binding:"status"`
+	DateRangeInput
+	OccupancyInput
+}
+
+type ReservationUpdateInput struct {
+	RoomID string `json:"room_id" binding:"required,uuid" format:"uuid"`
+	DateRangeInput
+	OccupancyInput
+}
+
+func NewReservation(service service.Reservation) Reservation {
+	log.Trace()
+
+	return &reservation{
+		service: service,
+	}
+}
+
+func (h *reservation) CreateTableReservations() {
+	log.Trace()
+
+	h.service.CreateTableReservations(context.Background())
+}
+
+func (h *reservation) Add(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	var input ReservationInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+	userId, _ := uuid.Parse(input.UserId)
+	roomId, _ := uuid.Parse(input.RoomID)
+	startDate, endDate := input.Dates()
+
+	reservation := &model.Reservation{
+		Id:        uuid.New(),
+		UserId:    userId,
+		StartDate: startDate,
+		EndDate:   endDate,
+		RoomID:    roomId,
+		Status:    input.Status,
+		Occupancy: input.Occupancy(),
+		Created:   time.Now(),
+		Updated:   time.Now(),
+		Deleted:   false,
+	}
+
+	if err := h.service.Add(c.Request.Context(), reservation); err != nil {
+		logFor(c).Errorf("Failed to create reservation: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, reservation.Version)
+	resp.Send(c, http.StatusOK, "reservation created", reservation)
+}
+
+func (h *reservation) Delete(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	f.uuid("reservation_id", c.Param("reservation_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	if err := h.service.Delete(c.Request.Context(), c.Param("reservation_id")); err != nil {
+		logFor(c).Errorf("Failed to delete reservation: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "reservation deleted", nil)
+}
+
+func (h *reservation) Find(c *gin.Context) {
+	logFor(c).Trace()
+
+	deleted, e := strconv.ParseBool(c.DefaultQuery("deleted", "false"))
+	if e != nil {
+		logFor(c).Error(e)
+		resp.Fail(c, errs.NewValidationError("Invalid input", errs.Cause{Field: "deleted", Message: "must be a boolean"}))
+		return
+	}
+
+	if deleted {
+		reservations, err := h.service.FindDeleted(c.Request.Context())
+		if err != nil {
+			logFor(c).Error(err.Message)
+			resp.Fail(c, err)
+			return
+		}
+
+		resp.Send(c, http.StatusOK, "deleted reservations found", reservations)
+		return
+	}
+
+	reservations, err := h.service.Find(c.Request.Context())
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "reservations found", reservations)
+}
+
+func (h *reservation) FindByRoomID(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	roomId := f.uuid("room_id", c.Param("room_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	reservations, err := h.service.FindByRoomID(c.Request.Context(), roomId)
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "reservations found", reservations)
+}
+
+func (h *reservation) GetById(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("reservation_id", c.Param("reservation_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	reservation, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, reservation.Version)
+	resp.Send(c, http.StatusOK, "reservation found", reservation)
+}
+
+func (h *reservation) Restore(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("reservation_id", c.Param("reservation_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	reservation, err := h.service.Restore(c.Request.Context(), id)
+	if err != nil {
+		logFor(c).Errorf("Failed to restore reservation: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, reservation.Version)
+	resp.Send(c, http.StatusOK, "reservation restored", reservation)
+}
+
+func (h *reservation) Update(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("reservation_id", c.Param("reservation_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	version, err := ifMatch(c, func() (int, *errs.Error) {
+		stored, err := h.service.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return 0, err
+		}
+		return stored.Version, nil
+	})
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	var input ReservationUpdateInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+	roomId, _ := uuid.Parse(input.RoomID)
+	startDate, endDate := input.Dates()
+
+	reservation := &model.Reservation{
+		Id:        id,
+		StartDate: startDate,
+		EndDate:   endDate,
+		RoomID:    roomId,
+		Occupancy: input.Occupancy(),
+		Version:   version,
+	}
+
+	if err := h.service.Update(c.Request.Context(), reservation); err != nil {
+		logFor(c).Errorf("Failed to update reservation: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, reservation.Version)
+	resp.Send(c, http.StatusOK, "reservation updated", reservation)
+}
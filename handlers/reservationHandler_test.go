@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+)
+
+func TestReservationUpdateKeepsStatus(t *testing.T) {
+	router := newTestRouter(t)
+
+	_, body := send(t, router, http.MethodPost, "/rooms/add", `{"name":"Blue"}`)
+	var room model.Room
+	if err := json.Unmarshal(body.Data, &room); err != nil {
+		t.Fatal(err)
+	}
+
+	w, body := send(t, router, http.MethodPost, "/reservations/add", `{"user_id":"00000000-0000-0000-0000-0000000000aa","room_id":"`+room.Id.String()+`","start_date":"2030-01-01","end_date":"2030-01-03","status":3}`)
+	var reservation model.Reservation
+	if err := json.Unmarshal(body.Data, &reservation); err != nil || w.Code != 200 || reservation.Status != model.RESERVATION {
+		t.Fatalf("Add: unexpected response %d %s", w.Code, body.Data)
+	}
+
+	req := `{"room_id":"` + room.Id.String() + `","start_date":"2030-01-01","end_date":"2030-01-04"}`
+	w, body = sendWithHeaders(t, router, http.MethodPut, "/reservations/"+reservation.Id.String(), req, map[string]string{"If-Match": w.Header().Get("ETag")})
+	if w.Code != 200 {
+		t.Fatalf("Update: expected 200, got %d %+v", w.Code, body)
+	}
+	if err := json.Unmarshal(body.Data, &reservation); err != nil || reservation.Status != model.RESERVATION {
+		t.Fatalf("Update: expected status %d, got %s", model.RESERVATION, body.Data)
+	}
+
+	_, body = send(t, router, http.MethodGet, "/reservations/"+reservation.Id.String(), "")
+	if err := json.Unmarshal(body.Data, &reservation); err != nil || reservation.Status != model.RESERVATION {
+		t.Fatalf("GetById: expected status %d, got %s", model.RESERVATION, body.Data)
+	}
+}
+
+func TestReservationOccupancy(t *testing.T) {
+	router := newTestRouter(t)
+
+	_, body := send(t, router, http.MethodPost, "/rooms/add", `{"name":"Blue","max_adults":2,"max_children":1,"max_occupancy":2}`)
+	var room model.Room
+	if err := json.Unmarshal(body.Data, &room); err != nil {
+		t.Fatal(err)
+	}
+	reservation := `{"user_id":"00000000-0000-0000-0000-0000000000aa","room_id":"` + room.Id.String() + `","start_date":"2030-01-01","end_date":"2030-01-03",`
+
+	tests := []struct {
+		name   string
+		guests string
+		code   int
+		causes []errs.Cause
+	}{
+		{name: "fits", guests: `"adults":2}`, code: 200},
+		{
+			name: "too many adults and guests", guests: `"adults":3,"children":1}`, code: 422,
+			causes: []errs.Cause{
+				{Field: "adults", Message: "must be at most 2 for this room"},
+				{Field: "occupancy", Message: "adults and children must be at most 2 for this room"},
+			},
+		},
+		{name: "negative guests", guests: `"adults":-1}`, code: 422, causes: []errs.Cause{{Field: "adults", Message: "must be at least 0"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := send(t, router, http.MethodPost, "/reservations/add", reservation+tt.guests)
+			if w.Code != tt.code {
+				t.Fatalf("expected %d, got %d %+v", tt.code, w.Code, body)
+			}
+			if len(body.Causes) != len(tt.causes) {
+				t.Fatalf("expected causes %v, got %v", tt.causes, body.Causes)
+			}
+			for i, cause := range tt.causes {
+				if body.Causes[i] != cause {
+					t.Fatalf("expected cause %v, got %v", cause, body.Causes[i])
+				}
+			}
+		})
+	}
+}
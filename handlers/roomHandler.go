@@ -1,267 +1,411 @@
-package handler
-
-import (
-	"net/http"
-	"time"
-
-	model "github.com/demkowo/booking/models"
-	service "github.com/demkowo/booking/services"
-	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	log "github.com/sirupsen/logrus"
-)
-
-type Room interface {
-	CreateTableRooms()
-
-	Add(*gin.Context)
-	Find(*gin.Context)
-	FindAvailable(*gin.Context)
-	GetById(*gin.Context)
-	CheckIfAvailableById(*gin.Context)
-	Update(*gin.Context)
-}
-
-type room struct {
-	service service.Room
-}
-
-func NewRoom(service service.Room) Room {
-	log.Trace()
-
-	return &room{
-		service: service,
-	}
-}
-
-func (h *room) CreateTableRooms() {
-	log.Trace()
-
-	res := h.service.CreateTableRooms()
-	log.Info(res)
-}
-
-func (h *room) Add(c *gin.Context) {
-	log.Trace()
-
-	var input struct {
-		Name string `json:"name"`
-	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Errorf("Failed to bind JSON input: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input",
-		})
-		return
-	}
-
-	room := &model.Room{
-		Id:      uuid.New(),
-		Name:    input.Name,
-		Created: time.Now(),
-		Updated: time.Now(),
-	}
-
-	if err := h.service.Add(room); err != nil {
-		log.Errorf("Failed to add room: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to add room",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"room": room})
-}
-
-func (h *room) Find(c *gin.Context) {
-	log.Trace()
-
-	rooms, err := h.service.Find()
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "list rooms failed",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"rooms": rooms})
-}
-
-func (h *room) FindAvailable(c *gin.Context) {
-	log.Trace()
-
-	var input struct {
-		StartDate string `json:"start_date"`
-		EndDate   string `json:"end_date"`
-	}
-
-	if e := c.ShouldBindJSON(&input); e != nil {
-		log.Errorf("Failed to bind JSON input: %v", e)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input",
-		})
-		return
-	}
-
-	startDate, e := time.Parse("2006-01-02", input.StartDate)
-	if e != nil {
-		log.Error(e)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid start date",
-		})
-		return
-	}
-
-	endDate, e := time.Parse("2006-01-02", input.EndDate)
-	if e != nil {
-		log.Error(e)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid end date",
-		})
-		return
-	}
-
-	rooms, err := h.service.FindAvailable(startDate, endDate)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "list available rooms failed",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"rooms": rooms})
-}
-
-func (h *room) GetById(c *gin.Context) {
-	log.Trace()
-
-	idStr := c.Param("room_id")
-
-	if idStr == "" {
-		log.Error("empty room id")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "room id can't be empty",
-		})
-		return
-	}
-
-	id, err := uuid.Parse(idStr)
-	if err != nil {
-		log.Error(err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid room id",
-		})
-		return
-	}
-
-	room, e := h.service.GetByID(id)
-	if e != nil {
-		log.Error(e)
-		c.JSON(http.StatusBadRequest, gin.H{"error": e.Message})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"room": room})
-}
-
-func (h *room) CheckIfAvailableById(c *gin.Context) {
-	log.Trace()
-
-	id, e := uuid.Parse(c.Param("room_id"))
-	if e != nil {
-		log.Errorf("Failed to parse room_id: %v", e)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid room_id",
-		})
-		return
-	}
-
-	var input struct {
-		StartDate string `json:"start_date"`
-		EndDate   string `json:"end_date"`
-	}
-
-	if e := c.ShouldBindJSON(&input); e != nil {
-		log.Errorf("Failed to bind JSON input: %v", e)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input",
-		})
-		return
-	}
-
-	startDate, e := time.Parse("2006-01-02", input.StartDate)
-	if e != nil {
-		log.Error(e)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid start date",
-		})
-		return
-	}
-
-	endDate, e := time.Parse("2006-01-02", input.EndDate)
-	if e != nil {
-		log.Error(e)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "invalid end date",
-		})
-		return
-	}
-
-	res, err := h.service.CheckIfAvailableById(id, startDate, endDate)
-	if err != nil {
-		log.Errorf("checking if room is available failed: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input",
-		})
-		return
-	}
-
-	if !res {
-		c.JSON(http.StatusOK, gin.H{"response": "room is not available"})
-	}
-
-	c.JSON(http.StatusOK, gin.H{"response": "room is available"})
-}
-
-func (h *room) Update(c *gin.Context) {
-	log.Trace()
-
-	id, err := uuid.Parse(c.Param("room_id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid room id",
-		})
-		return
-	}
-
-	var input struct {
-		Name string `json:"name"`
-	}
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		log.Errorf("Failed to bind JSON input: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid input",
-		})
-		return
-	}
-
-	room := &model.Room{
-		Id:      id,
-		Name:    input.Name,
-		Updated: time.Now(),
-	}
-
-	if err := h.service.Update(room); err != nil {
-		log.Errorf("Failed to update room: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to update room",
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{"room": room})
-}
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	model "github.com/demkowo/booking/models"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/resp"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+type Room interface {
+	CreateTableRooms()
+
+	Add(*gin.Context)
+	Archive(*gin.Context)
+	Find(*gin.Context)
+	FindAvailable(*gin.Context)
+	GetById(*gin.Context)
+	CheckIfAvailableById(*gin.Context)
+	MoveReservations(*gin.Context)
+	SetAmenities(*gin.Context)
+	Update(*gin.Context)
+}
+
+type room struct {
+	service service.Room
+}
+
+// RoomInput capacity limits of 0 mean no limit.
+type RoomInput struct {
+	Name         string `json:"name" binding:"required,notblank,max=255"`
+	MaxAdults    int    `json:"max_adults" binding:"min=0"`
+	MaxChildren  int    `json:"max_children" binding:"min=0"`
+	MaxOccupancy int    `json:"max_occupancy" binding:"min=0"`
+}
+
+// DateRangeInput is a stay, the end date has to be after the start date.
+type DateRangeInput struct {
+	StartDate string `json:"start_date" binding:"required,date" format:"date"`
+	EndDate   string `json:"end_date" binding:"required,date" format:"date"`
+}
+
+type OccupancyInput struct {
+	Adults   int `json:"adults" binding:"min=0"`
+	Children int `json:"children" binding:"min=0"`
+}
+
+// AvailabilitySearchInput lists the rooms free for a stay that fit the
+// guests, a zero occupancy matches any room.
+type AvailabilitySearchInput struct {
+	DateRangeInput
+	OccupancyInput
+	Amenities AmenitySearchInput `json:"amenities"`
+}
+
+// AmenitySearchInput leaves out rooms without every must have amenity, and
+// ranks first the rooms with the most nice to have ones.
+type AmenitySearchInput struct {
+	MustHave   []AmenityFilterInput `json:"must_have" binding:"max=20,dive"`
+	NiceToHave []AmenityFilterInput `json:"nice_to_have" binding:"max=20,dive"`
+}
+
+// AmenityFilterInput matches the value of an amenity, or a range of a
+// number amenity. Without a value and bounds a boolean amenity has to be
+// true and any value of other amenities matches.
+type AmenityFilterInput struct {
+	Key   string      `json:"key" binding:"required"`
+	Value interface{} `json:"value"`
+	Min   *float64    `json:"min"`
+	Max   *float64    `json:"max"`
+}
+
+type RoomAmenityInput struct {
+	Key   string      `json:"key" binding:"required"`
+	Value interface{} `json:"value"`
+}
+
+// RoomAmenitiesInput replaces all the amenities of a room.
+type RoomAmenitiesInput struct {
+	Amenities []RoomAmenityInput `json:"amenities" binding:"max=100,dive"`
+}
+
+type MoveReservationsInput struct {
+	TargetRoomID string `json:"target_room_id" binding:"required,uuid" format:"uuid"`
+}
+
+type MovedReservations struct {
+	Count int64 `json:"moved_reservations"`
+}
+
+type Availability struct {
+	Available bool `json:"available"`
+}
+
+// Dates returns the validated dates of the range.
+func (i DateRangeInput) Dates() (time.Time, time.Time) {
+	start, _ := time.Parse(dateLayout, i.StartDate)
+	end, _ := time.Parse(dateLayout, i.EndDate)
+
+	return start, end
+}
+
+// amenityFilters reads the filters of an availability search, their values
+// are checked against the amenity types by the service.
+func (f *fields) amenityFilters(field string, inputs []AmenityFilterInput) []model.AmenityFilter {
+	filters := []model.AmenityFilter{}
+	for i, input := range inputs {
+		filter := model.AmenityFilter{Key: input.Key, Min: input.Min, Max: input.Max}
+		if input.Value != nil {
+			value, ok := scalar(input.Value)
+			if !ok {
+				f.add(fmt.Sprintf("%s[%d].value", field, i), "must be a boolean, number or string", true)
+				continue
+			}
+			filter.Value = &value
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters
+}
+
+func (i OccupancyInput) Occupancy() model.Occupancy {
+	return model.Occupancy{Adults: i.Adults, Children: i.Children}
+}
+
+func NewRoom(service service.Room) Room {
+	log.Trace()
+
+	return &room{
+		service: service,
+	}
+}
+
+func (h *room) CreateTableRooms() {
+	log.Trace()
+
+	res := h.service.CreateTableRooms(context.Background())
+	log.Info(res)
+}
+
+func (h *room) Add(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	var input RoomInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	room := &model.Room{
+		Id:           uuid.New(),
+		Name:         input.Name,
+		MaxAdults:    input.MaxAdults,
+		MaxChildren:  input.MaxChildren,
+		MaxOccupancy: input.MaxOccupancy,
+		Amenities:    []model.RoomAmenity{},
+		Created:      time.Now(),
+		Updated:      time.Now(),
+	}
+
+	if err := h.service.Add(c.Request.Context(), room); err != nil {
+		logFor(c).Errorf("Failed to add room: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, room.Version)
+	resp.Send(c, http.StatusOK, "room created", room)
+}
+
+func (h *room) Archive(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("room_id", c.Param("room_id"))
+
+	var reassignTo *uuid.UUID
+	if target := c.Query("reassign_to"); target != "" {
+		targetId := f.uuid("reassign_to", target)
+		reassignTo = &targetId
+	}
+
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	moved, err := h.service.Archive(c.Request.Context(), id, reassignTo)
+	if err != nil {
+		logFor(c).Errorf("Failed to archive room: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "room archived", MovedReservations{Count: moved})
+}
+
+func (h *room) Find(c *gin.Context) {
+	logFor(c).Trace()
+
+	rooms, err := h.service.Find(c.Request.Context())
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "rooms found", rooms)
+}
+
+func (h *room) FindAvailable(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	var input AvailabilitySearchInput
+	f.bind(c, &input)
+
+	search := model.RoomSearch{
+		Occupancy: input.Occupancy(),
+		Required:  f.amenityFilters("amenities.must_have", input.Amenities.MustHave),
+		Preferred: f.amenityFilters("amenities.nice_to_have", input.Amenities.NiceToHave),
+	}
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+	search.Start, search.End = input.Dates()
+
+	rooms, err := h.service.FindAvailable(c.Request.Context(), search)
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "available rooms found", rooms)
+}
+
+func (h *room) GetById(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("room_id", c.Param("room_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	room, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, room.Version)
+	resp.Send(c, http.StatusOK, "room found", room)
+}
+
+func (h *room) CheckIfAvailableById(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("room_id", c.Param("room_id"))
+
+	var input DateRangeInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+	startDate, endDate := input.Dates()
+
+	available, err := h.service.CheckIfAvailableById(c.Request.Context(), id, startDate, endDate)
+	if err != nil {
+		logFor(c).Errorf("checking if room is available failed: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	message := "room is available"
+	if !available {
+		message = "room is not available"
+	}
+
+	resp.Send(c, http.StatusOK, message, Availability{Available: available})
+}
+
+func (h *room) MoveReservations(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	from := f.uuid("room_id", c.Param("room_id"))
+
+	var input MoveReservationsInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+	to, _ := uuid.Parse(input.TargetRoomID)
+
+	moved, err := h.service.MoveReservations(c.Request.Context(), from, to)
+	if err != nil {
+		logFor(c).Errorf("Failed to move reservations: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	resp.Send(c, http.StatusOK, "reservations moved", MovedReservations{Count: moved})
+}
+
+func (h *room) SetAmenities(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("room_id", c.Param("room_id"))
+
+	var input RoomAmenitiesInput
+	f.bind(c, &input)
+
+	amenities := []model.RoomAmenity{}
+	for i, a := range input.Amenities {
+		field := fmt.Sprintf("amenities[%d].value", i)
+		if a.Value == nil {
+			f.add(field, "is required", true)
+			continue
+		}
+		value, ok := scalar(a.Value)
+		if !ok {
+			f.add(field, "must be a boolean, number or string", true)
+			continue
+		}
+		amenities = append(amenities, model.RoomAmenity{Key: a.Key, Value: value})
+	}
+
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	room, err := h.service.SetAmenities(c.Request.Context(), id, amenities)
+	if err != nil {
+		logFor(c).Errorf("Failed to set room amenities: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, room.Version)
+	resp.Send(c, http.StatusOK, "room amenities set", room)
+}
+
+func (h *room) Update(c *gin.Context) {
+	logFor(c).Trace()
+
+	var f fields
+	id := f.uuid("room_id", c.Param("room_id"))
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	version, err := ifMatch(c, func() (int, *errs.Error) {
+		stored, err := h.service.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return 0, err
+		}
+		return stored.Version, nil
+	})
+	if err != nil {
+		logFor(c).Error(err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	var input RoomInput
+	f.bind(c, &input)
+	if err := f.err(c); err != nil {
+		resp.Fail(c, err)
+		return
+	}
+
+	room := &model.Room{
+		Id:           id,
+		Name:         input.Name,
+		MaxAdults:    input.MaxAdults,
+		MaxChildren:  input.MaxChildren,
+		MaxOccupancy: input.MaxOccupancy,
+		Updated:      time.Now(),
+		Version:      version,
+	}
+
+	if err := h.service.Update(c.Request.Context(), room); err != nil {
+		logFor(c).Errorf("Failed to update room: %v", err.Message)
+		resp.Fail(c, err)
+		return
+	}
+
+	setETag(c, room.Version)
+	resp.Send(c, http.StatusOK, "room updated", room)
+}
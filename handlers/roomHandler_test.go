@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+)
+
+func TestRoomAmenitiesChangeTheETag(t *testing.T) {
+	router := newTestRouter(t)
+
+	_, body := send(t, router, http.MethodPost, "/amenities/add", `{"key":"balcony","name":"Balcony","type":"boolean"}`)
+	var amenity model.Amenity
+	if err := json.Unmarshal(body.Data, &amenity); err != nil {
+		t.Fatal(err)
+	}
+
+	w, body := send(t, router, http.MethodPost, "/rooms/add", `{"name":"Blue"}`)
+	var room model.Room
+	if err := json.Unmarshal(body.Data, &room); err != nil {
+		t.Fatal(err)
+	}
+	seen := w.Header().Get("ETag")
+
+	w, body = send(t, router, http.MethodPut, "/rooms/"+room.Id.String()+"/amenities", `{"amenities":[{"key":"balcony","value":true}]}`)
+	if w.Code != 200 {
+		t.Fatalf("SetAmenities: expected 200, got %d %+v", w.Code, body)
+	}
+	withAmenities := w.Header().Get("ETag")
+	if withAmenities == seen {
+		t.Fatalf("SetAmenities: expected a new ETag, got %s again", seen)
+	}
+
+	w, body = sendWithHeaders(t, router, http.MethodPut, "/rooms/"+room.Id.String(), `{"name":"Red"}`, map[string]string{"If-Match": seen})
+	if w.Code != 412 {
+		t.Fatalf("Update with the ETag from before SetAmenities: expected 412, got %d %+v", w.Code, body)
+	}
+
+	send(t, router, http.MethodDelete, "/amenities/"+amenity.Id.String(), "")
+	w, _ = send(t, router, http.MethodGet, "/rooms/"+room.Id.String(), "")
+	if got := w.Header().Get("ETag"); got == withAmenities {
+		t.Fatalf("amenity Delete: expected a new ETag, got %s again", got)
+	}
+}
+
+func TestRoomCapacity(t *testing.T) {
+	router := newTestRouter(t)
+
+	tests := []struct {
+		name   string
+		body   string
+		code   int
+		causes []errs.Cause
+	}{
+		{name: "valid", body: `{"name":"Blue","max_adults":2,"max_children":1,"max_occupancy":3}`, code: 200},
+		{
+			name: "negative limit", body: `{"name":"Red","max_adults":-1}`, code: 422,
+			causes: []errs.Cause{{Field: "max_adults", Message: "must be at least 0"}},
+		},
+		{
+			name: "negative limit and missing name", body: `{"name":" ","max_children":-1}`, code: 400,
+			causes: []errs.Cause{{Field: "name", Message: "is required"}, {Field: "max_children", Message: "must be at least 0"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := send(t, router, http.MethodPost, "/rooms/add", tt.body)
+			if w.Code != tt.code {
+				t.Fatalf("expected %d, got %d %+v", tt.code, w.Code, body)
+			}
+			if len(body.Causes) != len(tt.causes) {
+				t.Fatalf("expected causes %v, got %v", tt.causes, body.Causes)
+			}
+			for i, cause := range tt.causes {
+				if body.Causes[i] != cause {
+					t.Fatalf("expected cause %v, got %v", cause, body.Causes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRoomUpdateKeepsRoomForFutureGuests(t *testing.T) {
+	router := newTestRouter(t)
+
+	w, body := send(t, router, http.MethodPost, "/rooms/add", `{"name":"Blue","max_adults":3}`)
+	var room model.Room
+	if err := json.Unmarshal(body.Data, &room); err != nil {
+		t.Fatal(err)
+	}
+	etag := w.Header().Get("ETag")
+
+	w, body = send(t, router, http.MethodPost, "/reservations/add", `{"user_id":"00000000-0000-0000-0000-0000000000aa","room_id":"`+room.Id.String()+`","start_date":"2030-01-01","end_date":"2030-01-03","adults":3}`)
+	if w.Code != 200 {
+		t.Fatalf("reservation Add: expected 200, got %d %+v", w.Code, body)
+	}
+
+	w, body = sendWithHeaders(t, router, http.MethodPut, "/rooms/"+room.Id.String(), `{"name":"Blue","max_adults":2}`, map[string]string{"If-Match": etag})
+	if w.Code != 409 {
+		t.Fatalf("Update: expected 409, got %d %+v", w.Code, body)
+	}
+
+	w, body = sendWithHeaders(t, router, http.MethodPut, "/rooms/"+room.Id.String(), `{"name":"Blue","max_adults":4}`, map[string]string{"If-Match": etag})
+	if w.Code != 200 {
+		t.Fatalf("Update: expected 200, got %d %+v", w.Code, body)
+	}
+}
@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+)
+
+func TestHandlersAreScopedToTheProperty(t *testing.T) {
+	router := newTestRouter(t)
+	other := map[string]string{testPropertyHeader: uuid.New().String()}
+
+	w, body := sendWithHeaders(t, router, http.MethodPost, "/rooms/add", `{"name":"Blue"}`, other)
+	var room model.Room
+	if err := json.Unmarshal(body.Data, &room); err != nil || w.Code != 200 {
+		t.Fatalf("Add: unexpected response %d %+v", w.Code, body)
+	}
+	path := "/rooms/" + room.Id.String()
+	reservation := `{"user_id":"00000000-0000-0000-0000-0000000000aa","room_id":"` + room.Id.String() + `","start_date":"2030-01-01","end_date":"2030-01-03"}`
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		headers map[string]string
+		code    int
+	}{
+		{name: "get in its property", method: http.MethodGet, path: path, headers: other, code: 200},
+		{name: "get in another property", method: http.MethodGet, path: path, code: 404},
+		{name: "update in another property", method: http.MethodPut, path: path, body: `{"name":"Red"}`, headers: map[string]string{"If-Match": "*"}, code: 404},
+		{name: "set amenities in another property", method: http.MethodPut, path: path + "/amenities", body: `{"amenities":[]}`, code: 404},
+		{name: "book in another property", method: http.MethodPost, path: "/reservations/add", body: reservation, code: 404},
+		{name: "book in its property", method: http.MethodPost, path: "/reservations/add", body: reservation, headers: other, code: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, body := sendWithHeaders(t, router, tt.method, tt.path, tt.body, tt.headers)
+			if w.Code != tt.code {
+				t.Fatalf("expected %d, got %d %+v", tt.code, w.Code, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+)
+
+// Validation tags of the input structs on top of the built-in ones of
+// go-playground/validator.
+const (
+	TAG_DATE       = "date"
+	TAG_UUID       = "uuid"
+	TAG_STATUS     = "status"
+	TAG_NOT_BLANK  = "notblank"
+	TAG_DATE_RANGE = "date_range"
+
+	TAG_AMENITY_KEY  = "amenity_key"
+	TAG_AMENITY_TYPE = "amenity_type"
+)
+
+var amenityKey = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// formatTags fail when a field is missing or can't be read, reported with
+// 400. The other tags are rules on readable values, reported with 422.
+var formatTags = map[string]bool{
+	"required":    true,
+	TAG_NOT_BLANK: true,
+	TAG_DATE:      true,
+	TAG_UUID:      true,
+
+	TAG_AMENITY_KEY: true,
+}
+
+// RegisterValidators adds the custom tags to the validator Gin binds with
+// and makes it name fields by their JSON names.
+func RegisterValidators() error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("the binding validator isn't go-playground/validator")
+	}
+
+	v.RegisterTagNameFunc(jsonName)
+
+	for tag, fn := range map[string]validator.Func{
+		TAG_DATE:      isDate,
+		TAG_UUID:      isUUID,
+		TAG_STATUS:    isStatus,
+		TAG_NOT_BLANK: validators.NotBlank,
+
+		TAG_AMENITY_KEY:  isAmenityKey,
+		TAG_AMENITY_TYPE: isAmenityType,
+	} {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+	v.RegisterStructValidation(validateDateRange, DateRangeInput{})
+
+	return nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+func isDate(fl validator.FieldLevel) bool {
+	_, err := time.Parse(dateLayout, fl.Field().String())
+	return err == nil
+}
+
+// isUUID accepts what uuid.Parse does, unlike the built-in tag which only
+// takes lower case.
+func isUUID(fl validator.FieldLevel) bool {
+	_, err := uuid.Parse(fl.Field().String())
+	return err == nil
+}
+
+func isStatus(fl validator.FieldLevel) bool {
+	return model.ValidStatus(int(fl.Field().Int()))
+}
+
+func isAmenityKey(fl validator.FieldLevel) bool {
+	return amenityKey.MatchString(fl.Field().String())
+}
+
+func isAmenityType(fl validator.FieldLevel) bool {
+	return model.ValidAmenityType(fl.Field().String())
+}
+
+// validateDateRange checks that the end date is after the start date. Dates
+// that don't parse are already reported by the date tag.
+func validateDateRange(sl validator.StructLevel) {
+	input := sl.Current().Interface().(DateRangeInput)
+
+	start, err := time.Parse(dateLayout, input.StartDate)
+	if err != nil {
+		return
+	}
+	end, err := time.Parse(dateLayout, input.EndDate)
+	if err != nil {
+		return
+	}
+
+	if !end.After(start) {
+		sl.ReportError(input.EndDate, "end_date", "EndDate", TAG_DATE_RANGE, "start_date")
+	}
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", TAG_NOT_BLANK:
+		return "is required"
+	case TAG_DATE:
+		return "must be a date in YYYY-MM-DD format"
+	case TAG_UUID:
+		return "must be a valid UUID"
+	case TAG_STATUS:
+		return "must be one of " + statusList()
+	case TAG_AMENITY_KEY:
+		return "must start with a lower case letter followed by lower case letters, digits or underscores"
+	case TAG_AMENITY_TYPE:
+		return "must be one of " + strings.Join(model.AmenityTypes, ", ")
+	case TAG_DATE_RANGE:
+		return "must be after " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	default:
+		return "is invalid"
+	}
+}
+
+func statusList() string {
+	list := []string{}
+	for status := model.AVAILABLE; model.ValidStatus(status); status++ {
+		list = append(list, fmt.Sprintf("%d (%s)", status, model.StatusName(status)))
+	}
+
+	return strings.Join(list, ", ")
+}
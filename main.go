@@ -1,7 +1,7 @@
-package main
-
-import "github.com/demkowo/booking/app"
-
-func main() {
-	app.Start()
-}
+package main
+
+import "github.com/demkowo/booking/app"
+
+func main() {
+	app.Start()
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/resp"
+)
+
+const (
+	ACCOUNT_KEY = "account"
+)
+
+type Auth interface {
+	Handle(*gin.Context)
+}
+
+type auth struct {
+	enabled bool
+	secret  []byte
+	parser  *jwt.Parser
+}
+
+// NewAuth reads the account from an HS256 signed bearer token. When enabled
+// is false requests without a token are let through anonymously, a token
+// that is sent is still verified.
+func NewAuth(enabled bool, secret []byte) Auth {
+	log.Trace()
+
+	return &auth{
+		enabled: enabled,
+		secret:  secret,
+		parser:  &jwt.Parser{ValidMethods: []string{jwt.SigningMethodHS256.Alg()}},
+	}
+}
+
+func (m *auth) Handle(c *gin.Context) {
+	log.Trace()
+
+	header := c.GetHeader("Authorization")
+	if header == "" {
+		if m.enabled {
+			m.fail(c, "Missing bearer token")
+			return
+		}
+		c.Next()
+		return
+	}
+
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found || len(m.secret) == 0 {
+		m.fail(c, "Invalid bearer token")
+		return
+	}
+
+	account := &model.Account{}
+	if _, err := m.parser.ParseWithClaims(token, account, func(*jwt.Token) (interface{}, error) { return m.secret, nil }); err != nil {
+		logger.FromContext(c.Request.Context()).Warnf("invalid bearer token: %v", err)
+		m.fail(c, "Invalid bearer token")
+		return
+	}
+
+	c.Set(ACCOUNT_KEY, account)
+	entry := logger.FromContext(c.Request.Context()).WithField(logger.USER_FIELD, accountName(account))
+	c.Request = c.Request.WithContext(logger.WithEntry(c.Request.Context(), entry))
+
+	c.Next()
+}
+
+func (m *auth) fail(c *gin.Context, message string) {
+	c.Header("WWW-Authenticate", "Bearer")
+	resp.Fail(c, errs.NewError(message, 401, "Unauthorized", nil))
+}
+
+// AccountFrom returns the account of an authenticated request.
+func AccountFrom(c *gin.Context) (*model.Account, bool) {
+	value, exist := c.Get(ACCOUNT_KEY)
+	if !exist {
+		return nil, false
+	}
+	account, ok := value.(*model.Account)
+
+	return account, ok
+}
+
+func accountName(account *model.Account) string {
+	if account.ID != uuid.Nil {
+		return account.ID.String()
+	}
+	if account.Subject != "" {
+		return account.Subject
+	}
+
+	return account.Email
+}
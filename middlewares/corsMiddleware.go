@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+)
+
+type CORS interface {
+	Handle(*gin.Context)
+}
+
+type corsMiddleware struct {
+	handler gin.HandlerFunc
+}
+
+// NewCORS lets the origins in cfg call the API from a browser. Origins may
+// contain one "*" wildcard, e.g. https://*.example.com. Requests from other
+// origins get 403 and preflight requests are answered with 204 without
+// reaching the handlers. Without origins the middleware does nothing.
+func NewCORS(cfg model.CORSConfig) CORS {
+	log.Trace()
+
+	if len(cfg.AllowOrigins) == 0 {
+		return &corsMiddleware{}
+	}
+
+	return &corsMiddleware{
+		handler: cors.New(cors.Config{
+			AllowOrigins:     cfg.AllowOrigins,
+			AllowMethods:     cfg.AllowMethods,
+			AllowHeaders:     cfg.AllowHeaders,
+			ExposeHeaders:    cfg.ExposeHeaders,
+			AllowCredentials: cfg.AllowCredentials,
+			MaxAge:           cfg.MaxAge,
+			AllowWildcard:    true,
+		}),
+	}
+}
+
+func (m *corsMiddleware) Handle(c *gin.Context) {
+	log.Trace()
+
+	if m.handler == nil {
+		c.Next()
+		return
+	}
+
+	m.handler(c)
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/resp"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+const (
+	IDEMPOTENCY_KEY_HEADER      = "Idempotency-Key"
+	IDEMPOTENCY_REPLAYED_HEADER = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+type Idempotency interface {
+	Handle(*gin.Context)
+}
+
+type idempotency struct {
+	service      service.Idempotency
+	maxBodyBytes int64
+}
+
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+// NewIdempotency replays the responses of POST requests retried with the
+// same Idempotency-Key. The body of these requests is read up front, bodies
+// over maxBodyBytes are refused.
+func NewIdempotency(service service.Idempotency, maxBodyBytes int) Idempotency {
+	log.Trace()
+
+	return &idempotency{
+		service:      service,
+		maxBodyBytes: int64(maxBodyBytes),
+	}
+}
+
+func (m *idempotency) Handle(c *gin.Context) {
+	logger.FromContext(c.Request.Context()).Trace()
+
+	key := c.GetHeader(IDEMPOTENCY_KEY_HEADER)
+	if c.Request.Method != http.MethodPost || key == "" {
+		c.Next()
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		resp.Fail(c, errs.NewValidationError("Invalid input", errs.Cause{Field: IDEMPOTENCY_KEY_HEADER, Message: "must be at most 255 characters long"}))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, m.maxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		resp.Fail(c, errs.NewError(fmt.Sprintf("Request body is larger than %d bytes", tooLarge.Limit), 413, "Request Entity Too Large", nil))
+		return
+	}
+	if err != nil {
+		logger.FromContext(c.Request.Context()).Errorf("Failed to read request body: %v", err)
+		resp.Fail(c, errs.NewError("Invalid input", 400, "Bad Request", nil))
+		return
+	}
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	hash := sha256.New()
+	hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+	hash.Write(body)
+	requestHash := hex.EncodeToString(hash.Sum(nil))
+
+	stored := scopedKey(c, key)
+	record, e := m.service.Begin(c.Request.Context(), stored, requestHash)
+	if e != nil {
+		logger.FromContext(c.Request.Context()).Errorf("Failed to begin idempotent request: %s", e.Message)
+		resp.Fail(c, e)
+		return
+	}
+
+	if record != nil {
+		m.replay(c, record, requestHash)
+		return
+	}
+
+	recorder := &bodyRecorder{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+	c.Writer = recorder
+
+	completed := false
+	defer func() {
+		if completed {
+			return
+		}
+		if e := m.service.Release(context.WithoutCancel(c.Request.Context()), stored); e != nil {
+			logger.FromContext(c.Request.Context()).Errorf("Failed to release idempotency key %s: %s", key, e.Message)
+		}
+	}()
+
+	c.Next()
+
+	if recorder.Status() >= http.StatusInternalServerError {
+		return
+	}
+
+	headers := map[string]string{}
+	for _, name := range replayedHeaders {
+		if value := recorder.Header().Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+
+	if e := m.service.Complete(context.WithoutCancel(c.Request.Context()), &model.IdempotencyKey{
+		Key:         stored,
+		RequestHash: requestHash,
+		Status:      recorder.Status(),
+		Headers:     headers,
+		Response:    recorder.body.Bytes(),
+	}); e != nil {
+		logger.FromContext(c.Request.Context()).Errorf("Failed to store response for idempotency key %s: %s", key, e.Message)
+		return
+	}
+	completed = true
+}
+
+// scopedKey namespaces the key by the account and the property of the
+// request, so clients that pick the same key never get each other's
+// responses.
+func scopedKey(c *gin.Context, key string) string {
+	var account, property string
+	if a, ok := AccountFrom(c); ok {
+		account = accountName(a)
+	}
+	if p, ok := tenant.Property(c.Request.Context()); ok {
+		property = p.String()
+	}
+
+	sum := sha256.Sum256([]byte(account + "\n" + property + "\n" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func (m *idempotency) replay(c *gin.Context, record *model.IdempotencyKey, requestHash string) {
+	logger.FromContext(c.Request.Context()).Trace()
+
+	if record.RequestHash != requestHash {
+		resp.Fail(c, errs.NewError("Idempotency-Key was already used with a different request", 422, "Unprocessable Entity", nil))
+		return
+	}
+
+	if record.Status == 0 {
+		resp.Fail(c, errs.NewError("Request with this Idempotency-Key is in progress", 409, "Conflict", nil))
+		return
+	}
+
+	for name, value := range record.Headers {
+		c.Header(name, value)
+	}
+	c.Header(IDEMPOTENCY_REPLAYED_HEADER, "true")
+	c.Data(record.Status, record.Headers["Content-Type"], record.Response)
+	c.Abort()
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/repositories/memory"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+func TestIdempotencyKeysAreScoped(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	idempotency := NewIdempotency(service.NewIdempotency(memory.NewIdempotency(memory.NewStore()), time.Hour), 1<<20)
+	calls := 0
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if id := c.GetHeader("X-Account"); id != "" {
+			c.Set(ACCOUNT_KEY, &model.Account{ID: uuid.MustParse(id)})
+		}
+		if id := c.GetHeader("X-Property"); id != "" {
+			c.Request = c.Request.WithContext(tenant.WithProperty(c.Request.Context(), uuid.MustParse(id)))
+		}
+		c.Next()
+	}, idempotency.Handle)
+	router.POST("/add", func(c *gin.Context) {
+		calls++
+		c.String(http.StatusOK, "created %d", calls)
+	})
+
+	send := func(account uuid.UUID, property uuid.UUID) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/add", strings.NewReader(`{"name":"Blue"}`))
+		req.Header.Set(IDEMPOTENCY_KEY_HEADER, "retry-1")
+		req.Header.Set("X-Account", account.String())
+		req.Header.Set("X-Property", property.String())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	alice, bob := uuid.New(), uuid.New()
+	first, second := uuid.New(), uuid.New()
+
+	if w := send(alice, first); w.Body.String() != "created 1" {
+		t.Fatalf("first request: got %d %q", w.Code, w.Body.String())
+	}
+	w := send(alice, first)
+	if w.Body.String() != "created 1" || w.Header().Get(IDEMPOTENCY_REPLAYED_HEADER) != "true" {
+		t.Fatalf("retry: expected the stored response, got %d %q", w.Code, w.Body.String())
+	}
+	if w := send(bob, first); w.Body.String() != "created 2" || w.Header().Get(IDEMPOTENCY_REPLAYED_HEADER) != "" {
+		t.Fatalf("other account: expected a new request, got %d %q", w.Code, w.Body.String())
+	}
+	if w := send(alice, second); w.Body.String() != "created 3" || w.Header().Get(IDEMPOTENCY_REPLAYED_HEADER) != "" {
+		t.Fatalf("other property: expected a new request, got %d %q", w.Code, w.Body.String())
+	}
+}
+
+func TestIdempotencyLimitsTheBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	idempotency := NewIdempotency(service.NewIdempotency(memory.NewIdempotency(memory.NewStore()), time.Hour), 16)
+	calls := 0
+
+	router := gin.New()
+	router.Use(idempotency.Handle)
+	router.POST("/add", func(c *gin.Context) {
+		calls++
+		c.String(http.StatusOK, "created %d", calls)
+	})
+
+	send := func(key string, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/add", strings.NewReader(body))
+		req.Header.Set(IDEMPOTENCY_KEY_HEADER, key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("small", `{"name":"Blue"}`); w.Code != http.StatusOK {
+		t.Fatalf("small body: expected 200, got %d %q", w.Code, w.Body.String())
+	}
+	if w := send("large", `{"name":"`+strings.Repeat("a", 32)+`"}`); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("large body: expected 413, got %d %q", w.Code, w.Body.String())
+	}
+	if calls != 1 {
+		t.Fatalf("expected the large request not to reach the handler, got %d calls", calls)
+	}
+	if w := send("large", `{"name":"Red"}`); w.Code != http.StatusOK || w.Header().Get(IDEMPOTENCY_REPLAYED_HEADER) != "" {
+		t.Fatalf("retry after 413: expected a new request, got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/demkowo/booking/utils/metrics"
+)
+
+type Metrics interface {
+	Handle(*gin.Context)
+}
+
+type metricsMiddleware struct {
+}
+
+func NewMetrics() Metrics {
+	log.Trace()
+
+	return &metricsMiddleware{}
+}
+
+// Handle records the latency of every request, labelled with the route
+// template, e.g. /api/v1/rooms/:room_id, rather than the requested path.
+func (m *metricsMiddleware) Handle(c *gin.Context) {
+	log.Trace()
+
+	start := time.Now()
+	metrics.HTTPRequestsInFlight.Inc()
+	defer metrics.HTTPRequestsInFlight.Dec()
+
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = metrics.ROUTE_UNMATCHED
+	}
+
+	metrics.HTTPRequestDuration.
+		WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+		Observe(time.Since(start).Seconds())
+}
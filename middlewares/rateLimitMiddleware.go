@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/metrics"
+	"github.com/demkowo/booking/utils/ratelimit"
+	"github.com/demkowo/booking/utils/resp"
+)
+
+const (
+	RATE_LIMIT_LIMIT_HEADER     = "RateLimit-Limit"
+	RATE_LIMIT_REMAINING_HEADER = "RateLimit-Remaining"
+	RATE_LIMIT_RESET_HEADER     = "RateLimit-Reset"
+	RATE_LIMIT_POLICY_HEADER    = "RateLimit-Policy"
+	RETRY_AFTER_HEADER          = "Retry-After"
+)
+
+type RateLimit interface {
+	Handle(*gin.Context)
+}
+
+type rateLimit struct {
+	store        ratelimit.Store
+	group        string
+	rule         model.RateLimitRule
+	apiKeyHeader string
+}
+
+// NewRateLimit limits the requests of each client to rule. Clients of
+// different groups have separate buckets. A rule without requests lets
+// everything through. It has to run after the Auth middleware to limit
+// accounts rather than addresses.
+func NewRateLimit(store ratelimit.Store, group string, rule model.RateLimitRule, apiKeyHeader string) RateLimit {
+	log.Trace()
+
+	return &rateLimit{
+		store:        store,
+		group:        group,
+		rule:         rule,
+		apiKeyHeader: apiKeyHeader,
+	}
+}
+
+func (m *rateLimit) Handle(c *gin.Context) {
+	logger.FromContext(c.Request.Context()).Trace()
+
+	if m.rule.Requests == 0 {
+		c.Next()
+		return
+	}
+
+	var (
+		client string
+		result ratelimit.Result
+	)
+	for i, name := range m.clients(c) {
+		taken, err := m.store.Take(c.Request.Context(), m.group+":"+name, m.rule)
+		if err != nil {
+			logger.FromContext(c.Request.Context()).Errorf("Failed to check the %s rate limit, letting the request through: %v", m.group, err)
+			c.Next()
+			return
+		}
+		if i == 0 || !taken.Allowed || result.Allowed && taken.Remaining < result.Remaining {
+			client, result = name, taken
+		}
+		if !taken.Allowed {
+			break
+		}
+	}
+
+	c.Header(RATE_LIMIT_LIMIT_HEADER, strconv.Itoa(result.Limit))
+	c.Header(RATE_LIMIT_REMAINING_HEADER, strconv.Itoa(result.Remaining))
+	c.Header(RATE_LIMIT_RESET_HEADER, seconds(result.Reset))
+	c.Header(RATE_LIMIT_POLICY_HEADER, fmt.Sprintf("%d;w=%s;burst=%d", m.rule.Requests, seconds(m.rule.Period), ratelimit.Burst(m.rule)))
+
+	if !result.Allowed {
+		retryAfter := seconds(result.RetryAfter)
+		metrics.RateLimited.WithLabelValues(m.group).Inc()
+		logger.FromContext(c.Request.Context()).Warnf("%s rate limit exceeded by %s", m.group, client)
+
+		c.Header(RETRY_AFTER_HEADER, retryAfter)
+		resp.Fail(c, errs.NewError("Rate limit exceeded, retry in "+retryAfter+" seconds", 429, "Too Many Requests", nil))
+		return
+	}
+
+	c.Next()
+}
+
+// clients names who the limit applies to: the authenticated account, or the
+// API key and the client address, or the client address. API keys aren't
+// verified, so a request with one also counts against its address and a
+// client can't dodge the limit by sending new keys. Keys are hashed so they
+// don't end up in logs or a shared store.
+func (m *rateLimit) clients(c *gin.Context) []string {
+	if account, ok := AccountFrom(c); ok {
+		return []string{"account:" + accountName(account)}
+	}
+
+	ip := "ip:" + c.ClientIP()
+	if m.apiKeyHeader != "" {
+		if key := c.GetHeader(m.apiKeyHeader); key != "" {
+			sum := sha256.Sum256([]byte(key))
+			return []string{"key:" + hex.EncodeToString(sum[:8]), ip}
+		}
+	}
+
+	return []string{ip}
+}
+
+// seconds rounds d up to whole seconds, as the headers expect.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/ratelimit"
+)
+
+func TestRateLimitCountsAPIKeysAgainstTheAddress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limit := NewRateLimit(ratelimit.NewMemoryStore(), "api", model.RateLimitRule{Requests: 2, Period: time.Minute, Burst: 2}, "X-API-Key")
+
+	router := gin.New()
+	router.Use(limit.Handle)
+	router.GET("/rooms", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(key string, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/rooms", nil)
+		req.RemoteAddr = addr
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := range 2 {
+		if w := send(fmt.Sprintf("key-%d", i), "10.0.0.1:1234"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, w.Code)
+		}
+	}
+
+	w := send("key-new", "10.0.0.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("a new key from the same address: expected 429, got %d", w.Code)
+	}
+	if w.Header().Get(RETRY_AFTER_HEADER) == "" {
+		t.Error("expected a Retry-After header")
+	}
+
+	if w := send("key-0", "10.0.0.2:1234"); w.Code != http.StatusOK {
+		t.Fatalf("a known key from another address: expected 200, got %d", w.Code)
+	}
+	if w := send("key-0", "10.0.0.3:1234"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("an exhausted key from another address: expected 429, got %d", w.Code)
+	}
+	if w := send("", "10.0.0.4:1234"); w.Code != http.StatusOK {
+		t.Fatalf("another address without a key: expected 200, got %d", w.Code)
+	}
+}
+
+func TestRateLimitReportsTheStricterBucket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	limit := NewRateLimit(ratelimit.NewMemoryStore(), "api", model.RateLimitRule{Requests: 5, Period: time.Minute, Burst: 5}, "X-API-Key")
+
+	router := gin.New()
+	router.Use(limit.Handle)
+	router.GET("/rooms", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/rooms", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	send("")
+	send("")
+	w := send("key-1")
+	if got := w.Header().Get(RATE_LIMIT_REMAINING_HEADER); got != "2" {
+		t.Fatalf("expected the remaining requests of the address, got %q", got)
+	}
+}
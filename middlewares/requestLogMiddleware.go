@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/metrics"
+)
+
+const (
+	REQUEST_ID_HEADER = "X-Request-ID"
+)
+
+// validRequestID limits the request IDs taken from clients, so they can't
+// inject arbitrary text into the logs.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type RequestLog interface {
+	Handle(*gin.Context)
+}
+
+type requestLog struct {
+}
+
+func NewRequestLog() RequestLog {
+	log.Trace()
+
+	return &requestLog{}
+}
+
+// Handle takes the request ID from the X-Request-ID header, or generates one,
+// and returns it in the same response header. Everything logged through
+// logger.FromContext during the request carries the ID, and one access log
+// entry is written when the request is done.
+func (m *requestLog) Handle(c *gin.Context) {
+	log.Trace()
+
+	start := time.Now()
+
+	requestID := c.GetHeader(REQUEST_ID_HEADER)
+	if !validRequestID.MatchString(requestID) {
+		requestID = uuid.NewString()
+	}
+	c.Header(REQUEST_ID_HEADER, requestID)
+
+	entry := log.WithField(logger.REQUEST_ID_FIELD, requestID)
+	if span := trace.SpanContextFromContext(c.Request.Context()); span.IsValid() {
+		entry = entry.WithField(logger.TRACE_ID_FIELD, span.TraceID().String())
+	}
+	c.Request = c.Request.WithContext(logger.WithEntry(c.Request.Context(), entry))
+
+	c.Next()
+
+	route := c.FullPath()
+	if route == "" {
+		route = metrics.ROUTE_UNMATCHED
+	}
+	bytes := c.Writer.Size()
+	if bytes < 0 {
+		bytes = 0
+	}
+
+	access := logger.FromContext(c.Request.Context()).WithFields(log.Fields{
+		"method":     c.Request.Method,
+		"route":      route,
+		"path":       c.Request.URL.Path,
+		"status":     c.Writer.Status(),
+		"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+		"bytes":      bytes,
+		"client_ip":  c.ClientIP(),
+	})
+	if _, exist := access.Data[logger.USER_FIELD]; !exist {
+		access = access.WithField(logger.USER_FIELD, "")
+	}
+
+	switch status := c.Writer.Status(); {
+	case status >= http.StatusInternalServerError:
+		access.Error("request completed")
+	case status >= http.StatusBadRequest:
+		access.Warn("request completed")
+	default:
+		access.Info("request completed")
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/resp"
+)
+
+type Role interface {
+	Handle(*gin.Context)
+}
+
+type role struct {
+	name string
+}
+
+// NewRole lets through requests whose account has the role. It has to run
+// after the Auth middleware.
+func NewRole(name string) Role {
+	log.Trace()
+
+	return &role{
+		name: name,
+	}
+}
+
+func (m *role) Handle(c *gin.Context) {
+	log.Trace()
+
+	account, ok := AccountFrom(c)
+	if !ok {
+		c.Header("WWW-Authenticate", "Bearer")
+		resp.Fail(c, errs.NewError("Missing bearer token", 401, "Unauthorized", nil))
+		return
+	}
+
+	for _, r := range account.Roles {
+		if r.Name == m.name {
+			c.Next()
+			return
+		}
+	}
+
+	resp.Fail(c, errs.NewError("Account doesn't have the "+m.name+" role", 403, "Forbidden", nil))
+}
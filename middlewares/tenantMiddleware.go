@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/resp"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+const (
+	PROPERTY_PARAM = "property_id"
+)
+
+type Tenant interface {
+	Handle(*gin.Context)
+	Global(*gin.Context)
+}
+
+type tenancy struct {
+	properties service.Property
+}
+
+// NewTenant scopes requests to a property. The property comes from the
+// property_id path parameter, then from the property claim of the token, and
+// falls back to the default property. It has to run after the Auth
+// middleware.
+func NewTenant(properties service.Property) Tenant {
+	log.Trace()
+
+	return &tenancy{
+		properties: properties,
+	}
+}
+
+func (m *tenancy) Handle(c *gin.Context) {
+	logger.FromContext(c.Request.Context()).Trace()
+
+	property := model.DEFAULT_PROPERTY
+	account, _ := AccountFrom(c)
+	if account != nil && account.Property != uuid.Nil {
+		property = account.Property
+	}
+
+	if param := c.Param(PROPERTY_PARAM); param != "" {
+		id, err := uuid.Parse(param)
+		if err != nil {
+			resp.Fail(c, errs.NewValidationError("Invalid input", errs.Cause{Field: PROPERTY_PARAM, Message: "must be a valid UUID"}))
+			return
+		}
+		if account != nil && account.Property != uuid.Nil && account.Property != id {
+			resp.Fail(c, errs.NewError("Account can't access this property", 403, "Forbidden", nil))
+			return
+		}
+		property = id
+	}
+
+	if _, e := m.properties.GetByID(c.Request.Context(), property); e != nil {
+		logger.FromContext(c.Request.Context()).Tracef("property %s: %s", property, e.Message)
+		resp.Fail(c, e)
+		return
+	}
+
+	ctx := tenant.WithProperty(c.Request.Context(), property)
+	entry := logger.FromContext(ctx).WithField(logger.PROPERTY_FIELD, property.String())
+	c.Request = c.Request.WithContext(logger.WithEntry(ctx, entry))
+
+	c.Next()
+}
+
+// Global lets through requests that aren't limited to one property, for the
+// routes that work across properties.
+func (m *tenancy) Global(c *gin.Context) {
+	logger.FromContext(c.Request.Context()).Trace()
+
+	if account, ok := AccountFrom(c); ok && account.Property != uuid.Nil {
+		resp.Fail(c, errs.NewError("Account is limited to one property", 403, "Forbidden", nil))
+		return
+	}
+
+	c.Next()
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/demkowo/booking/utils/tracing"
+)
+
+type Tracing interface {
+	Handle(*gin.Context)
+}
+
+type tracingMiddleware struct {
+}
+
+func NewTracing() Tracing {
+	log.Trace()
+
+	return &tracingMiddleware{}
+}
+
+// Handle starts a server span for the request. The span continues the trace
+// of an incoming traceparent header and its context is returned in the
+// traceparent response header.
+func (m *tracingMiddleware) Handle(c *gin.Context) {
+	log.Trace()
+
+	propagator := otel.GetTextMapPropagator()
+	ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+	name := c.Request.Method
+	attributes := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(c.Request.Method),
+		semconv.URLPath(c.Request.URL.Path),
+	}
+	if route := c.FullPath(); route != "" {
+		name += " " + route
+		attributes = append(attributes, semconv.HTTPRoute(route))
+	}
+
+	ctx, span := tracing.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(attributes...))
+	defer span.End()
+
+	propagator.Inject(ctx, propagation.HeaderCarrier(c.Writer.Header()))
+	c.Request = c.Request.WithContext(ctx)
+
+	c.Next()
+
+	status := c.Writer.Status()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+	if status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(status))
+	}
+}
@@ -0,0 +1,112 @@
+package model
+
+import (
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AMENITY_BOOLEAN = "boolean"
+	AMENITY_NUMBER  = "number"
+	AMENITY_ENUM    = "enum"
+)
+
+var AmenityTypes = []string{AMENITY_BOOLEAN, AMENITY_NUMBER, AMENITY_ENUM}
+
+// Amenity is an attribute of rooms from the catalog, e.g. a sea view
+// (boolean), the number of beds (number) or the view (enum of Values).
+type Amenity struct {
+	Id      uuid.UUID
+	Key     string
+	Name    string
+	Type    string
+	Values  []string
+	Created time.Time
+	Updated time.Time
+	Version int
+}
+
+// RoomAmenity is the value an amenity has for a room, in the form Parse
+// returns it.
+type RoomAmenity struct {
+	AmenityID uuid.UUID
+	Key       string
+	Type      string
+	Value     string
+}
+
+// AmenityFilter matches the rooms that have the amenity. The service looks
+// up AmenityID by Key. Value has to match exactly, Min and Max bound the
+// value of number amenities.
+type AmenityFilter struct {
+	Key       string
+	AmenityID uuid.UUID
+	Value     *string
+	Min       *float64
+	Max       *float64
+}
+
+// ValidAmenityType reports whether t is one of the amenity types.
+func ValidAmenityType(t string) bool {
+	return slices.Contains(AmenityTypes, t)
+}
+
+// Parse returns value in the form it is stored and compared in, e.g. "1.5"
+// for "1.50", or false when it isn't a value of the amenity.
+func (a *Amenity) Parse(value string) (string, bool) {
+	switch a.Type {
+	case AMENITY_BOOLEAN:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", false
+		}
+		return strconv.FormatBool(b), true
+	case AMENITY_NUMBER:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsInf(n, 0) || math.IsNaN(n) {
+			return "", false
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), true
+	case AMENITY_ENUM:
+		return value, slices.Contains(a.Values, value)
+	default:
+		return "", false
+	}
+}
+
+// Number returns the value of a number amenity, nil for the other types.
+func (ra RoomAmenity) Number() *float64 {
+	if ra.Type != AMENITY_NUMBER {
+		return nil
+	}
+	n, err := strconv.ParseFloat(ra.Value, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &n
+}
+
+// Matches reports whether the amenity value of a room passes the filter.
+func (f AmenityFilter) Matches(ra RoomAmenity) bool {
+	if ra.AmenityID != f.AmenityID {
+		return false
+	}
+	if f.Value != nil && ra.Value != *f.Value {
+		return false
+	}
+	if f.Min == nil && f.Max == nil {
+		return true
+	}
+
+	n := ra.Number()
+	if n == nil {
+		return false
+	}
+
+	return (f.Min == nil || *n >= *f.Min) && (f.Max == nil || *n <= *f.Max)
+}
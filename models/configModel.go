@@ -1,46 +1,143 @@
-package model
-
-import (
-	"os"
-
-	log "github.com/sirupsen/logrus"
-)
-
-var (
-	Config configInterface = &ConfigStruct{}
-)
-
-type configInterface interface {
-	Get() *ConfigStruct
-	Set(*ConfigStruct)
-}
-
-type ConfigStruct struct {
-	Logrus LogrusConfig `yaml:"logrus"`
-}
-
-type LogrusConfig struct {
-	Output   []string `yaml:"output"`
-	Reporter bool     `yaml:"reporter"`
-	Format   string   `yaml:"format"`
-	Path     string   `yaml:"path"`
-	Level    int      `yaml:"level"`
-	LogFile  *os.File
-}
-
-func (c *ConfigStruct) Get() *ConfigStruct {
-	log.Trace()
-
-	return c
-}
-
-func (c *ConfigStruct) Set(cfg *ConfigStruct) {
-	log.Trace()
-
-	c.Logrus.Output = cfg.Logrus.Output
-	c.Logrus.Reporter = cfg.Logrus.Reporter
-	c.Logrus.Format = cfg.Logrus.Format
-	c.Logrus.Path = cfg.Logrus.Path
-	c.Logrus.Level = cfg.Logrus.Level
-	c.Logrus.LogFile = cfg.Logrus.LogFile
-}
+package model
+
+import (
+	"io"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	Config configInterface = &ConfigStruct{}
+)
+
+type configInterface interface {
+	Get() *ConfigStruct
+	Set(*ConfigStruct)
+}
+
+type ConfigStruct struct {
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Logrus      LogrusConfig      `yaml:"logrus"`
+	Auth        AuthConfig        `yaml:"auth"`
+	Retention   RetentionConfig   `yaml:"retention"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	CORS        CORSConfig        `yaml:"cors"`
+}
+
+type ServerConfig struct {
+	Port            int           `yaml:"port"`
+	ErrorFormat     string        `yaml:"error_format"`
+	ShutdownDelay   time.Duration `yaml:"shutdown_delay"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	TrustedProxies  []string      `yaml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	Driver          string                   `yaml:"driver"`
+	DSN             string                   `yaml:"dsn"`
+	MaxOpenConns    int                      `yaml:"max_open_conns"`
+	MaxIdleConns    int                      `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration            `yaml:"conn_max_lifetime"`
+	QueryTimeout    time.Duration            `yaml:"query_timeout"`
+	QueryTimeouts   map[string]time.Duration `yaml:"query_timeouts"`
+}
+
+type LogrusConfig struct {
+	Output      []string          `yaml:"output"`
+	Reporter    bool              `yaml:"reporter"`
+	Format      string            `yaml:"format"`
+	Path        string            `yaml:"path"`
+	Level       int               `yaml:"level"`
+	Levels      map[string]string `yaml:"levels"`
+	MaxSizeMB   int               `yaml:"max_size_mb"`
+	MaxAgeDays  int               `yaml:"max_age_days"`
+	MaxBackups  int               `yaml:"max_backups"`
+	Compress    bool              `yaml:"compress"`
+	RotateEvery time.Duration     `yaml:"rotate_every"`
+	Redact      []string          `yaml:"redact"`
+	LogFile     io.WriteCloser    `yaml:"-"`
+}
+
+type AuthConfig struct {
+	Enabled   bool   `yaml:"enabled"`
+	JWTSecret string `yaml:"jwt_secret"`
+}
+
+type RetentionConfig struct {
+	Period   time.Duration `yaml:"period"`
+	Interval time.Duration `yaml:"interval"`
+	Mode     string        `yaml:"mode"`
+}
+
+type IdempotencyConfig struct {
+	TTL          time.Duration `yaml:"ttl"`
+	MaxBodyBytes int           `yaml:"max_body_bytes"`
+}
+
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter"`
+	Endpoint    string  `yaml:"endpoint"`
+	Path        string  `yaml:"path"`
+	SampleRatio float64 `yaml:"sample_ratio"`
+	ServiceName string  `yaml:"service_name"`
+}
+
+type RateLimitConfig struct {
+	Enabled      bool                     `yaml:"enabled"`
+	APIKeyHeader string                   `yaml:"api_key_header"`
+	Limits       map[string]RateLimitRule `yaml:"limits"`
+}
+
+// RateLimitRule allows Requests per Period, refilled evenly, with up to
+// Burst requests at once. Burst defaults to Requests.
+type RateLimitRule struct {
+	Requests int           `yaml:"requests"`
+	Period   time.Duration `yaml:"period"`
+	Burst    int           `yaml:"burst"`
+}
+
+type CORSConfig struct {
+	AllowOrigins     []string      `yaml:"allow_origins"`
+	AllowMethods     []string      `yaml:"allow_methods"`
+	AllowHeaders     []string      `yaml:"allow_headers"`
+	ExposeHeaders    []string      `yaml:"expose_headers"`
+	AllowCredentials bool          `yaml:"allow_credentials"`
+	MaxAge           time.Duration `yaml:"max_age"`
+}
+
+func (c *ConfigStruct) Get() *ConfigStruct {
+	log.Trace()
+
+	return c
+}
+
+func (c *ConfigStruct) Set(cfg *ConfigStruct) {
+	log.Trace()
+
+	c.Server = cfg.Server
+	c.Database = cfg.Database
+	c.Auth = cfg.Auth
+	c.Retention = cfg.Retention
+	c.Idempotency = cfg.Idempotency
+	c.Tracing = cfg.Tracing
+	c.RateLimit = cfg.RateLimit
+	c.CORS = cfg.CORS
+
+	c.Logrus.Output = cfg.Logrus.Output
+	c.Logrus.Reporter = cfg.Logrus.Reporter
+	c.Logrus.Format = cfg.Logrus.Format
+	c.Logrus.Path = cfg.Logrus.Path
+	c.Logrus.Level = cfg.Logrus.Level
+	c.Logrus.Levels = cfg.Logrus.Levels
+	c.Logrus.MaxSizeMB = cfg.Logrus.MaxSizeMB
+	c.Logrus.MaxAgeDays = cfg.Logrus.MaxAgeDays
+	c.Logrus.MaxBackups = cfg.Logrus.MaxBackups
+	c.Logrus.Compress = cfg.Logrus.Compress
+	c.Logrus.RotateEvery = cfg.Logrus.RotateEvery
+	c.Logrus.Redact = cfg.Logrus.Redact
+	c.Logrus.LogFile = cfg.Logrus.LogFile
+}
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+type IdempotencyKey struct {
+	Key         string
+	RequestHash string
+	Status      int
+	Headers     map[string]string
+	Response    []byte
+	Created     time.Time
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DEFAULT_PROPERTY owns the rooms created before properties were added, and
+// serves requests that name no property.
+var DEFAULT_PROPERTY = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+type Property struct {
+	Id      uuid.UUID
+	Name    string
+	Created time.Time
+	Updated time.Time
+	Version int
+}
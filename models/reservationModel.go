@@ -1,27 +1,66 @@
-package model
-
-import (
-	"time"
-
-	uuid "github.com/google/uuid"
-)
-
-const (
-	AVAILABLE    = 0
-	BLOCKED      = 1
-	BOOK_REQUEST = 2
-	RESERVATION  = 3
-	RENT         = 4
-)
-
-type Reservation struct {
-	Id        uuid.UUID
-	UserId    uuid.UUID
-	RoomID    uuid.UUID
-	Status    int
-	StartDate time.Time
-	EndDate   time.Time
-	Created   time.Time
-	Updated   time.Time
-	Deleted   bool
-}
+package model
+
+import (
+	"time"
+
+	uuid "github.com/google/uuid"
+)
+
+const (
+	AVAILABLE    = 0
+	BLOCKED      = 1
+	BOOK_REQUEST = 2
+	RESERVATION  = 3
+	RENT         = 4
+)
+
+var statusNames = map[int]string{
+	AVAILABLE:    "available",
+	BLOCKED:      "blocked",
+	BOOK_REQUEST: "book_request",
+	RESERVATION:  "reservation",
+	RENT:         "rent",
+}
+
+// Occupancy is the number of guests staying in a room.
+type Occupancy struct {
+	Adults   int
+	Children int
+}
+
+func (o Occupancy) Total() int {
+	return o.Adults + o.Children
+}
+
+type Reservation struct {
+	Id         uuid.UUID
+	PropertyID uuid.UUID
+	UserId     uuid.UUID
+	RoomID     uuid.UUID
+	Status     int
+	StartDate  time.Time
+	EndDate    time.Time
+	Created    time.Time
+	Updated    time.Time
+	Deleted    bool
+	DeletedAt  *time.Time
+	Version    int
+
+	Occupancy
+}
+
+// ValidStatus reports whether status is one of the reservation statuses.
+func ValidStatus(status int) bool {
+	_, exist := statusNames[status]
+
+	return exist
+}
+
+// StatusName returns the name of a reservation status, or "unknown".
+func StatusName(status int) string {
+	if name, exist := statusNames[status]; exist {
+		return name
+	}
+
+	return "unknown"
+}
@@ -1,14 +1,53 @@
-package model
-
-import (
-	"time"
-
-	"github.com/google/uuid"
-)
-
-type Room struct {
-	Id      uuid.UUID
-	Name    string
-	Created time.Time
-	Updated time.Time
-}
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/demkowo/booking/utils/errs"
+)
+
+// Room capacity limits of 0 mean no limit.
+type Room struct {
+	Id           uuid.UUID
+	PropertyID   uuid.UUID
+	Name         string
+	MaxAdults    int
+	MaxChildren  int
+	MaxOccupancy int
+	Created      time.Time
+	Updated      time.Time
+	Archived     *time.Time
+	Version      int
+	Amenities    []RoomAmenity
+}
+
+// RoomSearch selects the rooms free for a stay that fit the guests and
+// have every Required amenity. Rooms having more of the Preferred amenities
+// rank first.
+type RoomSearch struct {
+	Start     time.Time
+	End       time.Time
+	Occupancy Occupancy
+	Required  []AmenityFilter
+	Preferred []AmenityFilter
+}
+
+// Accommodates returns the limits of the room the occupancy exceeds, none
+// when it fits.
+func (r *Room) Accommodates(o Occupancy) []errs.Cause {
+	causes := []errs.Cause{}
+	if r.MaxAdults > 0 && o.Adults > r.MaxAdults {
+		causes = append(causes, errs.Cause{Field: "adults", Message: fmt.Sprintf("must be at most %d for this room", r.MaxAdults)})
+	}
+	if r.MaxChildren > 0 && o.Children > r.MaxChildren {
+		causes = append(causes, errs.Cause{Field: "children", Message: fmt.Sprintf("must be at most %d for this room", r.MaxChildren)})
+	}
+	if r.MaxOccupancy > 0 && o.Total() > r.MaxOccupancy {
+		causes = append(causes, errs.Cause{Field: "occupancy", Message: fmt.Sprintf("adults and children must be at most %d for this room", r.MaxOccupancy)})
+	}
+
+	return causes
+}
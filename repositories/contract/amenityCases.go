@@ -0,0 +1,297 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+)
+
+func amenityCatalog(ctx context.Context, b Backend) error {
+	amenity, err := addAmenity(ctx, b, model.AMENITY_ENUM, "sea", "garden")
+	if err != nil {
+		return err
+	}
+	if amenity.Version != 1 {
+		return fmt.Errorf("Amenities.Add: expected version 1, got %d", amenity.Version)
+	}
+
+	duplicate := *amenity
+	duplicate.Id = uuid.New()
+	if err := expectCode("Amenities.Add duplicate key", b.Amenities.Add(ctx, &duplicate), 409); err != nil {
+		return err
+	}
+
+	found, e := b.Amenities.GetByID(ctx, amenity.Id)
+	if err := expectOK("Amenities.GetByID", e); err != nil {
+		return err
+	}
+	if found.Key != amenity.Key || found.Type != model.AMENITY_ENUM || !slices.Equal(found.Values, amenity.Values) {
+		return fmt.Errorf("Amenities.GetByID: got %+v", found)
+	}
+
+	amenity.Name = "renamed"
+	amenity.Values = append(amenity.Values, "city")
+	if err := expectOK("Amenities.Update", b.Amenities.Update(ctx, amenity)); err != nil {
+		return err
+	}
+	if amenity.Version != 2 {
+		return fmt.Errorf("Amenities.Update: expected version 2, got %d", amenity.Version)
+	}
+
+	stale := *amenity
+	stale.Version = 1
+	if err := expectCode("Amenities.Update stale", b.Amenities.Update(ctx, &stale), 412); err != nil {
+		return err
+	}
+
+	list, e := b.Amenities.Find(ctx)
+	if err := expectOK("Amenities.Find", e); err != nil {
+		return err
+	}
+	listed := false
+	for _, a := range list {
+		if a.Id == amenity.Id {
+			listed = a.Name == "renamed" && slices.Equal(a.Values, []string{"sea", "garden", "city"})
+		}
+	}
+	if !listed {
+		return fmt.Errorf("Amenities.Find: updated amenity %s missing", amenity.Id)
+	}
+
+	if err := expectOK("Amenities.Delete", b.Amenities.Delete(ctx, amenity.Id)); err != nil {
+		return err
+	}
+	if err := expectCode("Amenities.Delete twice", b.Amenities.Delete(ctx, amenity.Id), 404); err != nil {
+		return err
+	}
+	_, e = b.Amenities.GetByID(ctx, amenity.Id)
+	return expectCode("Amenities.GetByID deleted", e, 404)
+}
+
+// roomAmenitiesChangeVersion checks that setting the amenities of a room, or
+// deleting one of them from the catalog, bumps the version of the room, so
+// its ETag changes with its amenities.
+func roomAmenitiesChangeVersion(ctx context.Context, b Backend) error {
+	balcony, err := addAmenity(ctx, b, model.AMENITY_BOOLEAN)
+	if err != nil {
+		return err
+	}
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	other, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+
+	expectVersion := func(call string, id uuid.UUID, version int) error {
+		found, e := b.Rooms.GetByID(ctx, id)
+		if err := expectOK(call, e); err != nil {
+			return err
+		}
+		if found.Version != version {
+			return fmt.Errorf("%s: expected version %d, got %d", call, version, found.Version)
+		}
+		return nil
+	}
+
+	amenities := []model.RoomAmenity{roomAmenity(balcony, "true")}
+	if err := expectOK("Amenities.SetRoomAmenities", b.Amenities.SetRoomAmenities(ctx, room.Id, amenities)); err != nil {
+		return err
+	}
+	if err := expectVersion("Rooms.GetByID after SetRoomAmenities", room.Id, 2); err != nil {
+		return err
+	}
+
+	if err := expectOK("Amenities.Delete", b.Amenities.Delete(ctx, balcony.Id)); err != nil {
+		return err
+	}
+	if err := expectVersion("Rooms.GetByID after Amenities.Delete", room.Id, 3); err != nil {
+		return err
+	}
+
+	return expectVersion("Rooms.GetByID of a room without the amenity", other.Id, 1)
+}
+
+func roomAmenities(ctx context.Context, b Backend) error {
+	balcony, err := addAmenity(ctx, b, model.AMENITY_BOOLEAN)
+	if err != nil {
+		return err
+	}
+	beds, err := addAmenity(ctx, b, model.AMENITY_NUMBER)
+	if err != nil {
+		return err
+	}
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	other, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+
+	amenities := []model.RoomAmenity{roomAmenity(beds, "2"), roomAmenity(balcony, "true")}
+	if err := expectOK("Amenities.SetRoomAmenities", b.Amenities.SetRoomAmenities(ctx, room.Id, amenities)); err != nil {
+		return err
+	}
+
+	found, e := b.Amenities.FindByRoomIDs(ctx, []uuid.UUID{room.Id, other.Id})
+	if err := expectOK("Amenities.FindByRoomIDs", e); err != nil {
+		return err
+	}
+	if _, exist := found[other.Id]; exist {
+		return fmt.Errorf("Amenities.FindByRoomIDs: room %s without amenities listed", other.Id)
+	}
+	if len(found[room.Id]) != 2 || found[room.Id][0].Key > found[room.Id][1].Key {
+		return fmt.Errorf("Amenities.FindByRoomIDs: expected 2 amenities ordered by key, got %+v", found[room.Id])
+	}
+	for _, ra := range found[room.Id] {
+		if ra.AmenityID == beds.Id && (ra.Value != "2" || ra.Type != model.AMENITY_NUMBER) {
+			return fmt.Errorf("Amenities.FindByRoomIDs: got %+v", ra)
+		}
+	}
+
+	amenities = []model.RoomAmenity{roomAmenity(beds, "3")}
+	if err := expectOK("Amenities.SetRoomAmenities replace", b.Amenities.SetRoomAmenities(ctx, room.Id, amenities)); err != nil {
+		return err
+	}
+	found, e = b.Amenities.FindByRoomIDs(ctx, []uuid.UUID{room.Id})
+	if err := expectOK("Amenities.FindByRoomIDs replaced", e); err != nil {
+		return err
+	}
+	if len(found[room.Id]) != 1 || found[room.Id][0].Value != "3" {
+		return fmt.Errorf("Amenities.FindByRoomIDs replaced: got %+v", found[room.Id])
+	}
+
+	if err := expectOK("Amenities.Delete", b.Amenities.Delete(ctx, beds.Id)); err != nil {
+		return err
+	}
+	found, e = b.Amenities.FindByRoomIDs(ctx, []uuid.UUID{room.Id})
+	if err := expectOK("Amenities.FindByRoomIDs deleted", e); err != nil {
+		return err
+	}
+	if len(found[room.Id]) != 0 {
+		return fmt.Errorf("Amenities.FindByRoomIDs: deleted amenity still on room %s", room.Id)
+	}
+
+	return nil
+}
+
+// roomAmenitySearch checks that rooms lacking a required amenity are left
+// out and that rooms matching more preferred amenities come first.
+func roomAmenitySearch(ctx context.Context, b Backend) error {
+	seaView, err := addAmenity(ctx, b, model.AMENITY_BOOLEAN)
+	if err != nil {
+		return err
+	}
+	beds, err := addAmenity(ctx, b, model.AMENITY_NUMBER)
+	if err != nil {
+		return err
+	}
+	view, err := addAmenity(ctx, b, model.AMENITY_ENUM, "sea", "garden")
+	if err != nil {
+		return err
+	}
+
+	rooms := map[string]*model.Room{}
+	for name, amenities := range map[string][]model.RoomAmenity{
+		"sea":    {roomAmenity(seaView, "true"), roomAmenity(beds, "2"), roomAmenity(view, "sea")},
+		"garden": {roomAmenity(seaView, "false"), roomAmenity(beds, "3"), roomAmenity(view, "garden")},
+		"plain":  {},
+	} {
+		room, err := addRoom(ctx, b, "contract room")
+		if err != nil {
+			return err
+		}
+		if err := expectOK("Amenities.SetRoomAmenities", b.Amenities.SetRoomAmenities(ctx, room.Id, amenities)); err != nil {
+			return err
+		}
+		rooms[name] = room
+	}
+
+	start := day()
+	checks := []struct {
+		name      string
+		required  []model.AmenityFilter
+		preferred []model.AmenityFilter
+		listed    []string
+	}{
+		{"no filters", nil, nil, []string{"sea", "garden", "plain"}},
+		{"boolean", []model.AmenityFilter{{AmenityID: seaView.Id, Value: text("true")}}, nil, []string{"sea"}},
+		{"any value", []model.AmenityFilter{{AmenityID: seaView.Id}}, nil, []string{"sea", "garden"}},
+		{"enum", []model.AmenityFilter{{AmenityID: view.Id, Value: text("garden")}}, nil, []string{"garden"}},
+		{"number range", []model.AmenityFilter{{AmenityID: beds.Id, Min: number(2.5)}}, nil, []string{"garden"}},
+		{"number bounds", []model.AmenityFilter{{AmenityID: beds.Id, Min: number(2), Max: number(2)}}, nil, []string{"sea"}},
+		{"all required", []model.AmenityFilter{{AmenityID: seaView.Id, Value: text("true")}, {AmenityID: beds.Id, Min: number(3)}}, nil, []string{}},
+		{"preferred ranks", nil, []model.AmenityFilter{{AmenityID: beds.Id, Min: number(3)}, {AmenityID: view.Id, Value: text("garden")}, {AmenityID: seaView.Id}}, []string{"garden", "sea", "plain"}},
+		{"required and preferred", []model.AmenityFilter{{AmenityID: beds.Id}}, []model.AmenityFilter{{AmenityID: view.Id, Value: text("sea")}}, []string{"sea", "garden"}},
+	}
+	for _, check := range checks {
+		found, e := b.Rooms.FindAvailable(ctx, model.RoomSearch{Start: start, End: start.AddDate(0, 0, 1), Required: check.required, Preferred: check.preferred})
+		if err := expectOK("Rooms.FindAvailable "+check.name, e); err != nil {
+			return err
+		}
+
+		listed := []string{}
+		for _, room := range found {
+			for name, ours := range rooms {
+				if room.Id == ours.Id {
+					listed = append(listed, name)
+				}
+			}
+		}
+		if !slices.Equal(listed, check.listed) && (check.preferred != nil || !sameNames(listed, check.listed)) {
+			return fmt.Errorf("Rooms.FindAvailable %s: expected %v, got %v", check.name, check.listed, listed)
+		}
+	}
+
+	return nil
+}
+
+func addAmenity(ctx context.Context, b Backend, kind string, values ...string) (*model.Amenity, error) {
+	now := time.Now()
+	amenity := &model.Amenity{
+		Id:      uuid.New(),
+		Key:     fmt.Sprintf("contract_%s_%d", kind, rand.Int63()),
+		Name:    "contract amenity",
+		Type:    kind,
+		Values:  append([]string{}, values...),
+		Created: now,
+		Updated: now,
+	}
+	if err := b.Amenities.Add(ctx, amenity); err != nil {
+		return nil, fmt.Errorf("Amenities.Add: %s", err.Message)
+	}
+
+	return amenity, nil
+}
+
+func roomAmenity(amenity *model.Amenity, value string) model.RoomAmenity {
+	return model.RoomAmenity{AmenityID: amenity.Id, Key: amenity.Key, Type: amenity.Type, Value: value}
+}
+
+// sameNames compares the rooms listed without ranking, where the order of
+// rooms with the same name isn't defined.
+func sameNames(a []string, b []string) bool {
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+
+	return slices.Equal(a, b)
+}
+
+func text(value string) *string {
+	return &value
+}
+
+func number(value float64) *float64 {
+	return &value
+}
@@ -0,0 +1,155 @@
+// Package contract holds the behaviour every repository backend has to share.
+// The cases only touch rows they create themselves, in a property created
+// for the run, so they can run against a database that is already in use.
+package contract
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+type Backend struct {
+	Properties   service.PropertyRepo
+	Rooms        service.RoomRepo
+	Reservations service.ReservationRepo
+	Amenities    service.AmenityRepo
+	UnitOfWork   service.UnitOfWork
+}
+
+type Case struct {
+	Name string
+	Run  func(context.Context, Backend) error
+}
+
+type Result struct {
+	Name string
+	Err  error
+}
+
+func Cases() []Case {
+	return []Case{
+		{"property catalog", propertyCatalog},
+		{"property isolation", propertyIsolation},
+		{"unscoped context is refused", unscopedContext},
+		{"room add and get", roomAddAndGet},
+		{"room update checks version", roomUpdateChecksVersion},
+		{"room archive hides room", roomArchiveHidesRoom},
+		{"room capacity", roomCapacity},
+		{"amenity catalog", amenityCatalog},
+		{"room amenities", roomAmenities},
+		{"room amenities change the room version", roomAmenitiesChangeVersion},
+		{"room amenity search", roomAmenitySearch},
+		{"reservation add and get", reservationAddAndGet},
+		{"reservation occupancy", reservationOccupancy},
+		{"reservation overlap", reservationOverlap},
+		{"reservation soft delete and restore", reservationSoftDeleteAndRestore},
+		{"reservation update checks version", reservationUpdateChecksVersion},
+		{"reservation update keeps status", reservationUpdateKeepsStatus},
+		{"reservation retention", reservationRetention},
+		{"reservation move", reservationMove},
+		{"unit of work rolls back", unitOfWorkRollsBack},
+	}
+}
+
+// Run executes every case against the backend and returns one result per
+// case, in order. The cases run scoped to a new property.
+func Run(ctx context.Context, backend Backend) []Result {
+	results := []Result{}
+
+	property, err := addProperty(ctx, backend)
+	if err != nil {
+		for _, c := range Cases() {
+			results = append(results, Result{Name: c.Name, Err: err})
+		}
+		return results
+	}
+	ctx = tenant.WithProperty(ctx, property.Id)
+
+	for _, c := range Cases() {
+		results = append(results, Result{Name: c.Name, Err: c.Run(ctx, backend)})
+	}
+
+	return results
+}
+
+// day returns midnight UTC of a random day far in the future, so cases don't
+// collide with reservations that already exist.
+func day() time.Time {
+	return time.Date(2100+rand.Intn(100), time.January, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, rand.Intn(300))
+}
+
+func addRoom(ctx context.Context, b Backend, name string) (*model.Room, error) {
+	room := &model.Room{Id: uuid.New(), Name: name}
+	if err := b.Rooms.Add(ctx, room); err != nil {
+		return nil, fmt.Errorf("Rooms.Add: %s", err.Message)
+	}
+
+	return room, nil
+}
+
+func addReservation(ctx context.Context, b Backend, roomID uuid.UUID, start time.Time, nights int) (*model.Reservation, error) {
+	now := time.Now()
+	reservation := &model.Reservation{
+		Id:        uuid.New(),
+		UserId:    uuid.New(),
+		RoomID:    roomID,
+		Status:    model.RESERVATION,
+		StartDate: start,
+		EndDate:   start.AddDate(0, 0, nights),
+		Created:   now,
+		Updated:   now,
+	}
+	if err := b.Reservations.Add(ctx, reservation); err != nil {
+		return nil, fmt.Errorf("Reservations.Add: %s", err.Message)
+	}
+
+	return reservation, nil
+}
+
+func expectCode(call string, err *errs.Error, code int) error {
+	if err == nil {
+		return fmt.Errorf("%s: expected %d, got no error", call, code)
+	}
+	if err.Code != code {
+		return fmt.Errorf("%s: expected %d, got %d %s", call, code, err.Code, err.Message)
+	}
+
+	return nil
+}
+
+func expectOK(call string, err *errs.Error) error {
+	if err != nil {
+		return fmt.Errorf("%s: %d %s", call, err.Code, err.Message)
+	}
+
+	return nil
+}
+
+func containsRoom(rooms []*model.Room, id uuid.UUID) bool {
+	for _, room := range rooms {
+		if room.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsReservation(reservations []*model.Reservation, id uuid.UUID) bool {
+	for _, reservation := range reservations {
+		if reservation.Id == id {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,300 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+func propertyCatalog(ctx context.Context, b Backend) error {
+	property, err := addProperty(ctx, b)
+	if err != nil {
+		return err
+	}
+	if property.Version != 1 {
+		return fmt.Errorf("Properties.Add: expected version 1, got %d", property.Version)
+	}
+
+	found, e := b.Properties.GetByID(ctx, property.Id)
+	if err := expectOK("Properties.GetByID", e); err != nil {
+		return err
+	}
+	if found.Name != property.Name {
+		return fmt.Errorf("Properties.GetByID: got %+v", found)
+	}
+
+	_, e = b.Properties.GetByID(ctx, model.DEFAULT_PROPERTY)
+	if err := expectOK("Properties.GetByID default", e); err != nil {
+		return err
+	}
+	_, e = b.Properties.GetByID(ctx, uuid.New())
+	if err := expectCode("Properties.GetByID unknown", e, 404); err != nil {
+		return err
+	}
+
+	property.Name = "renamed property"
+	if err := expectOK("Properties.Update", b.Properties.Update(ctx, property)); err != nil {
+		return err
+	}
+	if property.Version != 2 {
+		return fmt.Errorf("Properties.Update: expected version 2, got %d", property.Version)
+	}
+
+	stale := *property
+	stale.Version = 1
+	if err := expectCode("Properties.Update stale", b.Properties.Update(ctx, &stale), 412); err != nil {
+		return err
+	}
+
+	list, e := b.Properties.Find(ctx)
+	if err := expectOK("Properties.Find", e); err != nil {
+		return err
+	}
+	for _, p := range list {
+		if p.Id == property.Id && p.Name == "renamed property" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Properties.Find: updated property %s missing", property.Id)
+}
+
+// propertyIsolation checks that rooms and reservations of one property can't
+// be read or changed from another one.
+func propertyIsolation(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	start := day()
+	reservation, err := addReservation(ctx, b, room.Id, start, 2)
+	if err != nil {
+		return err
+	}
+	deleted, err := addReservation(ctx, b, room.Id, reservation.EndDate, 1)
+	if err != nil {
+		return err
+	}
+	if err := expectOK("Reservations.Delete", b.Reservations.Delete(ctx, deleted.Id.String())); err != nil {
+		return err
+	}
+	amenity, err := addAmenity(ctx, b, model.AMENITY_BOOLEAN)
+	if err != nil {
+		return err
+	}
+	if err := expectOK("Amenities.SetRoomAmenities", b.Amenities.SetRoomAmenities(ctx, room.Id, []model.RoomAmenity{roomAmenity(amenity, "true")})); err != nil {
+		return err
+	}
+	if room.PropertyID != scopedProperty(ctx) || reservation.PropertyID != scopedProperty(ctx) {
+		return fmt.Errorf("Add: expected property %s, got room %s and reservation %s", scopedProperty(ctx), room.PropertyID, reservation.PropertyID)
+	}
+
+	other, err := addProperty(ctx, b)
+	if err != nil {
+		return err
+	}
+	foreign := tenant.WithProperty(ctx, other.Id)
+
+	rooms, e := b.Rooms.Find(foreign)
+	if err := expectOK("Rooms.Find", e); err != nil {
+		return err
+	}
+	if containsRoom(rooms, room.Id) {
+		return fmt.Errorf("Rooms.Find: room %s listed in another property", room.Id)
+	}
+	rooms, e = b.Rooms.FindAvailable(foreign, model.RoomSearch{Start: start.AddDate(0, 0, 5), End: start.AddDate(0, 0, 6)})
+	if err := expectOK("Rooms.FindAvailable", e); err != nil {
+		return err
+	}
+	if containsRoom(rooms, room.Id) {
+		return fmt.Errorf("Rooms.FindAvailable: room %s listed in another property", room.Id)
+	}
+	_, e = b.Rooms.GetByID(foreign, room.Id)
+	if err := expectCode("Rooms.GetByID", e, 404); err != nil {
+		return err
+	}
+	available, e := b.Rooms.CheckIfAvailableById(foreign, room.Id, start.AddDate(0, 0, 5), start.AddDate(0, 0, 6))
+	if err := expectOK("Rooms.CheckIfAvailableById", e); err != nil {
+		return err
+	}
+	if available {
+		return fmt.Errorf("Rooms.CheckIfAvailableById: room %s available in another property", room.Id)
+	}
+	if err := expectCode("Rooms.LockActive", b.Rooms.LockActive(foreign, room.Id), 404); err != nil {
+		return err
+	}
+	renamed := *room
+	renamed.Name = "taken over"
+	if err := expectCode("Rooms.Update", b.Rooms.Update(foreign, &renamed), 404); err != nil {
+		return err
+	}
+	if err := expectCode("Rooms.Archive", b.Rooms.Archive(foreign, room.Id), 404); err != nil {
+		return err
+	}
+
+	reservations, e := b.Reservations.Find(foreign)
+	if err := expectOK("Reservations.Find", e); err != nil {
+		return err
+	}
+	if containsReservation(reservations, reservation.Id) {
+		return fmt.Errorf("Reservations.Find: reservation %s listed in another property", reservation.Id)
+	}
+	reservations, e = b.Reservations.FindByRoomID(foreign, room.Id)
+	if err := expectOK("Reservations.FindByRoomID", e); err != nil {
+		return err
+	}
+	if len(reservations) != 0 {
+		return fmt.Errorf("Reservations.FindByRoomID: got %d reservations of another property", len(reservations))
+	}
+	reservations, e = b.Reservations.FindDeleted(foreign)
+	if err := expectOK("Reservations.FindDeleted", e); err != nil {
+		return err
+	}
+	if containsReservation(reservations, deleted.Id) {
+		return fmt.Errorf("Reservations.FindDeleted: reservation %s listed in another property", deleted.Id)
+	}
+	_, e = b.Reservations.GetByID(foreign, reservation.Id)
+	if err := expectCode("Reservations.GetByID", e, 404); err != nil {
+		return err
+	}
+	_, e = b.Reservations.GetDeletedByID(foreign, deleted.Id)
+	if err := expectCode("Reservations.GetDeletedByID", e, 404); err != nil {
+		return err
+	}
+	overlap, e := b.Reservations.HasOverlap(foreign, room.Id, start, start.AddDate(0, 0, 1), uuid.Nil)
+	if err := expectOK("Reservations.HasOverlap", e); err != nil {
+		return err
+	}
+	if overlap {
+		return fmt.Errorf("Reservations.HasOverlap: reservation of another property overlaps")
+	}
+	count, e := b.Reservations.CountFutureByRoomID(foreign, room.Id, time.Now())
+	if err := expectOK("Reservations.CountFutureByRoomID", e); err != nil {
+		return err
+	}
+	if count != 0 {
+		return fmt.Errorf("Reservations.CountFutureByRoomID: got %d reservations of another property", count)
+	}
+
+	moved := *reservation
+	moved.StartDate = moved.StartDate.AddDate(0, 0, 10)
+	moved.EndDate = moved.EndDate.AddDate(0, 0, 10)
+	if err := expectCode("Reservations.Update", b.Reservations.Update(foreign, &moved), 404); err != nil {
+		return err
+	}
+	if err := expectCode("Reservations.Delete", b.Reservations.Delete(foreign, reservation.Id.String()), 404); err != nil {
+		return err
+	}
+	if err := expectCode("Reservations.Restore", b.Reservations.Restore(foreign, deleted.Id), 404); err != nil {
+		return err
+	}
+	if _, err := addReservation(foreign, b, room.Id, start.AddDate(0, 0, 20), 1); err == nil {
+		return fmt.Errorf("Reservations.Add: booked a room of another property")
+	}
+
+	local, err := addRoom(foreign, b, "contract room")
+	if err != nil {
+		return err
+	}
+	if _, e := b.Reservations.MoveFuture(foreign, local.Id, room.Id, time.Now()); e == nil {
+		return fmt.Errorf("Reservations.MoveFuture: moved into a room of another property")
+	}
+	count, e = b.Reservations.MoveFuture(foreign, room.Id, local.Id, time.Now())
+	if err := expectOK("Reservations.MoveFuture", e); err != nil {
+		return err
+	}
+	if count != 0 {
+		return fmt.Errorf("Reservations.MoveFuture: moved %d reservations of another property", count)
+	}
+	if _, e := b.Reservations.PurgeDeleted(foreign, time.Now().Add(time.Hour)); e != nil {
+		return fmt.Errorf("Reservations.PurgeDeleted: %d %s", e.Code, e.Message)
+	}
+	if _, e := b.Reservations.AnonymizeDeleted(foreign, time.Now().Add(time.Hour)); e != nil {
+		return fmt.Errorf("Reservations.AnonymizeDeleted: %d %s", e.Code, e.Message)
+	}
+
+	amenities, e := b.Amenities.FindByRoomIDs(foreign, []uuid.UUID{room.Id})
+	if err := expectOK("Amenities.FindByRoomIDs", e); err != nil {
+		return err
+	}
+	if len(amenities[room.Id]) != 0 {
+		return fmt.Errorf("Amenities.FindByRoomIDs: got amenities of a room of another property")
+	}
+	if err := expectCode("Amenities.SetRoomAmenities", b.Amenities.SetRoomAmenities(foreign, room.Id, nil), 404); err != nil {
+		return err
+	}
+
+	stored, e := b.Reservations.GetByID(ctx, reservation.Id)
+	if err := expectOK("Reservations.GetByID own property", e); err != nil {
+		return err
+	}
+	if stored.RoomID != room.Id || !stored.StartDate.Equal(reservation.StartDate) || stored.Version != reservation.Version {
+		return fmt.Errorf("Reservations.GetByID own property: changed from another property, got %+v", stored)
+	}
+	restored, e := b.Reservations.GetDeletedByID(ctx, deleted.Id)
+	if err := expectOK("Reservations.GetDeletedByID own property", e); err != nil {
+		return err
+	}
+	if restored.UserId != deleted.UserId {
+		return fmt.Errorf("Reservations.AnonymizeDeleted: anonymized a reservation of another property")
+	}
+	amenities, e = b.Amenities.FindByRoomIDs(ctx, []uuid.UUID{room.Id})
+	if err := expectOK("Amenities.FindByRoomIDs own property", e); err != nil {
+		return err
+	}
+	if len(amenities[room.Id]) != 1 {
+		return fmt.Errorf("Amenities.FindByRoomIDs own property: expected 1 amenity, got %d", len(amenities[room.Id]))
+	}
+	found, e := b.Rooms.GetByID(ctx, room.Id)
+	if err := expectOK("Rooms.GetByID own property", e); err != nil {
+		return err
+	}
+	if found.Name != room.Name || found.Archived != nil {
+		return fmt.Errorf("Rooms.GetByID own property: changed from another property, got %+v", found)
+	}
+
+	return nil
+}
+
+// unscopedContext checks that repositories refuse to run without a property
+// instead of reading every property.
+func unscopedContext(ctx context.Context, b Backend) error {
+	unscoped := tenant.WithProperty(ctx, uuid.Nil)
+
+	_, e := b.Rooms.Find(unscoped)
+	if err := expectCode("Rooms.Find", e, 500); err != nil {
+		return err
+	}
+	_, e = b.Reservations.Find(unscoped)
+	if err := expectCode("Reservations.Find", e, 500); err != nil {
+		return err
+	}
+	_, e = b.Reservations.PurgeDeleted(unscoped, time.Now())
+	if err := expectCode("Reservations.PurgeDeleted", e, 500); err != nil {
+		return err
+	}
+	_, e = b.Amenities.FindByRoomIDs(unscoped, []uuid.UUID{uuid.New()})
+
+	return expectCode("Amenities.FindByRoomIDs", e, 500)
+}
+
+func addProperty(ctx context.Context, b Backend) (*model.Property, error) {
+	now := time.Now()
+	property := &model.Property{Id: uuid.New(), Name: "contract property", Created: now, Updated: now}
+	if err := b.Properties.Add(ctx, property); err != nil {
+		return nil, fmt.Errorf("Properties.Add: %s", err.Message)
+	}
+
+	return property, nil
+}
+
+func scopedProperty(ctx context.Context) uuid.UUID {
+	property, _ := tenant.Property(ctx)
+
+	return property
+}
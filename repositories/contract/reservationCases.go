@@ -0,0 +1,465 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+)
+
+func reservationAddAndGet(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	reservation, err := addReservation(ctx, b, room.Id, day(), 2)
+	if err != nil {
+		return err
+	}
+
+	found, e := b.Reservations.GetByID(ctx, reservation.Id)
+	if err := expectOK("Reservations.GetByID", e); err != nil {
+		return err
+	}
+	if found.RoomID != room.Id || found.UserId != reservation.UserId || !found.StartDate.Equal(reservation.StartDate) ||
+		!found.EndDate.Equal(reservation.EndDate) || found.Deleted || found.Version != 1 {
+		return fmt.Errorf("Reservations.GetByID: got %+v", found)
+	}
+
+	list, e := b.Reservations.FindByRoomID(ctx, room.Id)
+	if err := expectOK("Reservations.FindByRoomID", e); err != nil {
+		return err
+	}
+	if len(list) != 1 || list[0].Id != reservation.Id {
+		return fmt.Errorf("Reservations.FindByRoomID: expected only %s, got %d reservations", reservation.Id, len(list))
+	}
+
+	list, e = b.Reservations.Find(ctx)
+	if err := expectOK("Reservations.Find", e); err != nil {
+		return err
+	}
+	if !containsReservation(list, reservation.Id) {
+		return fmt.Errorf("Reservations.Find: reservation %s missing", reservation.Id)
+	}
+
+	_, e = b.Reservations.GetByID(ctx, uuid.New())
+	return expectCode("Reservations.GetByID unknown", e, 404)
+}
+
+func reservationOccupancy(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	reservation := &model.Reservation{
+		Id:        uuid.New(),
+		UserId:    uuid.New(),
+		RoomID:    room.Id,
+		Status:    model.RESERVATION,
+		Occupancy: model.Occupancy{Adults: 2, Children: 1},
+		StartDate: day(),
+		Created:   time.Now(),
+		Updated:   time.Now(),
+	}
+	reservation.EndDate = reservation.StartDate.AddDate(0, 0, 2)
+	if err := expectOK("Reservations.Add", b.Reservations.Add(ctx, reservation)); err != nil {
+		return err
+	}
+
+	found, e := b.Reservations.GetByID(ctx, reservation.Id)
+	if err := expectOK("Reservations.GetByID", e); err != nil {
+		return err
+	}
+	if found.Occupancy != reservation.Occupancy {
+		return fmt.Errorf("Reservations.GetByID: expected occupancy %+v, got %+v", reservation.Occupancy, found.Occupancy)
+	}
+
+	reservation.Occupancy = model.Occupancy{Adults: 1}
+	if err := expectOK("Reservations.Update", b.Reservations.Update(ctx, reservation)); err != nil {
+		return err
+	}
+
+	list, e := b.Reservations.FindByRoomID(ctx, room.Id)
+	if err := expectOK("Reservations.FindByRoomID", e); err != nil {
+		return err
+	}
+	if len(list) != 1 || list[0].Occupancy != reservation.Occupancy {
+		return fmt.Errorf("Reservations.FindByRoomID: expected occupancy %+v", reservation.Occupancy)
+	}
+
+	return nil
+}
+
+// reservationOverlap checks that stays are half-open ranges: a stay ending on
+// the day another one starts doesn't overlap it.
+func reservationOverlap(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	other, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	start := day()
+	reservation, err := addReservation(ctx, b, room.Id, start, 2)
+	if err != nil {
+		return err
+	}
+
+	checks := []struct {
+		name    string
+		room    uuid.UUID
+		start   time.Time
+		end     time.Time
+		exclude uuid.UUID
+		overlap bool
+	}{
+		{"same dates", room.Id, start, start.AddDate(0, 0, 2), uuid.Nil, true},
+		{"inside", room.Id, start.AddDate(0, 0, 1), start.AddDate(0, 0, 2), uuid.Nil, true},
+		{"around", room.Id, start.AddDate(0, 0, -1), start.AddDate(0, 0, 3), uuid.Nil, true},
+		{"ends on start", room.Id, start.AddDate(0, 0, -1), start, uuid.Nil, false},
+		{"starts on end", room.Id, start.AddDate(0, 0, 2), start.AddDate(0, 0, 3), uuid.Nil, false},
+		{"excluded", room.Id, start, start.AddDate(0, 0, 2), reservation.Id, false},
+		{"other room", other.Id, start, start.AddDate(0, 0, 2), uuid.Nil, false},
+	}
+
+	for _, check := range checks {
+		overlap, e := b.Reservations.HasOverlap(ctx, check.room, check.start, check.end, check.exclude)
+		if err := expectOK("Reservations.HasOverlap "+check.name, e); err != nil {
+			return err
+		}
+		if overlap != check.overlap {
+			return fmt.Errorf("Reservations.HasOverlap %s: expected %t", check.name, check.overlap)
+		}
+
+		if check.exclude != uuid.Nil {
+			continue
+		}
+
+		rooms, e := b.Rooms.FindAvailable(ctx, model.RoomSearch{Start: check.start, End: check.end})
+		if err := expectOK("Rooms.FindAvailable "+check.name, e); err != nil {
+			return err
+		}
+		if containsRoom(rooms, check.room) == check.overlap {
+			return fmt.Errorf("Rooms.FindAvailable %s: expected available %t", check.name, !check.overlap)
+		}
+
+		available, e := b.Rooms.CheckIfAvailableById(ctx, check.room, check.start, check.end)
+		if err := expectOK("Rooms.CheckIfAvailableById "+check.name, e); err != nil {
+			return err
+		}
+		if available == check.overlap {
+			return fmt.Errorf("Rooms.CheckIfAvailableById %s: expected %t", check.name, !check.overlap)
+		}
+	}
+
+	return nil
+}
+
+func reservationSoftDeleteAndRestore(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	start := day()
+	reservation, err := addReservation(ctx, b, room.Id, start, 2)
+	if err != nil {
+		return err
+	}
+
+	if err := expectOK("Reservations.Delete", b.Reservations.Delete(ctx, reservation.Id.String())); err != nil {
+		return err
+	}
+	if err := expectCode("Reservations.Delete twice", b.Reservations.Delete(ctx, reservation.Id.String()), 404); err != nil {
+		return err
+	}
+
+	_, e := b.Reservations.GetByID(ctx, reservation.Id)
+	if err := expectCode("Reservations.GetByID deleted", e, 404); err != nil {
+		return err
+	}
+
+	deleted, e := b.Reservations.GetDeletedByID(ctx, reservation.Id)
+	if err := expectOK("Reservations.GetDeletedByID", e); err != nil {
+		return err
+	}
+	if !deleted.Deleted || deleted.DeletedAt == nil || deleted.Version != 2 {
+		return fmt.Errorf("Reservations.GetDeletedByID: got %+v", deleted)
+	}
+
+	list, e := b.Reservations.FindByRoomID(ctx, room.Id)
+	if err := expectOK("Reservations.FindByRoomID", e); err != nil {
+		return err
+	}
+	if len(list) != 0 {
+		return fmt.Errorf("Reservations.FindByRoomID: deleted reservation listed")
+	}
+
+	list, e = b.Reservations.FindDeleted(ctx)
+	if err := expectOK("Reservations.FindDeleted", e); err != nil {
+		return err
+	}
+	if !containsReservation(list, reservation.Id) {
+		return fmt.Errorf("Reservations.FindDeleted: reservation %s missing", reservation.Id)
+	}
+
+	overlap, e := b.Reservations.HasOverlap(ctx, room.Id, start, start.AddDate(0, 0, 2), uuid.Nil)
+	if err := expectOK("Reservations.HasOverlap", e); err != nil {
+		return err
+	}
+	if overlap {
+		return fmt.Errorf("Reservations.HasOverlap: deleted reservation counted")
+	}
+
+	if err := expectOK("Reservations.Restore", b.Reservations.Restore(ctx, reservation.Id)); err != nil {
+		return err
+	}
+	if err := expectCode("Reservations.Restore twice", b.Reservations.Restore(ctx, reservation.Id), 404); err != nil {
+		return err
+	}
+
+	restored, e := b.Reservations.GetByID(ctx, reservation.Id)
+	if err := expectOK("Reservations.GetByID restored", e); err != nil {
+		return err
+	}
+	if restored.Deleted || restored.DeletedAt != nil || restored.Version != 3 {
+		return fmt.Errorf("Reservations.GetByID restored: got %+v", restored)
+	}
+
+	return expectCode("Reservations.Delete unknown", b.Reservations.Delete(ctx, uuid.New().String()), 404)
+}
+
+// reservationUpdateKeepsStatus checks that Update leaves the status alone and
+// reports the stored one.
+func reservationUpdateKeepsStatus(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	reservation, err := addReservation(ctx, b, room.Id, day(), 2)
+	if err != nil {
+		return err
+	}
+
+	reservation.Status = model.AVAILABLE
+	if err := expectOK("Reservations.Update", b.Reservations.Update(ctx, reservation)); err != nil {
+		return err
+	}
+	if reservation.Status != model.RESERVATION {
+		return fmt.Errorf("Reservations.Update: expected status %d, got %d", model.RESERVATION, reservation.Status)
+	}
+
+	found, e := b.Reservations.GetByID(ctx, reservation.Id)
+	if err := expectOK("Reservations.GetByID", e); err != nil {
+		return err
+	}
+	if found.Status != model.RESERVATION {
+		return fmt.Errorf("Reservations.GetByID: expected status %d, got %d", model.RESERVATION, found.Status)
+	}
+
+	return nil
+}
+
+func reservationUpdateChecksVersion(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	reservation, err := addReservation(ctx, b, room.Id, day(), 2)
+	if err != nil {
+		return err
+	}
+
+	reservation.EndDate = reservation.EndDate.AddDate(0, 0, 1)
+	if err := expectOK("Reservations.Update", b.Reservations.Update(ctx, reservation)); err != nil {
+		return err
+	}
+	if reservation.Version != 2 {
+		return fmt.Errorf("Reservations.Update: expected version 2, got %d", reservation.Version)
+	}
+
+	stale := *reservation
+	stale.Version = 1
+	if err := expectCode("Reservations.Update stale", b.Reservations.Update(ctx, &stale), 412); err != nil {
+		return err
+	}
+
+	found, e := b.Reservations.GetByID(ctx, reservation.Id)
+	if err := expectOK("Reservations.GetByID", e); err != nil {
+		return err
+	}
+	if !found.EndDate.Equal(reservation.EndDate) || found.Version != 2 {
+		return fmt.Errorf("Reservations.GetByID: got %+v", found)
+	}
+
+	if err := expectOK("Reservations.Delete", b.Reservations.Delete(ctx, reservation.Id.String())); err != nil {
+		return err
+	}
+	reservation.Version = 0
+	return expectCode("Reservations.Update deleted", b.Reservations.Update(ctx, reservation), 404)
+}
+
+func reservationRetention(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	anonymized, err := addReservation(ctx, b, room.Id, day(), 1)
+	if err != nil {
+		return err
+	}
+	kept, err := addReservation(ctx, b, room.Id, anonymized.EndDate, 1)
+	if err != nil {
+		return err
+	}
+
+	if err := expectOK("Reservations.Delete", b.Reservations.Delete(ctx, anonymized.Id.String())); err != nil {
+		return err
+	}
+
+	future := time.Now().Add(time.Hour)
+	count, e := b.Reservations.AnonymizeDeleted(ctx, future)
+	if err := expectOK("Reservations.AnonymizeDeleted", e); err != nil {
+		return err
+	}
+	if count < 1 {
+		return fmt.Errorf("Reservations.AnonymizeDeleted: expected at least 1, got %d", count)
+	}
+
+	deleted, e := b.Reservations.GetDeletedByID(ctx, anonymized.Id)
+	if err := expectOK("Reservations.GetDeletedByID", e); err != nil {
+		return err
+	}
+	if deleted.UserId != uuid.Nil {
+		return fmt.Errorf("Reservations.AnonymizeDeleted: user id kept")
+	}
+
+	count, e = b.Reservations.PurgeDeleted(ctx, future)
+	if err := expectOK("Reservations.PurgeDeleted", e); err != nil {
+		return err
+	}
+	if count < 1 {
+		return fmt.Errorf("Reservations.PurgeDeleted: expected at least 1, got %d", count)
+	}
+
+	_, e = b.Reservations.GetDeletedByID(ctx, anonymized.Id)
+	if err := expectCode("Reservations.GetDeletedByID purged", e, 404); err != nil {
+		return err
+	}
+
+	found, e := b.Reservations.GetByID(ctx, kept.Id)
+	if err := expectOK("Reservations.GetByID kept", e); err != nil {
+		return err
+	}
+	if found.UserId != kept.UserId {
+		return fmt.Errorf("Reservations.AnonymizeDeleted: active reservation anonymized")
+	}
+
+	return nil
+}
+
+func reservationMove(ctx context.Context, b Backend) error {
+	from, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	to, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	start := day()
+	if _, err := addReservation(ctx, b, from.Id, start, 2); err != nil {
+		return err
+	}
+	moving, err := addReservation(ctx, b, from.Id, start.AddDate(0, 0, 2), 2)
+	if err != nil {
+		return err
+	}
+	if _, err := addReservation(ctx, b, to.Id, start.AddDate(0, 0, 1), 2); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	count, e := b.Reservations.CountFutureByRoomID(ctx, from.Id, now)
+	if err := expectOK("Reservations.CountFutureByRoomID", e); err != nil {
+		return err
+	}
+	if count != 2 {
+		return fmt.Errorf("Reservations.CountFutureByRoomID: expected 2, got %d", count)
+	}
+
+	conflicts, e := b.Reservations.CountMoveConflicts(ctx, from.Id, to.Id, now)
+	if err := expectOK("Reservations.CountMoveConflicts", e); err != nil {
+		return err
+	}
+	if conflicts != 2 {
+		return fmt.Errorf("Reservations.CountMoveConflicts: expected 2, got %d", conflicts)
+	}
+
+	count, e = b.Reservations.CountFutureByRoomID(ctx, from.Id, moving.EndDate)
+	if err := expectOK("Reservations.CountFutureByRoomID past", e); err != nil {
+		return err
+	}
+	if count != 0 {
+		return fmt.Errorf("Reservations.CountFutureByRoomID past: expected 0, got %d", count)
+	}
+
+	empty, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	moved, e := b.Reservations.MoveFuture(ctx, from.Id, empty.Id, now)
+	if err := expectOK("Reservations.MoveFuture", e); err != nil {
+		return err
+	}
+	if moved != 2 {
+		return fmt.Errorf("Reservations.MoveFuture: expected 2, got %d", moved)
+	}
+
+	found, e := b.Reservations.GetByID(ctx, moving.Id)
+	if err := expectOK("Reservations.GetByID moved", e); err != nil {
+		return err
+	}
+	if found.RoomID != empty.Id || found.Version != 2 {
+		return fmt.Errorf("Reservations.GetByID moved: got %+v", found)
+	}
+
+	return nil
+}
+
+func unitOfWorkRollsBack(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+
+	var added uuid.UUID
+	e := b.UnitOfWork.Do(ctx, func(ctx context.Context) *errs.Error {
+		reservation, err := addReservation(ctx, b, room.Id, day(), 1)
+		if err != nil {
+			return errs.NewError(err.Error(), 500, "Internal Server Error", nil)
+		}
+		added = reservation.Id
+
+		if err := b.Rooms.Archive(ctx, room.Id); err != nil {
+			return err
+		}
+
+		return errs.NewError("rollback", 409, "Conflict", nil)
+	})
+	if err := expectCode("UnitOfWork.Do", e, 409); err != nil {
+		return err
+	}
+
+	_, e = b.Reservations.GetByID(ctx, added)
+	if err := expectCode("Reservations.GetByID rolled back", e, 404); err != nil {
+		return err
+	}
+
+	return expectOK("Rooms.LockActive rolled back", b.Rooms.LockActive(ctx, room.Id))
+}
@@ -0,0 +1,193 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+)
+
+func roomAddAndGet(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+	if room.Version != 1 {
+		return fmt.Errorf("Rooms.Add: expected version 1, got %d", room.Version)
+	}
+
+	found, e := b.Rooms.GetByID(ctx, room.Id)
+	if err := expectOK("Rooms.GetByID", e); err != nil {
+		return err
+	}
+	if found.Name != room.Name || found.Version != 1 || found.Archived != nil {
+		return fmt.Errorf("Rooms.GetByID: got %+v", found)
+	}
+
+	rooms, e := b.Rooms.Find(ctx)
+	if err := expectOK("Rooms.Find", e); err != nil {
+		return err
+	}
+	if !containsRoom(rooms, room.Id) {
+		return fmt.Errorf("Rooms.Find: room %s missing", room.Id)
+	}
+
+	_, e = b.Rooms.GetByID(ctx, uuid.New())
+	return expectCode("Rooms.GetByID unknown", e, 404)
+}
+
+func roomUpdateChecksVersion(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+
+	room.Name = "renamed"
+	if err := expectOK("Rooms.Update", b.Rooms.Update(ctx, room)); err != nil {
+		return err
+	}
+	if room.Version != 2 {
+		return fmt.Errorf("Rooms.Update: expected version 2, got %d", room.Version)
+	}
+
+	stale := *room
+	stale.Version = 1
+	if err := expectCode("Rooms.Update stale", b.Rooms.Update(ctx, &stale), 412); err != nil {
+		return err
+	}
+
+	room.Version = 0
+	if err := expectOK("Rooms.Update unconditional", b.Rooms.Update(ctx, room)); err != nil {
+		return err
+	}
+
+	found, e := b.Rooms.GetByID(ctx, room.Id)
+	if err := expectOK("Rooms.GetByID", e); err != nil {
+		return err
+	}
+	if found.Name != "renamed" || found.Version != 3 {
+		return fmt.Errorf("Rooms.GetByID: got %+v", found)
+	}
+
+	missing := *room
+	missing.Id = uuid.New()
+	return expectCode("Rooms.Update unknown", b.Rooms.Update(ctx, &missing), 404)
+}
+
+// roomCapacity checks capacity limits are stored and filter available rooms,
+// a limit of 0 means no limit.
+func roomCapacity(ctx context.Context, b Backend) error {
+	room := &model.Room{Id: uuid.New(), Name: "contract room", MaxAdults: 2, MaxChildren: 2, MaxOccupancy: 3}
+	if err := expectOK("Rooms.Add", b.Rooms.Add(ctx, room)); err != nil {
+		return err
+	}
+	unlimited, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+
+	found, e := b.Rooms.GetByID(ctx, room.Id)
+	if err := expectOK("Rooms.GetByID", e); err != nil {
+		return err
+	}
+	if found.MaxAdults != 2 || found.MaxChildren != 2 || found.MaxOccupancy != 3 {
+		return fmt.Errorf("Rooms.GetByID: got %+v", found)
+	}
+
+	start := day()
+	checks := []struct {
+		name      string
+		occupancy model.Occupancy
+		fits      bool
+	}{
+		{"no occupancy", model.Occupancy{}, true},
+		{"within limits", model.Occupancy{Adults: 2, Children: 1}, true},
+		{"too many adults", model.Occupancy{Adults: 3}, false},
+		{"too many children", model.Occupancy{Adults: 1, Children: 3}, false},
+		{"too many guests", model.Occupancy{Adults: 2, Children: 2}, false},
+	}
+	for _, check := range checks {
+		rooms, e := b.Rooms.FindAvailable(ctx, model.RoomSearch{Start: start, End: start.AddDate(0, 0, 1), Occupancy: check.occupancy})
+		if err := expectOK("Rooms.FindAvailable "+check.name, e); err != nil {
+			return err
+		}
+		if containsRoom(rooms, room.Id) != check.fits {
+			return fmt.Errorf("Rooms.FindAvailable %s: expected listed %t", check.name, check.fits)
+		}
+		if !containsRoom(rooms, unlimited.Id) {
+			return fmt.Errorf("Rooms.FindAvailable %s: room without limits missing", check.name)
+		}
+	}
+
+	room.MaxAdults = 4
+	room.MaxOccupancy = 0
+	if err := expectOK("Rooms.Update", b.Rooms.Update(ctx, room)); err != nil {
+		return err
+	}
+	rooms, e := b.Rooms.FindAvailable(ctx, model.RoomSearch{Start: start, End: start.AddDate(0, 0, 1), Occupancy: model.Occupancy{Adults: 4, Children: 2}})
+	if err := expectOK("Rooms.FindAvailable updated", e); err != nil {
+		return err
+	}
+	if !containsRoom(rooms, room.Id) {
+		return fmt.Errorf("Rooms.FindAvailable updated: room %s missing", room.Id)
+	}
+
+	return nil
+}
+
+func roomArchiveHidesRoom(ctx context.Context, b Backend) error {
+	room, err := addRoom(ctx, b, "contract room")
+	if err != nil {
+		return err
+	}
+
+	if err := expectOK("Rooms.LockActive", b.Rooms.LockActive(ctx, room.Id)); err != nil {
+		return err
+	}
+	if err := expectOK("Rooms.Archive", b.Rooms.Archive(ctx, room.Id)); err != nil {
+		return err
+	}
+	if err := expectCode("Rooms.Archive twice", b.Rooms.Archive(ctx, room.Id), 404); err != nil {
+		return err
+	}
+	if err := expectCode("Rooms.LockActive archived", b.Rooms.LockActive(ctx, room.Id), 404); err != nil {
+		return err
+	}
+
+	found, e := b.Rooms.GetByID(ctx, room.Id)
+	if err := expectOK("Rooms.GetByID archived", e); err != nil {
+		return err
+	}
+	if found.Archived == nil || found.Version != 2 {
+		return fmt.Errorf("Rooms.GetByID archived: got %+v", found)
+	}
+
+	rooms, e := b.Rooms.Find(ctx)
+	if err := expectOK("Rooms.Find", e); err != nil {
+		return err
+	}
+	if containsRoom(rooms, room.Id) {
+		return fmt.Errorf("Rooms.Find: archived room %s listed", room.Id)
+	}
+
+	start := day()
+	rooms, e = b.Rooms.FindAvailable(ctx, model.RoomSearch{Start: start, End: start.AddDate(0, 0, 1)})
+	if err := expectOK("Rooms.FindAvailable", e); err != nil {
+		return err
+	}
+	if containsRoom(rooms, room.Id) {
+		return fmt.Errorf("Rooms.FindAvailable: archived room %s listed", room.Id)
+	}
+
+	available, e := b.Rooms.CheckIfAvailableById(ctx, room.Id, start, start.AddDate(0, 0, 1))
+	if err := expectOK("Rooms.CheckIfAvailableById", e); err != nil {
+		return err
+	}
+	if available {
+		return fmt.Errorf("Rooms.CheckIfAvailableById: archived room %s available", room.Id)
+	}
+
+	return nil
+}
@@ -0,0 +1,217 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+type AmenityRepo interface {
+	CreateTableAmenities(context.Context) string
+
+	Add(context.Context, *model.Amenity) *errs.Error
+	Delete(context.Context, uuid.UUID) *errs.Error
+	Find(context.Context) ([]*model.Amenity, *errs.Error)
+	FindByRoomIDs(context.Context, []uuid.UUID) (map[uuid.UUID][]model.RoomAmenity, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Amenity, *errs.Error)
+	SetRoomAmenities(context.Context, uuid.UUID, []model.RoomAmenity) *errs.Error
+	Update(context.Context, *model.Amenity) *errs.Error
+}
+
+type amenity struct {
+	store *Store
+}
+
+func NewAmenity(store *Store) AmenityRepo {
+	return &amenity{
+		store: store,
+	}
+}
+
+func (r *amenity) CreateTableAmenities(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return "Memory amenities ready to go"
+}
+
+func (r *amenity) Add(ctx context.Context, amenity *model.Amenity) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	return r.store.write(ctx, func() *errs.Error {
+		for _, stored := range r.store.amenities {
+			if stored.Key == amenity.Key {
+				logger.FromContext(ctx).Tracef("amenity key %s already exists", amenity.Key)
+				return errs.NewError("Amenity key already exists", 409, "Conflict", nil)
+			}
+		}
+
+		amenity.Version = 1
+		stored := *amenity
+		stored.Values = slices.Clone(amenity.Values)
+		r.store.amenities[amenity.Id] = stored
+
+		return nil
+	})
+}
+
+// Delete removes the amenity from the catalog and from every room.
+func (r *amenity) Delete(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	return r.store.write(ctx, func() *errs.Error {
+		if _, exist := r.store.amenities[id]; !exist {
+			logger.FromContext(ctx).Tracef("amenity %s not found", id)
+			return errs.NewError("amenity not found", 404, "Not Found", nil)
+		}
+
+		delete(r.store.amenities, id)
+		updated := time.Now()
+		for roomID, amenities := range r.store.roomAmenities {
+			kept := slices.DeleteFunc(slices.Clone(amenities), func(ra model.RoomAmenity) bool {
+				return ra.AmenityID == id
+			})
+			if len(kept) != len(amenities) {
+				r.store.touchRoom(roomID, updated)
+			}
+			r.store.roomAmenities[roomID] = kept
+		}
+
+		return nil
+	})
+}
+
+func (r *amenity) Find(ctx context.Context) ([]*model.Amenity, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	amenities := []*model.Amenity{}
+	err := r.store.read(ctx, func() *errs.Error {
+		for _, stored := range r.store.amenities {
+			amenities = append(amenities, copyAmenity(stored))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(amenities, func(i, j int) bool {
+		return amenities[i].Key < amenities[j].Key
+	})
+
+	return amenities, nil
+}
+
+// FindByRoomIDs returns the amenities of the rooms ordered by key, rooms
+// without amenities are left out.
+func (r *amenity) FindByRoomIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]model.RoomAmenity, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	amenities := map[uuid.UUID][]model.RoomAmenity{}
+	err := r.store.read(ctx, func() *errs.Error {
+		for _, id := range ids {
+			if list := r.store.roomAmenities[id]; len(list) > 0 && r.store.roomIn(id, property) {
+				amenities[id] = slices.Clone(list)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return amenities, nil
+}
+
+func (r *amenity) GetByID(ctx context.Context, id uuid.UUID) (*model.Amenity, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	var amenity *model.Amenity
+	err := r.store.read(ctx, func() *errs.Error {
+		stored, exist := r.store.amenities[id]
+		if !exist {
+			logger.FromContext(ctx).Tracef("amenity %s not found", id)
+			return errs.NewError("amenity not found", 404, "Not Found", nil)
+		}
+		amenity = copyAmenity(stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return amenity, nil
+}
+
+// SetRoomAmenities replaces the amenities of a room.
+func (r *amenity) SetRoomAmenities(ctx context.Context, roomID uuid.UUID, amenities []model.RoomAmenity) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	return r.store.write(ctx, func() *errs.Error {
+		if !r.store.roomIn(roomID, property) {
+			logger.FromContext(ctx).Tracef("room %s not found", roomID)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+
+		list := slices.Clone(amenities)
+		sort.Slice(list, func(i, j int) bool {
+			return list[i].Key < list[j].Key
+		})
+		r.store.roomAmenities[roomID] = list
+		r.store.touchRoom(roomID, time.Now())
+
+		return nil
+	})
+}
+
+func (r *amenity) Update(ctx context.Context, amenity *model.Amenity) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	return r.store.write(ctx, func() *errs.Error {
+		stored, exist := r.store.amenities[amenity.Id]
+		if !exist {
+			logger.FromContext(ctx).Tracef("amenity %s not found", amenity.Id)
+			return errs.NewError("amenity not found", 404, "Not Found", nil)
+		}
+		if amenity.Version != 0 && amenity.Version != stored.Version {
+			logger.FromContext(ctx).Tracef("amenity %s version %d is stale", amenity.Id, amenity.Version)
+			return errs.NewError("amenity was modified by another request", 412, "Precondition Failed", nil)
+		}
+
+		updated := time.Now()
+		stored.Name = amenity.Name
+		stored.Values = slices.Clone(amenity.Values)
+		stored.Updated = updated
+		stored.Version++
+		r.store.amenities[amenity.Id] = stored
+
+		amenity.Updated = updated
+		amenity.Version = stored.Version
+
+		return nil
+	})
+}
+
+func copyAmenity(stored model.Amenity) *model.Amenity {
+	amenity := stored
+	amenity.Values = slices.Clone(stored.Values)
+
+	return &amenity
+}
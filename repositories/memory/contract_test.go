@@ -0,0 +1,28 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/demkowo/booking/repositories/contract"
+	"github.com/demkowo/booking/repositories/memory"
+)
+
+func TestContract(t *testing.T) {
+	store := memory.NewStore()
+	backend := contract.Backend{
+		Properties:   memory.NewProperty(store),
+		Rooms:        memory.NewRoom(store),
+		Reservations: memory.NewReservation(store),
+		Amenities:    memory.NewAmenity(store),
+		UnitOfWork:   memory.NewUnitOfWork(store),
+	}
+
+	for _, result := range contract.Run(context.Background(), backend) {
+		t.Run(result.Name, func(t *testing.T) {
+			if result.Err != nil {
+				t.Error(result.Err)
+			}
+		})
+	}
+}
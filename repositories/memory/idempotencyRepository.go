@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"time"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+)
+
+type IdempotencyRepo interface {
+	CreateTableIdempotencyKeys(context.Context) string
+
+	Complete(context.Context, *model.IdempotencyKey) *errs.Error
+	DeleteExpired(context.Context, string, time.Time) *errs.Error
+	Get(context.Context, string) (*model.IdempotencyKey, *errs.Error)
+	Release(context.Context, string) *errs.Error
+	Reserve(context.Context, *model.IdempotencyKey) (bool, *errs.Error)
+}
+
+type idempotency struct {
+	store *Store
+}
+
+func NewIdempotency(store *Store) IdempotencyRepo {
+	return &idempotency{
+		store: store,
+	}
+}
+
+func (r *idempotency) CreateTableIdempotencyKeys(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return "Memory idempotency_keys ready to go"
+}
+
+func (r *idempotency) Complete(ctx context.Context, key *model.IdempotencyKey) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	return r.store.write(ctx, func() *errs.Error {
+		stored, exist := r.store.idempotencyKeys[key.Key]
+		if !exist {
+			return nil
+		}
+
+		stored.Status = key.Status
+		stored.Headers = make(map[string]string, len(key.Headers))
+		for name, value := range key.Headers {
+			stored.Headers[name] = value
+		}
+		stored.Response = append([]byte(nil), key.Response...)
+		r.store.idempotencyKeys[key.Key] = stored
+
+		return nil
+	})
+}
+
+func (r *idempotency) DeleteExpired(ctx context.Context, key string, before time.Time) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	return r.store.write(ctx, func() *errs.Error {
+		if stored, exist := r.store.idempotencyKeys[key]; exist && stored.Created.Before(before) {
+			delete(r.store.idempotencyKeys, key)
+		}
+		return nil
+	})
+}
+
+func (r *idempotency) Get(ctx context.Context, key string) (*model.IdempotencyKey, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	var record *model.IdempotencyKey
+	err := r.store.read(ctx, func() *errs.Error {
+		stored, exist := r.store.idempotencyKeys[key]
+		if !exist {
+			logger.FromContext(ctx).Tracef("idempotency key %s not found", key)
+			return errs.NewError("idempotency key not found", 404, "Not Found", nil)
+		}
+		record = &stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (r *idempotency) Release(ctx context.Context, key string) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	return r.store.write(ctx, func() *errs.Error {
+		if stored, exist := r.store.idempotencyKeys[key]; exist && stored.Status == 0 {
+			delete(r.store.idempotencyKeys, key)
+		}
+		return nil
+	})
+}
+
+func (r *idempotency) Reserve(ctx context.Context, key *model.IdempotencyKey) (bool, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	var reserved bool
+	err := r.store.write(ctx, func() *errs.Error {
+		if _, exist := r.store.idempotencyKeys[key.Key]; exist {
+			return nil
+		}
+
+		r.store.idempotencyKeys[key.Key] = model.IdempotencyKey{
+			Key:         key.Key,
+			RequestHash: key.RequestHash,
+			Created:     key.Created,
+		}
+		reserved = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return reserved, nil
+}
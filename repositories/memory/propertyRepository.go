@@ -0,0 +1,123 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+)
+
+type PropertyRepo interface {
+	CreateTableProperties(context.Context) string
+
+	Add(context.Context, *model.Property) *errs.Error
+	Find(context.Context) ([]*model.Property, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Property, *errs.Error)
+	Update(context.Context, *model.Property) *errs.Error
+}
+
+type property struct {
+	store *Store
+}
+
+func NewProperty(store *Store) PropertyRepo {
+	return &property{
+		store: store,
+	}
+}
+
+func (r *property) CreateTableProperties(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return "Memory properties ready to go"
+}
+
+func (r *property) Add(ctx context.Context, property *model.Property) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	return r.store.write(ctx, func() *errs.Error {
+		if _, exist := r.store.properties[property.Id]; exist {
+			logger.FromContext(ctx).Errorf("property %s already exists", property.Id)
+			return errs.NewError("Failed to create property", 500, "Internal Server Error", []interface{}{})
+		}
+
+		property.Version = 1
+		r.store.properties[property.Id] = *property
+
+		return nil
+	})
+}
+
+func (r *property) Find(ctx context.Context) ([]*model.Property, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	properties := []*model.Property{}
+	err := r.store.read(ctx, func() *errs.Error {
+		for _, stored := range r.store.properties {
+			property := stored
+			properties = append(properties, &property)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(properties, func(i, j int) bool {
+		return properties[i].Name < properties[j].Name
+	})
+
+	return properties, nil
+}
+
+func (r *property) GetByID(ctx context.Context, id uuid.UUID) (*model.Property, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	var property model.Property
+	err := r.store.read(ctx, func() *errs.Error {
+		stored, exist := r.store.properties[id]
+		if !exist {
+			logger.FromContext(ctx).Tracef("property %s not found", id)
+			return errs.NewError("property not found", 404, "Not Found", nil)
+		}
+		property = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &property, nil
+}
+
+func (r *property) Update(ctx context.Context, property *model.Property) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	return r.store.write(ctx, func() *errs.Error {
+		stored, exist := r.store.properties[property.Id]
+		if !exist {
+			logger.FromContext(ctx).Tracef("property %s not found", property.Id)
+			return errs.NewError("property not found", 404, "Not Found", nil)
+		}
+		if property.Version != 0 && property.Version != stored.Version {
+			logger.FromContext(ctx).Tracef("property %s version %d is stale", property.Id, property.Version)
+			return errs.NewError("property was modified by another request", 412, "Precondition Failed", nil)
+		}
+
+		updated := time.Now()
+		stored.Name = property.Name
+		stored.Updated = updated
+		stored.Version++
+		r.store.properties[property.Id] = stored
+
+		property.Updated = updated
+		property.Version = stored.Version
+
+		return nil
+	})
+}
@@ -0,0 +1,468 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+type ReservationRepo interface {
+	CreateTableReservations(context.Context) string
+
+	Add(context.Context, *model.Reservation) *errs.Error
+	AnonymizeDeleted(context.Context, time.Time) (int64, *errs.Error)
+	CountFutureByRoomID(context.Context, uuid.UUID, time.Time) (int64, *errs.Error)
+	CountMoveConflicts(context.Context, uuid.UUID, uuid.UUID, time.Time) (int64, *errs.Error)
+	Delete(context.Context, string) *errs.Error
+	Find(context.Context) ([]*model.Reservation, *errs.Error)
+	FindByRoomID(context.Context, uuid.UUID) ([]*model.Reservation, *errs.Error)
+	FindDeleted(context.Context) ([]*model.Reservation, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Reservation, *errs.Error)
+	GetDeletedByID(context.Context, uuid.UUID) (*model.Reservation, *errs.Error)
+	HasOverlap(context.Context, uuid.UUID, time.Time, time.Time, uuid.UUID) (bool, *errs.Error)
+	MoveFuture(context.Context, uuid.UUID, uuid.UUID, time.Time) (int64, *errs.Error)
+	PurgeDeleted(context.Context, time.Time) (int64, *errs.Error)
+	Restore(context.Context, uuid.UUID) *errs.Error
+	Update(context.Context, *model.Reservation) *errs.Error
+}
+
+type reservation struct {
+	store *Store
+}
+
+func NewReservation(store *Store) ReservationRepo {
+	return &reservation{
+		store: store,
+	}
+}
+
+func (r *reservation) CreateTableReservations(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return "Memory reservations ready to go"
+}
+
+func (r *reservation) Add(ctx context.Context, reservation *model.Reservation) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	return r.store.write(ctx, func() *errs.Error {
+		if _, exist := r.store.reservations[reservation.Id]; exist {
+			logger.FromContext(ctx).Errorf("reservation %s already exists", reservation.Id)
+			return errs.NewError("Failed to create reservation", 500, "Internal Server Error", []interface{}{})
+		}
+		if !r.store.roomIn(reservation.RoomID, property) {
+			logger.FromContext(ctx).Tracef("room %s not found", reservation.RoomID)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+
+		reservation.PropertyID = property
+		reservation.Version = 1
+		r.store.reservations[reservation.Id] = *copyReservation(*reservation)
+
+		return nil
+	})
+}
+
+func (r *reservation) AnonymizeDeleted(ctx context.Context, before time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	var count int64
+	err := r.store.write(ctx, func() *errs.Error {
+		now := time.Now()
+		for id, stored := range r.store.reservations {
+			if stored.PropertyID != property || !stored.Deleted || !stored.DeletedAt.Before(before) || stored.UserId == uuid.Nil {
+				continue
+			}
+			stored.UserId = uuid.Nil
+			stored.Updated = now
+			stored.Version++
+			r.store.reservations[id] = stored
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *reservation) CountFutureByRoomID(ctx context.Context, roomID uuid.UUID, since time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	var count int64
+	err := r.store.read(ctx, func() *errs.Error {
+		for _, stored := range r.store.reservations {
+			if stored.RoomID == roomID && stored.PropertyID == property && !stored.Deleted && stored.EndDate.After(since) {
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *reservation) CountMoveConflicts(ctx context.Context, from uuid.UUID, to uuid.UUID, since time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	var count int64
+	err := r.store.read(ctx, func() *errs.Error {
+		for _, src := range r.store.reservations {
+			if src.RoomID != from || src.PropertyID != property || src.Deleted || !src.EndDate.After(since) {
+				continue
+			}
+			for _, dst := range r.store.reservations {
+				if dst.RoomID == to && dst.PropertyID == property && !dst.Deleted && src.StartDate.Before(dst.EndDate) && src.EndDate.After(dst.StartDate) {
+					count++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *reservation) Delete(ctx context.Context, id string) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	return r.store.write(ctx, func() *errs.Error {
+		reservationID, err := uuid.Parse(id)
+		if err != nil {
+			return errs.NewError("Reservation not found", 404, "Not Found", nil)
+		}
+
+		stored, exist := r.store.reservations[reservationID]
+		if !exist || stored.PropertyID != property || stored.Deleted {
+			return errs.NewError("Reservation not found", 404, "Not Found", nil)
+		}
+
+		now := time.Now()
+		stored.Deleted = true
+		stored.DeletedAt = &now
+		stored.Updated = now
+		stored.Version++
+		r.store.reservations[reservationID] = stored
+
+		return nil
+	})
+}
+
+func (r *reservation) Find(ctx context.Context) ([]*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	reservations, err := r.list(ctx, func(stored model.Reservation) bool {
+		return stored.PropertyID == property && !stored.Deleted
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortByUpdated(reservations)
+
+	return reservations, nil
+}
+
+func (r *reservation) FindByRoomID(ctx context.Context, id uuid.UUID) ([]*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	reservations, err := r.list(ctx, func(stored model.Reservation) bool {
+		return stored.PropertyID == property && !stored.Deleted && stored.RoomID == id
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortByUpdated(reservations)
+
+	return reservations, nil
+}
+
+func (r *reservation) FindDeleted(ctx context.Context) ([]*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	reservations, err := r.list(ctx, func(stored model.Reservation) bool {
+		return stored.PropertyID == property && stored.Deleted
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(reservations, func(i, j int) bool {
+		return reservations[i].DeletedAt.After(*reservations[j].DeletedAt)
+	})
+
+	return reservations, nil
+}
+
+func (r *reservation) GetByID(ctx context.Context, id uuid.UUID) (*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	return r.get(ctx, id, property, false)
+}
+
+func (r *reservation) GetDeletedByID(ctx context.Context, id uuid.UUID) (*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	return r.get(ctx, id, property, true)
+}
+
+func (r *reservation) HasOverlap(ctx context.Context, roomID uuid.UUID, start time.Time, end time.Time, exclude uuid.UUID) (bool, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return false, e
+	}
+
+	var overlap bool
+	err := r.store.read(ctx, func() *errs.Error {
+		overlap = r.store.roomIn(roomID, property) && r.store.overlaps(roomID, start, end, exclude)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return overlap, nil
+}
+
+func (r *reservation) MoveFuture(ctx context.Context, from uuid.UUID, to uuid.UUID, since time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	var moved int64
+	err := r.store.write(ctx, func() *errs.Error {
+		if !r.store.roomIn(to, property) {
+			logger.FromContext(ctx).Tracef("room %s not found", to)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+
+		for id, stored := range r.store.reservations {
+			if stored.RoomID != from || stored.PropertyID != property || stored.Deleted || !stored.EndDate.After(since) {
+				continue
+			}
+			stored.RoomID = to
+			stored.Updated = since
+			stored.Version++
+			r.store.reservations[id] = stored
+			moved++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
+
+func (r *reservation) PurgeDeleted(ctx context.Context, before time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	var count int64
+	err := r.store.write(ctx, func() *errs.Error {
+		for id, stored := range r.store.reservations {
+			if stored.PropertyID == property && stored.Deleted && stored.DeletedAt.Before(before) {
+				delete(r.store.reservations, id)
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *reservation) Restore(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	return r.store.write(ctx, func() *errs.Error {
+		stored, exist := r.store.reservations[id]
+		if !exist || stored.PropertyID != property || !stored.Deleted {
+			return errs.NewError("Deleted reservation not found", 404, "Not Found", nil)
+		}
+
+		stored.Deleted = false
+		stored.DeletedAt = nil
+		stored.Updated = time.Now()
+		stored.Version++
+		r.store.reservations[id] = stored
+
+		return nil
+	})
+}
+
+func (r *reservation) Update(ctx context.Context, reservation *model.Reservation) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	return r.store.write(ctx, func() *errs.Error {
+		stored, exist := r.store.reservations[reservation.Id]
+		if !exist || stored.PropertyID != property || stored.Deleted {
+			logger.FromContext(ctx).Tracef("reservation %s not found", reservation.Id)
+			return errs.NewError("Reservation not found", 404, "Not Found", nil)
+		}
+		if !r.store.roomIn(reservation.RoomID, property) {
+			logger.FromContext(ctx).Tracef("room %s not found", reservation.RoomID)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+		if reservation.Version != 0 && reservation.Version != stored.Version {
+			logger.FromContext(ctx).Tracef("reservation %s version %d is stale", reservation.Id, reservation.Version)
+			return errs.NewError("Reservation was modified by another request", 412, "Precondition Failed", nil)
+		}
+
+		updated := time.Now()
+		stored.StartDate = reservation.StartDate
+		stored.EndDate = reservation.EndDate
+		stored.RoomID = reservation.RoomID
+		stored.Occupancy = reservation.Occupancy
+		stored.Updated = updated
+		stored.Version++
+		r.store.reservations[reservation.Id] = stored
+
+		reservation.PropertyID = property
+		reservation.Status = stored.Status
+		reservation.Version = stored.Version
+		reservation.Updated = updated
+
+		return nil
+	})
+}
+
+func (r *reservation) get(ctx context.Context, id uuid.UUID, property uuid.UUID, deleted bool) (*model.Reservation, *errs.Error) {
+	var reservation *model.Reservation
+	err := r.store.read(ctx, func() *errs.Error {
+		stored, exist := r.store.reservations[id]
+		if !exist || stored.PropertyID != property || stored.Deleted != deleted {
+			logger.FromContext(ctx).Tracef("reservation %s not found", id)
+			return errs.NewError("Reservation not found", 404, "Not Found", nil)
+		}
+		reservation = copyReservation(stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservation, nil
+}
+
+func (r *reservation) list(ctx context.Context, keep func(model.Reservation) bool) ([]*model.Reservation, *errs.Error) {
+	reservations := []*model.Reservation{}
+	err := r.store.read(ctx, func() *errs.Error {
+		for _, stored := range r.store.reservations {
+			if keep(stored) {
+				reservations = append(reservations, copyReservation(stored))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reservations, nil
+}
+
+func copyReservation(stored model.Reservation) *model.Reservation {
+	reservation := stored
+	if stored.DeletedAt != nil {
+		deletedAt := *stored.DeletedAt
+		reservation.DeletedAt = &deletedAt
+	}
+
+	return &reservation
+}
+
+func sortByUpdated(reservations []*model.Reservation) {
+	sort.SliceStable(reservations, func(i, j int) bool {
+		return reservations[i].Updated.After(reservations[j].Updated)
+	})
+}
@@ -0,0 +1,294 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+type RoomRepo interface {
+	CreateTableRooms(context.Context) string
+
+	Add(context.Context, *model.Room) *errs.Error
+	Archive(context.Context, uuid.UUID) *errs.Error
+	Find(context.Context) ([]*model.Room, *errs.Error)
+	FindAvailable(context.Context, model.RoomSearch) ([]*model.Room, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Room, *errs.Error)
+	CheckIfAvailableById(context.Context, uuid.UUID, time.Time, time.Time) (bool, *errs.Error)
+	LockActive(context.Context, uuid.UUID) *errs.Error
+	Update(context.Context, *model.Room) *errs.Error
+}
+
+type room struct {
+	store *Store
+}
+
+func NewRoom(store *Store) RoomRepo {
+	return &room{
+		store: store,
+	}
+}
+
+func (r *room) CreateTableRooms(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return "Memory rooms ready to go"
+}
+
+func (r *room) Add(ctx context.Context, room *model.Room) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	return r.store.write(ctx, func() *errs.Error {
+		if _, exist := r.store.rooms[room.Id]; exist {
+			logger.FromContext(ctx).Errorf("room %s already exists", room.Id)
+			return errs.NewError("Failed to create room", 500, "Internal Server Error", []interface{}{})
+		}
+
+		created := time.Now()
+		room.PropertyID = property
+		room.Version = 1
+		r.store.rooms[room.Id] = model.Room{
+			Id:           room.Id,
+			PropertyID:   property,
+			Name:         room.Name,
+			MaxAdults:    room.MaxAdults,
+			MaxChildren:  room.MaxChildren,
+			MaxOccupancy: room.MaxOccupancy,
+			Created:      created,
+			Updated:      created,
+			Version:      room.Version,
+		}
+
+		return nil
+	})
+}
+
+func (r *room) Archive(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	return r.store.write(ctx, func() *errs.Error {
+		stored, exist := r.store.rooms[id]
+		if !exist || stored.PropertyID != property || stored.Archived != nil {
+			logger.FromContext(ctx).Tracef("room %s not found", id)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+
+		now := time.Now()
+		stored.Archived = &now
+		stored.Updated = now
+		stored.Version++
+		r.store.rooms[id] = stored
+
+		return nil
+	})
+}
+
+func (r *room) Find(ctx context.Context) ([]*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	rooms := []*model.Room{}
+	err := r.store.read(ctx, func() *errs.Error {
+		for _, stored := range r.store.rooms {
+			if stored.PropertyID == property && stored.Archived == nil {
+				rooms = append(rooms, copyRoom(stored))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortRooms(rooms)
+
+	return rooms, nil
+}
+
+func (r *room) FindAvailable(ctx context.Context, search model.RoomSearch) ([]*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	rooms := []*model.Room{}
+	matches := map[uuid.UUID]int{}
+	err := r.store.read(ctx, func() *errs.Error {
+		for _, stored := range r.store.rooms {
+			if stored.PropertyID != property || stored.Archived != nil || len(stored.Accommodates(search.Occupancy)) > 0 {
+				continue
+			}
+			if r.store.overlaps(stored.Id, search.Start, search.End, uuid.Nil) || !r.hasAll(stored.Id, search.Required) {
+				continue
+			}
+			for _, filter := range search.Preferred {
+				if r.store.hasAmenity(stored.Id, filter) {
+					matches[stored.Id]++
+				}
+			}
+			rooms = append(rooms, copyRoom(stored))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortRooms(rooms)
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return matches[rooms[i].Id] > matches[rooms[j].Id]
+	})
+
+	return rooms, nil
+}
+
+func (r *room) GetByID(ctx context.Context, id uuid.UUID) (*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	var room *model.Room
+	err := r.store.read(ctx, func() *errs.Error {
+		stored, exist := r.store.rooms[id]
+		if !exist || stored.PropertyID != property {
+			logger.FromContext(ctx).Tracef("room %s not found", id)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+		room = copyRoom(stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (r *room) CheckIfAvailableById(ctx context.Context, id uuid.UUID, start time.Time, end time.Time) (bool, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return false, e
+	}
+
+	var available bool
+	err := r.store.read(ctx, func() *errs.Error {
+		stored, exist := r.store.rooms[id]
+		available = exist && stored.PropertyID == property && stored.Archived == nil && !r.store.overlaps(id, start, end, uuid.Nil)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return available, nil
+}
+
+// LockActive only checks that the room is active. Writes made through a unit
+// of work already hold the store's write lock.
+func (r *room) LockActive(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	return r.store.read(ctx, func() *errs.Error {
+		stored, exist := r.store.rooms[id]
+		if !exist || stored.PropertyID != property || stored.Archived != nil {
+			logger.FromContext(ctx).Tracef("room %s not found", id)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+		return nil
+	})
+}
+
+func (r *room) Update(ctx context.Context, room *model.Room) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	return r.store.write(ctx, func() *errs.Error {
+		stored, exist := r.store.rooms[room.Id]
+		if !exist || stored.PropertyID != property {
+			logger.FromContext(ctx).Tracef("room %s not found", room.Id)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+		if room.Version != 0 && room.Version != stored.Version {
+			logger.FromContext(ctx).Tracef("room %s version %d is stale", room.Id, room.Version)
+			return errs.NewError("room was modified by another request", 412, "Precondition Failed", nil)
+		}
+
+		updated := time.Now()
+		stored.Name = room.Name
+		stored.MaxAdults = room.MaxAdults
+		stored.MaxChildren = room.MaxChildren
+		stored.MaxOccupancy = room.MaxOccupancy
+		stored.Updated = updated
+		stored.Version++
+		r.store.rooms[room.Id] = stored
+
+		room.PropertyID = property
+		room.Version = stored.Version
+		room.Updated = updated
+
+		return nil
+	})
+}
+
+func (r *room) hasAll(roomID uuid.UUID, filters []model.AmenityFilter) bool {
+	for _, filter := range filters {
+		if !r.store.hasAmenity(roomID, filter) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func copyRoom(stored model.Room) *model.Room {
+	room := stored
+	if stored.Archived != nil {
+		archived := *stored.Archived
+		room.Archived = &archived
+	}
+
+	return &room
+}
+
+func sortRooms(rooms []*model.Room) {
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return rooms[i].Name < rooms[j].Name
+	})
+}
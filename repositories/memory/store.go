@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+)
+
+// Store holds the data of all memory repositories. Repositories created from
+// the same Store see each other's writes, the same way tables in one database
+// do.
+type Store struct {
+	mu sync.RWMutex
+
+	properties      map[uuid.UUID]model.Property
+	rooms           map[uuid.UUID]model.Room
+	reservations    map[uuid.UUID]model.Reservation
+	idempotencyKeys map[string]model.IdempotencyKey
+	amenities       map[uuid.UUID]model.Amenity
+	roomAmenities   map[uuid.UUID][]model.RoomAmenity
+}
+
+type lockKey struct{}
+
+func NewStore() *Store {
+	now := time.Now()
+
+	return &Store{
+		properties: map[uuid.UUID]model.Property{
+			model.DEFAULT_PROPERTY: {Id: model.DEFAULT_PROPERTY, Name: "Default", Created: now, Updated: now, Version: 1},
+		},
+		rooms:           map[uuid.UUID]model.Room{},
+		reservations:    map[uuid.UUID]model.Reservation{},
+		idempotencyKeys: map[string]model.IdempotencyKey{},
+		amenities:       map[uuid.UUID]model.Amenity{},
+		roomAmenities:   map[uuid.UUID][]model.RoomAmenity{},
+	}
+}
+
+// read runs fn under the read lock, unless ctx belongs to a unit of work that
+// already holds the write lock.
+func (s *Store) read(ctx context.Context, fn func() *errs.Error) *errs.Error {
+	if err := errs.FromContext(ctx); err != nil {
+		return err
+	}
+
+	if ctx.Value(lockKey{}) == s {
+		return fn()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn()
+}
+
+// write runs fn under the write lock, unless ctx belongs to a unit of work
+// that already holds it.
+func (s *Store) write(ctx context.Context, fn func() *errs.Error) *errs.Error {
+	if err := errs.FromContext(ctx); err != nil {
+		return err
+	}
+
+	if ctx.Value(lockKey{}) == s {
+		return fn()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fn()
+}
+
+type snapshot struct {
+	properties      map[uuid.UUID]model.Property
+	rooms           map[uuid.UUID]model.Room
+	reservations    map[uuid.UUID]model.Reservation
+	idempotencyKeys map[string]model.IdempotencyKey
+	amenities       map[uuid.UUID]model.Amenity
+	roomAmenities   map[uuid.UUID][]model.RoomAmenity
+}
+
+func (s *Store) snapshot() snapshot {
+	snap := snapshot{
+		properties:      make(map[uuid.UUID]model.Property, len(s.properties)),
+		rooms:           make(map[uuid.UUID]model.Room, len(s.rooms)),
+		reservations:    make(map[uuid.UUID]model.Reservation, len(s.reservations)),
+		idempotencyKeys: make(map[string]model.IdempotencyKey, len(s.idempotencyKeys)),
+		amenities:       make(map[uuid.UUID]model.Amenity, len(s.amenities)),
+		roomAmenities:   make(map[uuid.UUID][]model.RoomAmenity, len(s.roomAmenities)),
+	}
+	for id, property := range s.properties {
+		snap.properties[id] = property
+	}
+	for id, room := range s.rooms {
+		snap.rooms[id] = room
+	}
+	for id, reservation := range s.reservations {
+		snap.reservations[id] = reservation
+	}
+	for key, record := range s.idempotencyKeys {
+		snap.idempotencyKeys[key] = record
+	}
+	for id, amenity := range s.amenities {
+		snap.amenities[id] = amenity
+	}
+	for id, amenities := range s.roomAmenities {
+		snap.roomAmenities[id] = amenities
+	}
+
+	return snap
+}
+
+func (s *Store) restore(snap snapshot) {
+	s.properties = snap.properties
+	s.rooms = snap.rooms
+	s.reservations = snap.reservations
+	s.idempotencyKeys = snap.idempotencyKeys
+	s.amenities = snap.amenities
+	s.roomAmenities = snap.roomAmenities
+}
+
+// roomIn reports whether the room belongs to the property, archived rooms
+// included.
+func (s *Store) roomIn(roomID uuid.UUID, property uuid.UUID) bool {
+	room, exist := s.rooms[roomID]
+
+	return exist && room.PropertyID == property
+}
+
+// touchRoom bumps the version of a room whose amenities changed, the same
+// as ROOM_AMENITIES_TOUCH_ROOM does.
+func (s *Store) touchRoom(roomID uuid.UUID, updated time.Time) {
+	room, exist := s.rooms[roomID]
+	if !exist {
+		return
+	}
+
+	room.Updated = updated
+	room.Version++
+	s.rooms[roomID] = room
+}
+
+// overlaps mirrors the overlap condition used by the Postgres queries:
+// start < end_date AND end > start_date, ignoring deleted reservations.
+func (s *Store) overlaps(roomID uuid.UUID, start time.Time, end time.Time, exclude uuid.UUID) bool {
+	for _, reservation := range s.reservations {
+		if reservation.RoomID != roomID || reservation.Deleted || reservation.Id == exclude {
+			continue
+		}
+		if start.Before(reservation.EndDate) && end.After(reservation.StartDate) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasAmenity reports whether any amenity of the room passes the filter.
+func (s *Store) hasAmenity(roomID uuid.UUID, filter model.AmenityFilter) bool {
+	for _, ra := range s.roomAmenities[roomID] {
+		if filter.Matches(ra) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,54 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+)
+
+type UnitOfWork interface {
+	Do(context.Context, func(context.Context) *errs.Error) *errs.Error
+}
+
+type unitOfWork struct {
+	store *Store
+}
+
+func NewUnitOfWork(store *Store) UnitOfWork {
+	return &unitOfWork{
+		store: store,
+	}
+}
+
+// Do holds the store's write lock while fn runs and puts the data back as it
+// was when fn fails or panics.
+func (u *unitOfWork) Do(ctx context.Context, fn func(context.Context) *errs.Error) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	if ctx.Value(lockKey{}) == u.store {
+		return fn(ctx)
+	}
+
+	if err := errs.FromContext(ctx); err != nil {
+		return err
+	}
+
+	u.store.mu.Lock()
+	defer u.store.mu.Unlock()
+
+	snap := u.store.snapshot()
+	committed := false
+	defer func() {
+		if !committed {
+			u.store.restore(snap)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, lockKey{}, u.store)); err != nil {
+		return err
+	}
+	committed = true
+
+	return nil
+}
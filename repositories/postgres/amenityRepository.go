@@ -0,0 +1,350 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+const (
+	CREATE_AMENITIES_TABLES = `
+	CREATE TABLE IF NOT EXISTS public.amenities (
+		id uuid NOT NULL,
+		key varchar(64) NOT NULL,
+		name varchar(255) NOT NULL,
+		type varchar(16) NOT NULL,
+		enum_values jsonb NOT NULL DEFAULT '[]',
+		created timestamptz NOT NULL,
+		updated timestamptz NOT NULL,
+		version integer NOT NULL DEFAULT 1,
+		CONSTRAINT amenities_pkey PRIMARY KEY (id),
+		CONSTRAINT amenities_key_key UNIQUE (key)
+	);
+	CREATE TABLE IF NOT EXISTS public.room_amenities (
+		room_id uuid NOT NULL,
+		amenity_id uuid NOT NULL,
+		value text NOT NULL,
+		number double precision,
+		CONSTRAINT room_amenities_pkey PRIMARY KEY (room_id, amenity_id)
+	);
+	CREATE INDEX IF NOT EXISTS room_amenities_value ON public.room_amenities (amenity_id, value);
+	CREATE INDEX IF NOT EXISTS room_amenities_number ON public.room_amenities (amenity_id, number) WHERE number IS NOT NULL;
+	`
+
+	AMENITY_CREATE    = "INSERT INTO amenities (id, key, name, type, enum_values, created, updated, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (key) DO NOTHING"
+	AMENITY_DELETE    = "DELETE FROM amenities WHERE id = $1"
+	AMENITIES_FIND    = "SELECT id, key, name, type, enum_values, created, updated, version FROM amenities ORDER BY key ASC"
+	AMENITY_GET_BY_ID = "SELECT id, key, name, type, enum_values, created, updated, version FROM amenities WHERE id = $1"
+	AMENITY_UPDATE    = "UPDATE amenities SET name=$1, enum_values=$2, updated=$3, version=version+1 WHERE id=$4 AND ($5 = 0 OR version=$5) RETURNING version"
+
+	ROOM_AMENITIES_DELETE_BY_AMENITY_ID = "DELETE FROM room_amenities WHERE amenity_id = $1"
+	ROOM_AMENITIES_TOUCH_ROOM           = "UPDATE rooms SET updated = $2, version = version + 1 WHERE id = $1"
+	ROOM_AMENITIES_TOUCH_ROOMS          = "UPDATE rooms SET updated = $2, version = version + 1 WHERE id IN (SELECT room_id FROM room_amenities WHERE amenity_id = $1)"
+	ROOM_AMENITIES_DELETE_BY_ROOM_ID    = "DELETE FROM room_amenities WHERE room_id = $1"
+	ROOM_AMENITIES_ROOM_IN_PROPERTY     = "SELECT EXISTS (SELECT 1 FROM rooms WHERE id = $1 AND property_id = $2)"
+	ROOM_AMENITY_ADD                    = "INSERT INTO room_amenities (room_id, amenity_id, value, number) VALUES ($1, $2, $3, $4)"
+	ROOM_AMENITIES_FIND_BY_ROOM_IDS     = `
+	select
+			ra.room_id, a.id, a.key, a.type, ra.value
+		from
+			room_amenities ra
+		join amenities a on a.id = ra.amenity_id
+		join rooms r on r.id = ra.room_id and r.property_id = $2
+		where ra.room_id = any($1::uuid[])
+		order by a.key asc;
+	`
+)
+
+type AmenityRepo interface {
+	CreateTableAmenities(context.Context) string
+
+	Add(context.Context, *model.Amenity) *errs.Error
+	Delete(context.Context, uuid.UUID) *errs.Error
+	Find(context.Context) ([]*model.Amenity, *errs.Error)
+	FindByRoomIDs(context.Context, []uuid.UUID) (map[uuid.UUID][]model.RoomAmenity, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Amenity, *errs.Error)
+	SetRoomAmenities(context.Context, uuid.UUID, []model.RoomAmenity) *errs.Error
+	Update(context.Context, *model.Amenity) *errs.Error
+}
+
+type amenity struct {
+	db sqlclient.SqlClient
+}
+
+func NewAmenity(db sqlclient.SqlClient) AmenityRepo {
+	return &amenity{
+		db: db,
+	}
+}
+
+func (r *amenity) CreateTableAmenities(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	if _, err := r.db.ExecContext(ctx, CREATE_AMENITIES_TABLES); err != nil {
+		log.Panicf("CREATE_AMENITIES_TABLES failed: %v", err)
+	}
+
+	return "Tables amenities and room_amenities ready to go"
+}
+
+func (r *amenity) Add(ctx context.Context, amenity *model.Amenity) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "amenity.add")
+	defer done()
+
+	values, err := json.Marshal(amenity.Values)
+	if err != nil {
+		logger.FromContext(ctx).Error("AMENITY_CREATE json.Marshal failed", err)
+		return dbError(ctx, "Failed to create amenity")
+	}
+
+	amenity.Version = 1
+	res, err := r.db.ExecContext(ctx, AMENITY_CREATE, amenity.Id, amenity.Key, amenity.Name, amenity.Type, string(values), amenity.Created, amenity.Updated, amenity.Version)
+	if err != nil {
+		logger.FromContext(ctx).Error("AMENITY_CREATE failed", err)
+		return dbError(ctx, "Failed to create amenity")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("AMENITY_CREATE RowsAffected failed", err)
+		return dbError(ctx, "Failed to create amenity")
+	}
+	if affected == 0 {
+		logger.FromContext(ctx).Tracef("AMENITY_CREATE key %s already exists", amenity.Key)
+		return errs.NewError("Amenity key already exists", 409, "Conflict", nil)
+	}
+
+	return nil
+}
+
+// Delete removes the amenity from the catalog and from every room.
+func (r *amenity) Delete(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "amenity.delete")
+	defer done()
+
+	if _, err := r.db.ExecContext(ctx, ROOM_AMENITIES_TOUCH_ROOMS, id, time.Now()); err != nil {
+		logger.FromContext(ctx).Error("ROOM_AMENITIES_TOUCH_ROOMS failed", err)
+		return dbError(ctx, "Failed to delete amenity")
+	}
+
+	if _, err := r.db.ExecContext(ctx, ROOM_AMENITIES_DELETE_BY_AMENITY_ID, id); err != nil {
+		logger.FromContext(ctx).Error("ROOM_AMENITIES_DELETE_BY_AMENITY_ID failed", err)
+		return dbError(ctx, "Failed to delete amenity")
+	}
+
+	res, err := r.db.ExecContext(ctx, AMENITY_DELETE, id)
+	if err != nil {
+		logger.FromContext(ctx).Error("AMENITY_DELETE failed", err)
+		return dbError(ctx, "Failed to delete amenity")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("AMENITY_DELETE RowsAffected failed", err)
+		return dbError(ctx, "Failed to delete amenity")
+	}
+	if affected == 0 {
+		logger.FromContext(ctx).Tracef("AMENITY_DELETE amenity %s not found", id)
+		return errs.NewError("amenity not found", 404, "Not Found", nil)
+	}
+
+	return nil
+}
+
+func (r *amenity) Find(ctx context.Context) ([]*model.Amenity, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "amenity.find")
+	defer done()
+
+	rows, err := r.db.QueryContext(ctx, AMENITIES_FIND)
+	if err != nil {
+		logger.FromContext(ctx).Error("AMENITIES_FIND failed", err)
+		return nil, dbError(ctx, "Failed to find amenities")
+	}
+	defer rows.Close()
+
+	amenities := []*model.Amenity{}
+	for rows.Next() {
+		amenity := &model.Amenity{}
+
+		if err := scanAmenity(rows, amenity); err != nil {
+			logger.FromContext(ctx).Error("AMENITIES_FIND rows.Scan failed", err)
+			return nil, dbError(ctx, "Failed to scan amenities")
+		}
+
+		amenities = append(amenities, amenity)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.FromContext(ctx).Error("AMENITIES_FIND rows.Err not nil", err)
+		return nil, dbError(ctx, "Failed to find amenities")
+	}
+
+	return amenities, nil
+}
+
+// FindByRoomIDs returns the amenities of the rooms ordered by key, rooms
+// without amenities are left out.
+func (r *amenity) FindByRoomIDs(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]model.RoomAmenity, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	amenities := map[uuid.UUID][]model.RoomAmenity{}
+	if len(ids) == 0 {
+		return amenities, nil
+	}
+
+	ctx, done := query(ctx, "amenity.find_by_room_ids")
+	defer done()
+
+	list := make([]string, 0, len(ids))
+	for _, id := range ids {
+		list = append(list, id.String())
+	}
+
+	rows, err := r.db.QueryContext(ctx, ROOM_AMENITIES_FIND_BY_ROOM_IDS, pq.Array(list), property)
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOM_AMENITIES_FIND_BY_ROOM_IDS failed", err)
+		return nil, dbError(ctx, "Failed to find room amenities")
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var roomID uuid.UUID
+		var ra model.RoomAmenity
+
+		if err := rows.Scan(&roomID, &ra.AmenityID, &ra.Key, &ra.Type, &ra.Value); err != nil {
+			logger.FromContext(ctx).Error("ROOM_AMENITIES_FIND_BY_ROOM_IDS rows.Scan failed", err)
+			return nil, dbError(ctx, "Failed to scan room amenities")
+		}
+
+		amenities[roomID] = append(amenities[roomID], ra)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.FromContext(ctx).Error("ROOM_AMENITIES_FIND_BY_ROOM_IDS rows.Err not nil", err)
+		return nil, dbError(ctx, "Failed to find room amenities")
+	}
+
+	return amenities, nil
+}
+
+func (r *amenity) GetByID(ctx context.Context, id uuid.UUID) (*model.Amenity, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "amenity.get_by_id")
+	defer done()
+
+	amenity := &model.Amenity{}
+	if err := scanAmenity(r.db.QueryRowContext(ctx, AMENITY_GET_BY_ID, id), amenity); err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("AMENITY_GET_BY_ID amenity %s not found", id)
+			return nil, errs.NewError("amenity not found", 404, "Not Found", nil)
+		}
+		logger.FromContext(ctx).Errorf("AMENITY_GET_BY_ID failed: %v", err)
+		return nil, dbError(ctx, "Failed to get amenity")
+	}
+
+	return amenity, nil
+}
+
+// SetRoomAmenities replaces the amenities of a room.
+func (r *amenity) SetRoomAmenities(ctx context.Context, roomID uuid.UUID, amenities []model.RoomAmenity) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "amenity.set_room_amenities")
+	defer done()
+
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, ROOM_AMENITIES_ROOM_IN_PROPERTY, roomID, property).Scan(&exists); err != nil {
+		logger.FromContext(ctx).Error("ROOM_AMENITIES_ROOM_IN_PROPERTY failed", err)
+		return dbError(ctx, "Failed to set room amenities")
+	}
+	if !exists {
+		logger.FromContext(ctx).Tracef("ROOM_AMENITIES_ROOM_IN_PROPERTY room %s not found", roomID)
+		return errs.NewError("room not found", 404, "Not Found", nil)
+	}
+
+	if _, err := r.db.ExecContext(ctx, ROOM_AMENITIES_TOUCH_ROOM, roomID, time.Now()); err != nil {
+		logger.FromContext(ctx).Error("ROOM_AMENITIES_TOUCH_ROOM failed", err)
+		return dbError(ctx, "Failed to set room amenities")
+	}
+
+	if _, err := r.db.ExecContext(ctx, ROOM_AMENITIES_DELETE_BY_ROOM_ID, roomID); err != nil {
+		logger.FromContext(ctx).Error("ROOM_AMENITIES_DELETE_BY_ROOM_ID failed", err)
+		return dbError(ctx, "Failed to set room amenities")
+	}
+
+	for _, ra := range amenities {
+		if _, err := r.db.ExecContext(ctx, ROOM_AMENITY_ADD, roomID, ra.AmenityID, ra.Value, ra.Number()); err != nil {
+			logger.FromContext(ctx).Error("ROOM_AMENITY_ADD failed", err)
+			return dbError(ctx, "Failed to set room amenities")
+		}
+	}
+
+	return nil
+}
+
+func (r *amenity) Update(ctx context.Context, amenity *model.Amenity) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "amenity.update")
+	defer done()
+
+	values, err := json.Marshal(amenity.Values)
+	if err != nil {
+		logger.FromContext(ctx).Error("AMENITY_UPDATE json.Marshal failed", err)
+		return dbError(ctx, "Failed to update amenity")
+	}
+
+	updated := time.Now()
+	err = r.db.QueryRowContext(ctx, AMENITY_UPDATE, amenity.Name, string(values), updated, amenity.Id, amenity.Version).Scan(&amenity.Version)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			if _, e := r.GetByID(ctx, amenity.Id); e != nil {
+				return e
+			}
+			logger.FromContext(ctx).Tracef("AMENITY_UPDATE amenity %s version %d is stale", amenity.Id, amenity.Version)
+			return errs.NewError("amenity was modified by another request", 412, "Precondition Failed", nil)
+		}
+		logger.FromContext(ctx).Error("AMENITY_UPDATE failed", err)
+		return dbError(ctx, "Failed to update amenity")
+	}
+	amenity.Updated = updated
+
+	return nil
+}
+
+func scanAmenity(row scanner, amenity *model.Amenity) error {
+	var values []byte
+	if err := row.Scan(&amenity.Id, &amenity.Key, &amenity.Name, &amenity.Type, &values, &amenity.Created, &amenity.Updated, &amenity.Version); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(values, &amenity.Values)
+}
@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+)
+
+func TestAmenitySetRoomAmenitiesBumpsRoomVersion(t *testing.T) {
+	ctx, db := newMock(t)
+	roomID, amenityID := uuid.New(), uuid.New()
+	sqlclient.AddMock(sqlclient.Mock{Query: ROOM_AMENITIES_ROOM_IN_PROPERTY, Args: []interface{}{roomID, testProperty}, Rows: [][]interface{}{{true}}})
+	sqlclient.AddMock(sqlclient.Mock{Query: ROOM_AMENITIES_TOUCH_ROOM, Args: []interface{}{roomID, sqlclient.AnyArg}, RowsAffected: 1})
+	sqlclient.AddMock(sqlclient.Mock{Query: ROOM_AMENITIES_DELETE_BY_ROOM_ID, Args: []interface{}{roomID}})
+	sqlclient.AddMock(sqlclient.Mock{Query: ROOM_AMENITY_ADD, Args: []interface{}{roomID, amenityID, "true", sqlclient.AnyArg}, RowsAffected: 1})
+
+	amenities := []model.RoomAmenity{{AmenityID: amenityID, Key: "balcony", Type: model.AMENITY_BOOLEAN, Value: "true"}}
+	if err := NewAmenity(db).SetRoomAmenities(ctx, roomID, amenities); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+}
+
+func TestAmenityDeleteBumpsRoomVersions(t *testing.T) {
+	ctx, db := newMock(t)
+	id := uuid.New()
+	sqlclient.AddMock(sqlclient.Mock{Query: ROOM_AMENITIES_TOUCH_ROOMS, Args: []interface{}{id, sqlclient.AnyArg}, RowsAffected: 2})
+	sqlclient.AddMock(sqlclient.Mock{Query: ROOM_AMENITIES_DELETE_BY_AMENITY_ID, Args: []interface{}{id}, RowsAffected: 2})
+	sqlclient.AddMock(sqlclient.Mock{Query: AMENITY_DELETE, Args: []interface{}{id}, RowsAffected: 1})
+
+	if err := NewAmenity(db).Delete(ctx, id); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package postgres_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/demkowo/booking/repositories/contract"
+	"github.com/demkowo/booking/repositories/postgres"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+)
+
+// TestContract runs against the database in CONTRACT_POSTGRES_DSN and is
+// skipped without one.
+func TestContract(t *testing.T) {
+	dsn := os.Getenv("CONTRACT_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("CONTRACT_POSTGRES_DSN is not set")
+	}
+	ctx := context.Background()
+
+	db, err := sqlclient.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	backend := contract.Backend{
+		Properties:   postgres.NewProperty(db),
+		Rooms:        postgres.NewRoom(db),
+		Reservations: postgres.NewReservation(db),
+		Amenities:    postgres.NewAmenity(db),
+		UnitOfWork:   postgres.NewUnitOfWork(db),
+	}
+	backend.Properties.CreateTableProperties(ctx)
+	backend.Rooms.CreateTableRooms(ctx)
+	backend.Reservations.CreateTableReservations(ctx)
+	backend.Amenities.CreateTableAmenities(ctx)
+
+	for _, result := range contract.Run(ctx, backend) {
+		t.Run(result.Name, func(t *testing.T) {
+			if result.Err != nil {
+				t.Error(result.Err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/deadline"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/metrics"
+	"github.com/demkowo/booking/utils/tracing"
+)
+
+type scanner interface {
+	Scan(...interface{}) error
+}
+
+// query bounds ctx by the timeout of the operation, and traces and records
+// its latency until the returned func is called.
+func query(ctx context.Context, operation string) (context.Context, func()) {
+	ctx, span := tracing.Start(ctx, operation, trace.WithAttributes(
+		semconv.DBSystemKey.String("postgres"),
+		semconv.DBOperationName(operation),
+	))
+	ctx, cancel := deadline.For(ctx, operation)
+	ctx, observe := metrics.StartQuery(ctx, "postgres", operation)
+
+	return ctx, func() {
+		observe()
+		cancel()
+		span.End()
+	}
+}
+
+// dbError reports a failed database call as 503/504 when the request was
+// cancelled or ran out of time, and as 500 otherwise.
+func dbError(ctx context.Context, message string) *errs.Error {
+	tracing.Fail(ctx, message)
+
+	if err := errs.FromContext(ctx); err != nil {
+		if err.Code == 504 {
+			metrics.QueryFailed(ctx, "timeout")
+		} else {
+			metrics.QueryFailed(ctx, "canceled")
+		}
+		return err
+	}
+
+	metrics.QueryFailed(ctx, "error")
+	return errs.NewError(message, 500, "Internal Server Error", []interface{}{})
+}
+
+// filters are passed to the queries as a JSON array, which marshals as null
+// when there are none.
+func filters(list []model.AmenityFilter) []model.AmenityFilter {
+	if list == nil {
+		return []model.AmenityFilter{}
+	}
+
+	return list
+}
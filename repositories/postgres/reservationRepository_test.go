@@ -0,0 +1,159 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+)
+
+func reservationRow(id uuid.UUID, roomID uuid.UUID) []interface{} {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []interface{}{id, testProperty, uuid.New(), start, start.AddDate(0, 0, 2), roomID, 3, 2, 0, start, start, false, nil, 1}
+}
+
+func TestReservationAdd(t *testing.T) {
+	ctx, db := newMock(t)
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	reservation := &model.Reservation{Id: uuid.New(), UserId: uuid.New(), RoomID: uuid.New(), StartDate: start, EndDate: start.AddDate(0, 0, 2)}
+	sqlclient.AddMock(sqlclient.Mock{Query: RESERVATION_ROOM_IN_PROPERTY, Args: []interface{}{reservation.RoomID, testProperty}, Rows: [][]interface{}{{true}}})
+	sqlclient.AddMock(sqlclient.Mock{
+		Query: RESERVATION_CREATE,
+		Args: []interface{}{
+			reservation.Id, testProperty, reservation.UserId, start, start.AddDate(0, 0, 2), reservation.RoomID,
+			0, 0, 0, sqlclient.AnyArg, sqlclient.AnyArg, false, 1,
+		},
+		RowsAffected: 1,
+	})
+
+	if err := NewReservation(db).Add(ctx, reservation); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if reservation.PropertyID != testProperty || reservation.Version != 1 {
+		t.Fatalf("unexpected reservation %+v", reservation)
+	}
+}
+
+func TestReservationAddToRoomOfAnotherProperty(t *testing.T) {
+	ctx, db := newMock(t)
+	roomID := uuid.New()
+	sqlclient.AddMock(sqlclient.Mock{Query: RESERVATION_ROOM_IN_PROPERTY, Args: []interface{}{roomID, testProperty}, Rows: [][]interface{}{{false}}})
+
+	err := NewReservation(db).Add(ctx, &model.Reservation{Id: uuid.New(), RoomID: roomID})
+	if err == nil || err.Code != 404 {
+		t.Fatalf("expected 404, got %+v", err)
+	}
+}
+
+func TestReservationFind(t *testing.T) {
+	tests := []struct {
+		name  string
+		mock  sqlclient.Mock
+		count int
+		code  int
+	}{
+		{name: "found", mock: sqlclient.Mock{Rows: [][]interface{}{reservationRow(uuid.New(), uuid.New()), reservationRow(uuid.New(), uuid.New())}}, count: 2},
+		{name: "empty", mock: sqlclient.Mock{}},
+		{name: "query", mock: sqlclient.Mock{Error: errors.New("connection reset")}, code: 500},
+		{name: "rows", mock: sqlclient.Mock{Rows: [][]interface{}{reservationRow(uuid.New(), uuid.New())}, RowsError: errors.New("connection reset")}, code: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, db := newMock(t)
+			tt.mock.Query = RESERVATION_FIND
+			tt.mock.Args = []interface{}{testProperty}
+			sqlclient.AddMock(tt.mock)
+
+			reservations, err := NewReservation(db).Find(ctx)
+			if tt.code != 0 {
+				if err == nil || err.Code != tt.code {
+					t.Fatalf("expected %d, got %+v", tt.code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %+v", err)
+			}
+			if len(reservations) != tt.count {
+				t.Fatalf("expected %d reservations, got %d", tt.count, len(reservations))
+			}
+			for _, reservation := range reservations {
+				if reservation.PropertyID != testProperty || reservation.Status != model.RESERVATION || reservation.Adults != 2 {
+					t.Fatalf("unexpected reservation %+v", reservation)
+				}
+			}
+		})
+	}
+}
+
+func TestReservationDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		code     int
+	}{
+		{name: "deleted", affected: 1},
+		{name: "missing", affected: 0, code: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, db := newMock(t)
+			id := uuid.New()
+			sqlclient.AddMock(sqlclient.Mock{Query: RESERVATION_DELETE, Args: []interface{}{sqlclient.AnyArg, id.String(), testProperty}, RowsAffected: tt.affected})
+
+			err := NewReservation(db).Delete(ctx, id.String())
+			if tt.code == 0 && err != nil || tt.code != 0 && (err == nil || err.Code != tt.code) {
+				t.Fatalf("expected %d, got %+v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestUnitOfWork(t *testing.T) {
+	failed := errs.NewError("room not found", 404, "Not Found", nil)
+	tests := []struct {
+		name  string
+		mocks []sqlclient.Mock
+		fn    *errs.Error
+		code  int
+	}{
+		{name: "committed", mocks: []sqlclient.Mock{{Query: RESERVATION_DELETE, RowsAffected: 1}}},
+		{name: "rolled back", mocks: []sqlclient.Mock{{Query: RESERVATION_DELETE, RowsAffected: 1}}, fn: failed, code: 404},
+		{name: "begin fails", mocks: []sqlclient.Mock{{Query: sqlclient.MOCK_BEGIN, Error: errors.New("too many connections")}}, code: 500},
+		{
+			name:  "commit fails",
+			mocks: []sqlclient.Mock{{Query: RESERVATION_DELETE, RowsAffected: 1}, {Query: sqlclient.MOCK_COMMIT, Error: errors.New("serialization failure")}},
+			code:  500,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, db := newMock(t)
+			for _, mock := range tt.mocks {
+				sqlclient.AddMock(mock)
+			}
+
+			err := NewUnitOfWork(db).Do(ctx, func(ctx context.Context) *errs.Error {
+				if _, ok := sqlclient.TxFromContext(ctx); !ok {
+					t.Fatal("expected a transaction in the context")
+				}
+				if err := NewReservation(db).Delete(ctx, uuid.NewString()); err != nil {
+					return err
+				}
+				return tt.fn
+			})
+			if tt.code == 0 && err != nil || tt.code != 0 && (err == nil || err.Code != tt.code) {
+				t.Fatalf("expected %d, got %+v", tt.code, err)
+			}
+		})
+	}
+}
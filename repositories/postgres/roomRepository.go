@@ -1,223 +1,405 @@
-package postgres
-
-import (
-	"database/sql"
-	"strings"
-	"time"
-
-	"github.com/google/uuid"
-	log "github.com/sirupsen/logrus"
-
-	model "github.com/demkowo/booking/models"
-	"github.com/demkowo/booking/utils/errs"
-
-	_ "github.com/lib/pq"
-)
-
-const (
-	CHECK_IF_ROOMS_TABLE_EXIST = "SELECT to_regclass('public.rooms')"
-	CREATE_ROOMS_TABLE         = `CREATE TABLE IF NOT EXISTS public.rooms (
-		id uuid NOT NULL,
-		name varchar(255),
-		created timestamptz NOT NULL,
-		updated timestamptz NOT NULL
-	);`
-
-	ROOM_CREATE         = "INSERT INTO rooms (id, name, created, updated) VALUES ($1, $2, $3, $4)"
-	ROOMS_FIND          = "SELECT id, name, created, updated FROM rooms ORDER BY name ASC"
-	ROOMS_FIND_AVAILABE = `
-	select
-			r.id, r.name, r.created, r.updated
-		from
-			rooms r
-		where r.id not in 
-		(select room_id from reservations rr where $1 < rr.end_date and $2 > rr.start_date);
-	`
-	ROOM_GET_BY_ID                = "SELECT id, name, created, updated FROM rooms WHERE id = $1"
-	ROOM_CHECK_IF_AVAILABLE_BY_ID = `
-	select
-			r.id, r.name, r.created, r.updated
-		from
-			rooms r
-		where r.id=$1 
-		and r.id not in (select room_id from reservations rr where $2 < rr.end_date and $3 > rr.start_date);
-	`
-	ROOM_UPDATE = "UPDATE rooms SET name=$1, updated=$2 WHERE id=$3"
-)
-
-type RoomRepo interface {
-	CreateTableRooms() string
-
-	Add(*model.Room) *errs.Error
-	Find() ([]*model.Room, *errs.Error)
-	FindAvailable(time.Time, time.Time) ([]*model.Room, *errs.Error)
-	GetByID(uuid.UUID) (*model.Room, *errs.Error)
-	CheckIfAvailableById(uuid.UUID, time.Time, time.Time) (bool, *errs.Error)
-	Update(*model.Room) *errs.Error
-}
-
-type room struct {
-	db *sql.DB
-}
-
-func NewRoom(db *sql.DB) RoomRepo {
-	return &room{
-		db: db,
-	}
-}
-
-func (r *room) CreateTableRooms() string {
-	log.Trace()
-
-	rows, err := r.db.Query(CHECK_IF_ROOMS_TABLE_EXIST)
-	if err != nil {
-		log.Panicf("CHECK_IF_ROOMS_TABLE_EXIST failed: %v", err)
-	}
-	defer rows.Close()
-
-	var tableName sql.NullString
-	for rows.Next() {
-		err := rows.Scan(&tableName)
-		if err != nil {
-			log.Panicf("rows.Scan failed: %v", err)
-		}
-	}
-
-	if tableName.Valid {
-		return "Table rooms ready to go"
-	}
-
-	_, err = r.db.Exec(CREATE_ROOMS_TABLE)
-	if err != nil {
-		log.Panicf("CREATE_ROOMS_TABLE failed: %v", err)
-	}
-
-	return "Table rooms created, DB ready to go"
-}
-
-func (r *room) Add(room *model.Room) *errs.Error {
-	log.Trace()
-
-	created := time.Now()
-	updated := created
-	_, err := r.db.Exec(ROOM_CREATE, &room.Id, &room.Name, created, updated)
-	if err != nil {
-		log.Error("ROOM_CREATE failed", err)
-		return errs.NewError("Failed to create room", 500, "Internal Server Error", []interface{}{})
-	}
-
-	return nil
-}
-
-func (r *room) Find() ([]*model.Room, *errs.Error) {
-	log.Trace()
-
-	rows, err := r.db.Query(ROOMS_FIND)
-	if err != nil {
-		log.Error("ROOMS_FIND failed", err)
-		return nil, errs.NewError("Failed to find rooms", 500, "Internal Server Error", []interface{}{})
-	}
-	defer rows.Close()
-
-	rooms := []*model.Room{}
-	for rows.Next() {
-		room := &model.Room{}
-
-		err := rows.Scan(&room.Id, &room.Name, &room.Created, &room.Updated)
-		if err != nil {
-			log.Error("ROOMS_FIND rows.Scan failed", err)
-			return nil, errs.NewError("Failed to scan rooms", 500, "Internal Server Error", []interface{}{})
-		}
-
-		rooms = append(rooms, room)
-	}
-
-	if err := rows.Err(); err != nil {
-		log.Error("ROOMS_FIND rows.Err not nil", err)
-		return nil, errs.NewError("Failed to find rooms", 500, "Internal Server Error", []interface{}{})
-	}
-
-	return rooms, nil
-}
-
-func (r *room) FindAvailable(start time.Time, end time.Time) ([]*model.Room, *errs.Error) {
-	log.Trace()
-
-	rows, err := r.db.Query(ROOMS_FIND_AVAILABE, start, end)
-	if err != nil {
-		log.Error("ROOMS_FIND_AVAILABE failed", err)
-		return nil, errs.NewError("Failed to find available rooms", 500, "Internal Server Error", []interface{}{})
-	}
-	defer rows.Close()
-
-	rooms := []*model.Room{}
-	for rows.Next() {
-		room := &model.Room{}
-
-		err := rows.Scan(&room.Id, &room.Name, &room.Created, &room.Updated)
-		if err != nil {
-			log.Error("ROOMS_FIND_AVAILABE rows.Scan failed", err)
-			return nil, errs.NewError("Failed to scan available rooms", 500, "Internal Server Error", []interface{}{})
-		}
-
-		rooms = append(rooms, room)
-	}
-
-	if err := rows.Err(); err != nil {
-		log.Error("ROOMS_FIND_AVAILABE rows.Err not nil", err)
-		return nil, errs.NewError("Failed to find available rooms", 500, "Internal Server Error", []interface{}{})
-	}
-
-	return rooms, nil
-}
-
-func (r *room) GetByID(id uuid.UUID) (*model.Room, *errs.Error) {
-	log.Trace()
-
-	row := r.db.QueryRow(ROOM_GET_BY_ID, id)
-	room := &model.Room{}
-
-	err := row.Scan(&room.Id, &room.Name, &room.Created, &room.Updated)
-	if err != nil {
-		if strings.Contains(err.Error(), "sql: no rows in result set") {
-			log.Tracef("ROOM_GET_BY_ID room %s not found", id)
-			return nil, errs.NewError("room not found", 404, "Not Found", nil)
-		}
-		log.Errorf("ROOM_GET_BY_ID failed: %v", err)
-		return nil, errs.NewError("Failed to get room", 500, "Internal Server Error", []interface{}{})
-	}
-
-	return room, nil
-}
-
-func (r *room) CheckIfAvailableById(id uuid.UUID, start time.Time, end time.Time) (bool, *errs.Error) {
-	log.Trace()
-
-	row := r.db.QueryRow(ROOM_CHECK_IF_AVAILABLE_BY_ID, id, start, end)
-	room := &model.Room{}
-
-	err := row.Scan(&room.Id, &room.Name, &room.Created, &room.Updated)
-	if err != nil {
-		if strings.Contains(err.Error(), "sql: no rows in result set") {
-			log.Tracef("ROOM_CHECK_IF_AVAILABLE_BY_ID %s not found", id)
-			return false, nil
-		}
-		log.Errorf("ROOM_CHECK_IF_AVAILABLE_BY_ID failed: %v", err)
-		return false, errs.NewError("Failed to check if room is available", 500, "Internal Server Error", []interface{}{})
-	}
-
-	return true, nil
-}
-
-func (r *room) Update(room *model.Room) *errs.Error {
-	log.Trace()
-
-	updated := time.Now()
-	_, err := r.db.Exec(ROOM_UPDATE, room.Name, updated, room.Id)
-	if err != nil {
-		log.Error("ROOM_UPDATE failed", err)
-		return errs.NewError("Failed to update room", 500, "Internal Server Error", []interface{}{})
-	}
-
-	return nil
-}
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+	"github.com/demkowo/booking/utils/tenant"
+
+	_ "github.com/lib/pq"
+)
+
+const (
+	CHECK_IF_ROOMS_TABLE_EXIST = "SELECT to_regclass('public.rooms')"
+	CREATE_ROOMS_TABLE         = `CREATE TABLE IF NOT EXISTS public.rooms (
+		id uuid NOT NULL,
+		property_id uuid NOT NULL REFERENCES public.properties (id),
+		name varchar(255),
+		max_adults integer NOT NULL DEFAULT 0,
+		max_children integer NOT NULL DEFAULT 0,
+		max_occupancy integer NOT NULL DEFAULT 0,
+		created timestamptz NOT NULL,
+		updated timestamptz NOT NULL,
+		archived timestamptz,
+		version integer NOT NULL DEFAULT 1
+	);
+	CREATE INDEX IF NOT EXISTS rooms_property_id ON public.rooms (property_id);`
+	UPGRADE_ROOMS_TABLE = `
+	ALTER TABLE public.rooms ADD COLUMN IF NOT EXISTS archived timestamptz;
+	ALTER TABLE public.rooms ADD COLUMN IF NOT EXISTS version integer NOT NULL DEFAULT 1;
+	ALTER TABLE public.rooms ADD COLUMN IF NOT EXISTS max_adults integer NOT NULL DEFAULT 0;
+	ALTER TABLE public.rooms ADD COLUMN IF NOT EXISTS max_children integer NOT NULL DEFAULT 0;
+	ALTER TABLE public.rooms ADD COLUMN IF NOT EXISTS max_occupancy integer NOT NULL DEFAULT 0;
+	ALTER TABLE public.rooms ADD COLUMN IF NOT EXISTS property_id uuid NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001' REFERENCES public.properties (id);
+	ALTER TABLE public.rooms ALTER COLUMN property_id DROP DEFAULT;
+	CREATE INDEX IF NOT EXISTS rooms_property_id ON public.rooms (property_id);
+	`
+
+	ROOM_CREATE         = "INSERT INTO rooms (id, property_id, name, max_adults, max_children, max_occupancy, created, updated, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)"
+	ROOMS_FIND          = "SELECT id, property_id, name, max_adults, max_children, max_occupancy, created, updated, archived, version FROM rooms WHERE property_id = $1 AND archived IS NULL ORDER BY name ASC"
+	ROOMS_FIND_AVAILABE = `
+	select
+			r.id, r.property_id, r.name, r.max_adults, r.max_children, r.max_occupancy, r.created, r.updated, r.archived, r.version
+		from
+			rooms r
+		where r.property_id = $8
+		and r.archived is null
+		and (r.max_adults = 0 or r.max_adults >= $3)
+		and (r.max_children = 0 or r.max_children >= $4)
+		and (r.max_occupancy = 0 or r.max_occupancy >= $5)
+		and r.id not in 
+		(select room_id from reservations rr where rr.deleted = false and $1 < rr.end_date and $2 > rr.start_date)
+		and not exists (
+			select 1 from jsonb_to_recordset($6::jsonb) f("AmenityID" uuid, "Value" text, "Min" float8, "Max" float8)
+			where not exists (` + ROOM_AMENITY_MATCHES + `))
+		order by (
+			select count(*) from jsonb_to_recordset($7::jsonb) f("AmenityID" uuid, "Value" text, "Min" float8, "Max" float8)
+			where exists (` + ROOM_AMENITY_MATCHES + `)) desc, r.name asc;
+	`
+	// ROOM_AMENITY_MATCHES finds the amenity of room r that passes filter f,
+	// through the primary key of room_amenities.
+	ROOM_AMENITY_MATCHES = `
+		select 1 from room_amenities ra
+			where ra.room_id = r.id and ra.amenity_id = f."AmenityID"
+			and (f."Value" is null or ra.value = f."Value")
+			and (f."Min" is null or ra.number >= f."Min")
+			and (f."Max" is null or ra.number <= f."Max")`
+	ROOM_GET_BY_ID                = "SELECT id, property_id, name, max_adults, max_children, max_occupancy, created, updated, archived, version FROM rooms WHERE id = $1 AND property_id = $2"
+	ROOM_CHECK_IF_AVAILABLE_BY_ID = `
+	select
+			r.id, r.property_id, r.name, r.max_adults, r.max_children, r.max_occupancy, r.created, r.updated, r.archived, r.version
+		from
+			rooms r
+		where r.id = $1
+		and r.property_id = $4
+		and r.archived is null
+		and r.id not in (select room_id from reservations rr where rr.deleted = false and $2 < rr.end_date and $3 > rr.start_date);
+	`
+	ROOM_UPDATE = "UPDATE rooms SET name=$1, max_adults=$2, max_children=$3, max_occupancy=$4, updated=$5, version=version+1 WHERE id=$6 AND property_id=$8 AND ($7 = 0 OR version=$7) RETURNING version"
+
+	ROOM_LOCK_ACTIVE = "SELECT id FROM rooms WHERE id = $1 AND property_id = $2 AND archived IS NULL FOR UPDATE"
+	ROOM_ARCHIVE     = "UPDATE rooms SET archived=$1, updated=$1, version=version+1 WHERE id=$2 AND property_id=$3 AND archived IS NULL"
+)
+
+type RoomRepo interface {
+	CreateTableRooms(context.Context) string
+
+	Add(context.Context, *model.Room) *errs.Error
+	Archive(context.Context, uuid.UUID) *errs.Error
+	Find(context.Context) ([]*model.Room, *errs.Error)
+	FindAvailable(context.Context, model.RoomSearch) ([]*model.Room, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Room, *errs.Error)
+	CheckIfAvailableById(context.Context, uuid.UUID, time.Time, time.Time) (bool, *errs.Error)
+	LockActive(context.Context, uuid.UUID) *errs.Error
+	Update(context.Context, *model.Room) *errs.Error
+}
+
+type room struct {
+	db sqlclient.SqlClient
+}
+
+func NewRoom(db sqlclient.SqlClient) RoomRepo {
+	return &room{
+		db: db,
+	}
+}
+
+func (r *room) CreateTableRooms(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	rows, err := r.db.QueryContext(ctx, CHECK_IF_ROOMS_TABLE_EXIST)
+	if err != nil {
+		log.Panicf("CHECK_IF_ROOMS_TABLE_EXIST failed: %v", err)
+	}
+	defer rows.Close()
+
+	var tableName sql.NullString
+	for rows.Next() {
+		err := rows.Scan(&tableName)
+		if err != nil {
+			log.Panicf("rows.Scan failed: %v", err)
+		}
+	}
+
+	if tableName.Valid {
+		if _, err = r.db.ExecContext(ctx, UPGRADE_ROOMS_TABLE); err != nil {
+			log.Panicf("UPGRADE_ROOMS_TABLE failed: %v", err)
+		}
+		return "Table rooms ready to go"
+	}
+
+	_, err = r.db.ExecContext(ctx, CREATE_ROOMS_TABLE)
+	if err != nil {
+		log.Panicf("CREATE_ROOMS_TABLE failed: %v", err)
+	}
+
+	return "Table rooms created, DB ready to go"
+}
+
+func (r *room) Add(ctx context.Context, room *model.Room) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "room.add")
+	defer done()
+
+	created := time.Now()
+	updated := created
+	room.PropertyID = property
+	room.Version = 1
+	_, err := r.db.ExecContext(ctx, ROOM_CREATE, &room.Id, property, &room.Name, room.MaxAdults, room.MaxChildren, room.MaxOccupancy, created, updated, room.Version)
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOM_CREATE failed", err)
+		return dbError(ctx, "Failed to create room")
+	}
+
+	return nil
+}
+
+func (r *room) Archive(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "room.archive")
+	defer done()
+
+	now := time.Now()
+	res, err := r.db.ExecContext(ctx, ROOM_ARCHIVE, now, id, property)
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOM_ARCHIVE failed", err)
+		return dbError(ctx, "Failed to archive room")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOM_ARCHIVE RowsAffected failed", err)
+		return dbError(ctx, "Failed to archive room")
+	}
+	if affected == 0 {
+		logger.FromContext(ctx).Tracef("ROOM_ARCHIVE room %s not found", id)
+		return errs.NewError("room not found", 404, "Not Found", nil)
+	}
+
+	return nil
+}
+
+func (r *room) Find(ctx context.Context) ([]*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "room.find")
+	defer done()
+
+	rows, err := r.db.QueryContext(ctx, ROOMS_FIND, property)
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND failed", err)
+		return nil, dbError(ctx, "Failed to find rooms")
+	}
+	defer rows.Close()
+
+	rooms := []*model.Room{}
+	for rows.Next() {
+		room := &model.Room{}
+
+		err := scanRoom(rows, room)
+		if err != nil {
+			logger.FromContext(ctx).Error("ROOMS_FIND rows.Scan failed", err)
+			return nil, dbError(ctx, "Failed to scan rooms")
+		}
+
+		rooms = append(rooms, room)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND rows.Err not nil", err)
+		return nil, dbError(ctx, "Failed to find rooms")
+	}
+
+	return rooms, nil
+}
+
+func (r *room) FindAvailable(ctx context.Context, search model.RoomSearch) ([]*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "room.find_available")
+	defer done()
+
+	required, err := json.Marshal(filters(search.Required))
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE json.Marshal failed", err)
+		return nil, dbError(ctx, "Failed to find available rooms")
+	}
+	preferred, err := json.Marshal(filters(search.Preferred))
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE json.Marshal failed", err)
+		return nil, dbError(ctx, "Failed to find available rooms")
+	}
+
+	occupancy := search.Occupancy
+	rows, err := r.db.QueryContext(ctx, ROOMS_FIND_AVAILABE, search.Start, search.End, occupancy.Adults, occupancy.Children, occupancy.Total(), string(required), string(preferred), property)
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE failed", err)
+		return nil, dbError(ctx, "Failed to find available rooms")
+	}
+	defer rows.Close()
+
+	rooms := []*model.Room{}
+	for rows.Next() {
+		room := &model.Room{}
+
+		err := scanRoom(rows, room)
+		if err != nil {
+			logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE rows.Scan failed", err)
+			return nil, dbError(ctx, "Failed to scan available rooms")
+		}
+
+		rooms = append(rooms, room)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE rows.Err not nil", err)
+		return nil, dbError(ctx, "Failed to find available rooms")
+	}
+
+	return rooms, nil
+}
+
+func (r *room) GetByID(ctx context.Context, id uuid.UUID) (*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "room.get_by_id")
+	defer done()
+
+	row := r.db.QueryRowContext(ctx, ROOM_GET_BY_ID, id, property)
+	room := &model.Room{}
+
+	err := scanRoom(row, room)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("ROOM_GET_BY_ID room %s not found", id)
+			return nil, errs.NewError("room not found", 404, "Not Found", nil)
+		}
+		logger.FromContext(ctx).Errorf("ROOM_GET_BY_ID failed: %v", err)
+		return nil, dbError(ctx, "Failed to get room")
+	}
+
+	return room, nil
+}
+
+func (r *room) CheckIfAvailableById(ctx context.Context, id uuid.UUID, start time.Time, end time.Time) (bool, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return false, e
+	}
+
+	ctx, done := query(ctx, "room.check_if_available_by_id")
+	defer done()
+
+	row := r.db.QueryRowContext(ctx, ROOM_CHECK_IF_AVAILABLE_BY_ID, id, start, end, property)
+	room := &model.Room{}
+
+	err := scanRoom(row, room)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("ROOM_CHECK_IF_AVAILABLE_BY_ID %s not found", id)
+			return false, nil
+		}
+		logger.FromContext(ctx).Errorf("ROOM_CHECK_IF_AVAILABLE_BY_ID failed: %v", err)
+		return false, dbError(ctx, "Failed to check if room is available")
+	}
+
+	return true, nil
+}
+
+func (r *room) LockActive(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "room.lock_active")
+	defer done()
+
+	var locked uuid.UUID
+	err := r.db.QueryRowContext(ctx, ROOM_LOCK_ACTIVE, id, property).Scan(&locked)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("ROOM_LOCK_ACTIVE room %s not found", id)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+		logger.FromContext(ctx).Errorf("ROOM_LOCK_ACTIVE failed: %v", err)
+		return dbError(ctx, "Failed to lock room")
+	}
+
+	return nil
+}
+
+func (r *room) Update(ctx context.Context, room *model.Room) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "room.update")
+	defer done()
+
+	updated := time.Now()
+	err := r.db.QueryRowContext(ctx, ROOM_UPDATE, room.Name, room.MaxAdults, room.MaxChildren, room.MaxOccupancy, updated, room.Id, room.Version, property).Scan(&room.Version)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			if _, e := r.GetByID(ctx, room.Id); e != nil {
+				return e
+			}
+			logger.FromContext(ctx).Tracef("ROOM_UPDATE room %s version %d is stale", room.Id, room.Version)
+			return errs.NewError("room was modified by another request", 412, "Precondition Failed", nil)
+		}
+		logger.FromContext(ctx).Error("ROOM_UPDATE failed", err)
+		return dbError(ctx, "Failed to update room")
+	}
+	room.PropertyID = property
+	room.Updated = updated
+
+	return nil
+}
+
+func scanRoom(row scanner, room *model.Room) error {
+	return row.Scan(&room.Id, &room.PropertyID, &room.Name, &room.MaxAdults, &room.MaxChildren, &room.MaxOccupancy, &room.Created, &room.Updated, &room.Archived, &room.Version)
+}
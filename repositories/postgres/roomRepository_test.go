@@ -0,0 +1,240 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+var testProperty = uuid.MustParse("00000000-0000-0000-0000-0000000000a1")
+
+// newMock opens the mocked SQL client and fails the test when a mock isn't
+// used or a statement has no mock.
+func newMock(t *testing.T) (context.Context, sqlclient.SqlClient) {
+	t.Helper()
+
+	sqlclient.StartMockServer()
+	t.Cleanup(sqlclient.StopMockServer)
+
+	db, err := sqlclient.Open("postgres", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := sqlclient.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	return tenant.WithProperty(context.Background(), testProperty), db
+}
+
+func roomRow(id uuid.UUID, name string, version int) []interface{} {
+	now := time.Now()
+	return []interface{}{id, testProperty, name, 2, 1, 3, now, now, nil, version}
+}
+
+func TestRoomAdd(t *testing.T) {
+	ctx, db := newMock(t)
+	room := &model.Room{Id: uuid.New(), Name: "Blue", MaxAdults: 2}
+	sqlclient.AddMock(sqlclient.Mock{
+		Query:        ROOM_CREATE,
+		Args:         []interface{}{room.Id, testProperty, "Blue", 2, 0, 0, sqlclient.AnyArg, sqlclient.AnyArg, 1},
+		RowsAffected: 1,
+	})
+
+	if err := NewRoom(db).Add(ctx, room); err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if room.PropertyID != testProperty || room.Version != 1 {
+		t.Fatalf("unexpected room %+v", room)
+	}
+}
+
+func TestRoomAddFails(t *testing.T) {
+	ctx, db := newMock(t)
+	sqlclient.AddMock(sqlclient.Mock{Query: ROOM_CREATE, Error: errors.New("connection reset")})
+
+	err := NewRoom(db).Add(ctx, &model.Room{Id: uuid.New(), Name: "Blue"})
+	if err == nil || err.Code != 500 {
+		t.Fatalf("expected 500, got %+v", err)
+	}
+}
+
+func TestRoomGetByID(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name string
+		mock sqlclient.Mock
+		code int
+	}{
+		{name: "found", mock: sqlclient.Mock{Rows: [][]interface{}{roomRow(id, "Blue", 2)}}},
+		{name: "not found", mock: sqlclient.Mock{}, code: 404},
+		{name: "no rows error", mock: sqlclient.Mock{Error: errors.New("sql: no rows in result set")}, code: 404},
+		{name: "failed", mock: sqlclient.Mock{Error: errors.New("connection reset")}, code: 500},
+		{name: "bad row", mock: sqlclient.Mock{Rows: [][]interface{}{{id}}}, code: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, db := newMock(t)
+			tt.mock.Query = ROOM_GET_BY_ID
+			tt.mock.Args = []interface{}{id, testProperty}
+			sqlclient.AddMock(tt.mock)
+
+			room, err := NewRoom(db).GetByID(ctx, id)
+			if tt.code != 0 {
+				if err == nil || err.Code != tt.code {
+					t.Fatalf("expected %d, got %+v", tt.code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %+v", err)
+			}
+			if room.Id != id || room.PropertyID != testProperty || room.Name != "Blue" || room.MaxOccupancy != 3 || room.Archived != nil || room.Version != 2 {
+				t.Fatalf("unexpected room %+v", room)
+			}
+		})
+	}
+}
+
+func TestRoomFind(t *testing.T) {
+	ctx, db := newMock(t)
+	sqlclient.AddMock(sqlclient.Mock{
+		Query: ROOMS_FIND,
+		Args:  []interface{}{testProperty},
+		Rows:  [][]interface{}{roomRow(uuid.New(), "Blue", 1), roomRow(uuid.New(), "Red", 1)},
+	})
+
+	rooms, err := NewRoom(db).Find(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error %+v", err)
+	}
+	if len(rooms) != 2 || rooms[0].Name != "Blue" || rooms[1].Name != "Red" {
+		t.Fatalf("unexpected rooms %+v", rooms)
+	}
+}
+
+func TestRoomFindFails(t *testing.T) {
+	tests := []struct {
+		name string
+		mock sqlclient.Mock
+	}{
+		{name: "query", mock: sqlclient.Mock{Error: errors.New("connection reset")}},
+		{name: "scan", mock: sqlclient.Mock{Rows: [][]interface{}{{"not a room"}}}},
+		{name: "rows", mock: sqlclient.Mock{Rows: [][]interface{}{roomRow(uuid.New(), "Blue", 1)}, RowsError: errors.New("connection reset")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, db := newMock(t)
+			tt.mock.Query = ROOMS_FIND
+			sqlclient.AddMock(tt.mock)
+
+			rooms, err := NewRoom(db).Find(ctx)
+			if err == nil || err.Code != 500 || rooms != nil {
+				t.Fatalf("expected 500, got %v %+v", rooms, err)
+			}
+		})
+	}
+}
+
+func TestRoomUpdate(t *testing.T) {
+	id := uuid.New()
+	tests := []struct {
+		name  string
+		mocks []sqlclient.Mock
+		code  int
+	}{
+		{
+			name:  "updated",
+			mocks: []sqlclient.Mock{{Query: ROOM_UPDATE, Rows: [][]interface{}{{3}}}},
+		},
+		{
+			name: "stale",
+			mocks: []sqlclient.Mock{
+				{Query: ROOM_UPDATE},
+				{Query: ROOM_GET_BY_ID, Args: []interface{}{id, testProperty}, Rows: [][]interface{}{roomRow(id, "Blue", 3)}},
+			},
+			code: 412,
+		},
+		{
+			name: "missing",
+			mocks: []sqlclient.Mock{
+				{Query: ROOM_UPDATE},
+				{Query: ROOM_GET_BY_ID, Args: []interface{}{id, testProperty}},
+			},
+			code: 404,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, db := newMock(t)
+			for _, mock := range tt.mocks {
+				if mock.Query == ROOM_UPDATE {
+					mock.Args = []interface{}{"Red", 0, 0, 0, sqlclient.AnyArg, id, 2, testProperty}
+				}
+				sqlclient.AddMock(mock)
+			}
+
+			room := &model.Room{Id: id, Name: "Red", Version: 2}
+			err := NewRoom(db).Update(ctx, room)
+			if tt.code != 0 {
+				if err == nil || err.Code != tt.code {
+					t.Fatalf("expected %d, got %+v", tt.code, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error %+v", err)
+			}
+			if room.Version != 3 || room.PropertyID != testProperty {
+				t.Fatalf("unexpected room %+v", room)
+			}
+		})
+	}
+}
+
+func TestRoomArchive(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		code     int
+	}{
+		{name: "archived", affected: 1},
+		{name: "missing", affected: 0, code: 404},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, db := newMock(t)
+			id := uuid.New()
+			sqlclient.AddMock(sqlclient.Mock{Query: ROOM_ARCHIVE, Args: []interface{}{sqlclient.AnyArg, id, testProperty}, RowsAffected: tt.affected})
+
+			err := NewRoom(db).Archive(ctx, id)
+			if tt.code == 0 && err != nil || tt.code != 0 && (err == nil || err.Code != tt.code) {
+				t.Fatalf("expected %d, got %+v", tt.code, err)
+			}
+		})
+	}
+}
+
+func TestRoomRequiresProperty(t *testing.T) {
+	_, db := newMock(t)
+
+	if _, err := NewRoom(db).Find(context.Background()); err == nil || err.Code != 500 {
+		t.Fatalf("expected 500, got %+v", err)
+	}
+	if _, err := NewRoom(db).GetByID(context.Background(), uuid.New()); err == nil || err.Code != 500 {
+		t.Fatalf("expected 500, got %+v", err)
+	}
+}
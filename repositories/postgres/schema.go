@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+)
+
+const (
+	SCHEMA_TABLE_EXISTS = "SELECT to_regclass($1) IS NOT NULL"
+	SCHEMA_COLUMNS      = "SELECT column_name FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2"
+)
+
+// tables lists the columns the repositories use, including the ones added by
+// the UPGRADE statements.
+var tables = []struct {
+	name    string
+	columns []string
+}{
+	{"public.properties", []string{"id", "name", "created", "updated", "version"}},
+	{"public.rooms", []string{"id", "property_id", "name", "max_adults", "max_children", "max_occupancy", "created", "updated", "archived", "version"}},
+	{"public.reservations", []string{"id", "property_id", "user_id", "start_date", "end_date", "room_id", "status", "adults", "children", "created", "updated", "deleted", "deleted_at", "version"}},
+	{"public.idempotency_keys", []string{"key", "request_hash", "status", "headers", "response", "created"}},
+	{"public.amenities", []string{"id", "key", "name", "type", "enum_values", "created", "updated", "version"}},
+	{"public.room_amenities", []string{"room_id", "amenity_id", "value", "number"}},
+}
+
+// CheckSchema reports a table or a column the service needs that hasn't been
+// created.
+func CheckSchema(ctx context.Context, db sqlclient.SqlClient) error {
+	for _, table := range tables {
+		var exists bool
+		if err := db.QueryRowContext(ctx, SCHEMA_TABLE_EXISTS, table.name).Scan(&exists); err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("table %s doesn't exist", table.name)
+		}
+
+		columns, err := tableColumns(ctx, db, table.name)
+		if err != nil {
+			return err
+		}
+		for _, column := range table.columns {
+			if !columns[column] {
+				return fmt.Errorf("column %s.%s doesn't exist", table.name, column)
+			}
+		}
+	}
+
+	return nil
+}
+
+func tableColumns(ctx context.Context, db sqlclient.SqlClient, table string) (map[string]bool, error) {
+	schema, name, _ := strings.Cut(table, ".")
+
+	rows, err := db.QueryContext(ctx, SCHEMA_COLUMNS, schema, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns[column] = true
+	}
+
+	return columns, rows.Err()
+}
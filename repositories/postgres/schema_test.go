@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+)
+
+func TestCheckSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		missing string
+		rows    error
+		err     string
+	}{
+		{name: "up to date"},
+		{name: "missing column", missing: "public.rooms.property_id", err: "column public.rooms.property_id doesn't exist"},
+		{name: "missing upgrade column", missing: "public.reservations.adults", err: "column public.reservations.adults doesn't exist"},
+		{name: "failed read", rows: errors.New("connection reset"), err: "connection reset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, db := newMock(t)
+			for _, table := range tables {
+				sqlclient.AddMock(sqlclient.Mock{Query: SCHEMA_TABLE_EXISTS, Args: []interface{}{table.name}, Rows: [][]interface{}{{true}}})
+
+				schema, name, _ := strings.Cut(table.name, ".")
+				rows := [][]interface{}{}
+				for _, column := range table.columns {
+					if table.name+"."+column != tt.missing {
+						rows = append(rows, []interface{}{column})
+					}
+				}
+				sqlclient.AddMock(sqlclient.Mock{Query: SCHEMA_COLUMNS, Args: []interface{}{schema, name}, Rows: rows, RowsError: tt.rows})
+
+				if tt.err != "" && (tt.rows != nil || strings.HasPrefix(tt.missing, table.name+".")) {
+					break
+				}
+			}
+
+			err := CheckSchema(ctx, db)
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || err.Error() != tt.err) {
+				t.Fatalf("expected %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestCheckSchemaMissingTable(t *testing.T) {
+	ctx, db := newMock(t)
+	sqlclient.AddMock(sqlclient.Mock{Query: SCHEMA_TABLE_EXISTS, Args: []interface{}{"public.properties"}, Rows: [][]interface{}{{false}}})
+
+	if err := CheckSchema(ctx, db); err == nil || err.Error() != "table public.properties doesn't exist" {
+		t.Fatalf("expected a missing table, got %v", err)
+	}
+}
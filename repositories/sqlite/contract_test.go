@@ -0,0 +1,41 @@
+package sqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/demkowo/booking/repositories/contract"
+	"github.com/demkowo/booking/repositories/sqlite"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+)
+
+func TestContract(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqlclient.Open(sqlite.DRIVER_NAME, sqlite.DSN(filepath.Join(t.TempDir(), "contract.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	backend := contract.Backend{
+		Properties:   sqlite.NewProperty(db),
+		Rooms:        sqlite.NewRoom(db),
+		Reservations: sqlite.NewReservation(db),
+		Amenities:    sqlite.NewAmenity(db),
+		UnitOfWork:   sqlite.NewUnitOfWork(db),
+	}
+	backend.Properties.CreateTableProperties(ctx)
+	backend.Rooms.CreateTableRooms(ctx)
+	backend.Reservations.CreateTableReservations(ctx)
+	backend.Amenities.CreateTableAmenities(ctx)
+
+	for _, result := range contract.Run(ctx, backend) {
+		t.Run(result.Name, func(t *testing.T) {
+			if result.Err != nil {
+				t.Error(result.Err)
+			}
+		})
+	}
+}
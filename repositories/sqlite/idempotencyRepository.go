@@ -0,0 +1,146 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+)
+
+const (
+	IDEMPOTENCY_KEY_DELETE_EXPIRED = "DELETE FROM idempotency_keys WHERE key = ?1 AND created < ?2"
+	IDEMPOTENCY_KEY_RESERVE        = "INSERT INTO idempotency_keys (key, request_hash, created) VALUES (?1, ?2, ?3) ON CONFLICT (key) DO NOTHING"
+	IDEMPOTENCY_KEY_GET            = "SELECT key, request_hash, status, headers, response, created FROM idempotency_keys WHERE key = ?1"
+	IDEMPOTENCY_KEY_COMPLETE       = "UPDATE idempotency_keys SET status=?1, headers=?2, response=?3 WHERE key=?4"
+	IDEMPOTENCY_KEY_RELEASE        = "DELETE FROM idempotency_keys WHERE key = ?1 AND status = 0"
+)
+
+type IdempotencyRepo interface {
+	CreateTableIdempotencyKeys(context.Context) string
+
+	Complete(context.Context, *model.IdempotencyKey) *errs.Error
+	DeleteExpired(context.Context, string, time.Time) *errs.Error
+	Get(context.Context, string) (*model.IdempotencyKey, *errs.Error)
+	Release(context.Context, string) *errs.Error
+	Reserve(context.Context, *model.IdempotencyKey) (bool, *errs.Error)
+}
+
+type idempotency struct {
+	db sqlclient.SqlClient
+}
+
+func NewIdempotency(db sqlclient.SqlClient) IdempotencyRepo {
+	return &idempotency{
+		db: db,
+	}
+}
+
+func (r *idempotency) CreateTableIdempotencyKeys(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return migrate(ctx, r.db, "idempotency_keys")
+}
+
+func (r *idempotency) Complete(ctx context.Context, key *model.IdempotencyKey) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "idempotency.complete")
+	defer done()
+
+	headers, err := json.Marshal(key.Headers)
+	if err != nil {
+		logger.FromContext(ctx).Error("IDEMPOTENCY_KEY_COMPLETE json.Marshal failed", err)
+		return dbError(ctx, "Failed to store idempotent response")
+	}
+
+	if _, err := r.db.ExecContext(ctx, IDEMPOTENCY_KEY_COMPLETE, key.Status, string(headers), key.Response, key.Key); err != nil {
+		logger.FromContext(ctx).Error("IDEMPOTENCY_KEY_COMPLETE failed", err)
+		return dbError(ctx, "Failed to store idempotent response")
+	}
+
+	return nil
+}
+
+func (r *idempotency) DeleteExpired(ctx context.Context, key string, before time.Time) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "idempotency.delete_expired")
+	defer done()
+
+	if _, err := r.db.ExecContext(ctx, IDEMPOTENCY_KEY_DELETE_EXPIRED, key, before.UTC()); err != nil {
+		logger.FromContext(ctx).Error("IDEMPOTENCY_KEY_DELETE_EXPIRED failed", err)
+		return dbError(ctx, "Failed to expire idempotency key")
+	}
+
+	return nil
+}
+
+func (r *idempotency) Get(ctx context.Context, key string) (*model.IdempotencyKey, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "idempotency.get")
+	defer done()
+
+	record := &model.IdempotencyKey{}
+	var headers sql.NullString
+
+	err := r.db.QueryRowContext(ctx, IDEMPOTENCY_KEY_GET, key).Scan(&record.Key, &record.RequestHash, &record.Status, &headers, &record.Response, &record.Created)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("IDEMPOTENCY_KEY_GET %s not found", key)
+			return nil, errs.NewError("idempotency key not found", 404, "Not Found", nil)
+		}
+		logger.FromContext(ctx).Errorf("IDEMPOTENCY_KEY_GET failed: %v", err)
+		return nil, dbError(ctx, "Failed to get idempotency key")
+	}
+
+	if headers.Valid {
+		if err := json.Unmarshal([]byte(headers.String), &record.Headers); err != nil {
+			logger.FromContext(ctx).Errorf("IDEMPOTENCY_KEY_GET json.Unmarshal failed: %v", err)
+			return nil, dbError(ctx, "Failed to get idempotency key")
+		}
+	}
+
+	return record, nil
+}
+
+func (r *idempotency) Release(ctx context.Context, key string) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "idempotency.release")
+	defer done()
+
+	if _, err := r.db.ExecContext(ctx, IDEMPOTENCY_KEY_RELEASE, key); err != nil {
+		logger.FromContext(ctx).Error("IDEMPOTENCY_KEY_RELEASE failed", err)
+		return dbError(ctx, "Failed to release idempotency key")
+	}
+
+	return nil
+}
+
+func (r *idempotency) Reserve(ctx context.Context, key *model.IdempotencyKey) (bool, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "idempotency.reserve")
+	defer done()
+
+	res, err := r.db.ExecContext(ctx, IDEMPOTENCY_KEY_RESERVE, key.Key, key.RequestHash, key.Created.UTC())
+	if err != nil {
+		logger.FromContext(ctx).Error("IDEMPOTENCY_KEY_RESERVE failed", err)
+		return false, dbError(ctx, "Failed to reserve idempotency key")
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("IDEMPOTENCY_KEY_RESERVE RowsAffected failed", err)
+		return false, dbError(ctx, "Failed to reserve idempotency key")
+	}
+
+	return count == 1, nil
+}
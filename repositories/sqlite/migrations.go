@@ -0,0 +1,167 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+
+	"github.com/demkowo/booking/utils/logger"
+	_ "modernc.org/sqlite"
+)
+
+const (
+	DRIVER_NAME = "sqlite"
+
+	CREATE_SCHEMA_MIGRATIONS_TABLE = "CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL PRIMARY KEY, applied DATETIME NOT NULL)"
+	SCHEMA_MIGRATIONS_CURRENT      = "SELECT coalesce(max(version), 0) FROM schema_migrations"
+	SCHEMA_MIGRATIONS_ADD          = "INSERT INTO schema_migrations (version, applied) VALUES (?1, ?2)"
+
+	// DSN_PARAMS serialises writers, so a unit of work holds the write lock
+	// from its first statement like SELECT ... FOR UPDATE does in Postgres.
+	DSN_PARAMS = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate&_time_format=sqlite"
+)
+
+// migrations are applied in order and never edited once released. Append a
+// new entry to change the schema.
+var migrations = []string{
+	`CREATE TABLE rooms (
+		id TEXT NOT NULL PRIMARY KEY,
+		name TEXT,
+		created DATETIME NOT NULL,
+		updated DATETIME NOT NULL,
+		archived DATETIME,
+		version INTEGER NOT NULL DEFAULT 1
+	);
+	CREATE TABLE reservations (
+		id TEXT NOT NULL PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		start_date DATETIME NOT NULL,
+		end_date DATETIME NOT NULL,
+		room_id TEXT NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		created DATETIME NOT NULL,
+		updated DATETIME NOT NULL,
+		deleted INTEGER NOT NULL DEFAULT 0,
+		deleted_at DATETIME,
+		version INTEGER NOT NULL DEFAULT 1
+	);
+	CREATE INDEX reservations_room_dates ON reservations (room_id, start_date, end_date) WHERE deleted = 0;
+	CREATE TABLE idempotency_keys (
+		key TEXT NOT NULL PRIMARY KEY,
+		request_hash TEXT NOT NULL,
+		status INTEGER NOT NULL DEFAULT 0,
+		headers TEXT,
+		response BLOB,
+		created DATETIME NOT NULL
+	);`,
+	`ALTER TABLE rooms ADD COLUMN max_adults INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE rooms ADD COLUMN max_children INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE rooms ADD COLUMN max_occupancy INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE reservations ADD COLUMN adults INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE reservations ADD COLUMN children INTEGER NOT NULL DEFAULT 0;`,
+	`CREATE TABLE amenities (
+		id TEXT NOT NULL PRIMARY KEY,
+		key TEXT NOT NULL UNIQUE,
+		name TEXT NOT NULL,
+		type TEXT NOT NULL,
+		enum_values TEXT NOT NULL DEFAULT '[]',
+		created DATETIME NOT NULL,
+		updated DATETIME NOT NULL,
+		version INTEGER NOT NULL DEFAULT 1
+	);
+	CREATE TABLE room_amenities (
+		room_id TEXT NOT NULL,
+		amenity_id TEXT NOT NULL,
+		value TEXT NOT NULL,
+		number REAL,
+		PRIMARY KEY (room_id, amenity_id)
+	);
+	CREATE INDEX room_amenities_value ON room_amenities (amenity_id, value);
+	CREATE INDEX room_amenities_number ON room_amenities (amenity_id, number) WHERE number IS NOT NULL;`,
+	`CREATE TABLE properties (
+		id TEXT NOT NULL PRIMARY KEY,
+		name TEXT NOT NULL,
+		created DATETIME NOT NULL,
+		updated DATETIME NOT NULL,
+		version INTEGER NOT NULL DEFAULT 1
+	);
+	INSERT INTO properties (id, name, created, updated) VALUES ('00000000-0000-0000-0000-000000000001', 'Default', datetime('now'), datetime('now'));
+	ALTER TABLE rooms ADD COLUMN property_id TEXT NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+	ALTER TABLE reservations ADD COLUMN property_id TEXT NOT NULL DEFAULT '00000000-0000-0000-0000-000000000001';
+	CREATE INDEX rooms_property_id ON rooms (property_id);
+	CREATE INDEX reservations_property_id ON reservations (property_id);`,
+}
+
+var migrateMu sync.Mutex
+
+// DSN adds the connection parameters the repositories rely on to a database
+// file path.
+func DSN(path string) string {
+	if strings.Contains(path, "?") {
+		return path + "&" + DSN_PARAMS
+	}
+
+	return "file:" + strings.TrimPrefix(path, "file:") + "?" + DSN_PARAMS
+}
+
+// migrate brings the schema up to date. Every repository calls it from its
+// CreateTable method, the first call applies the pending migrations.
+func migrate(ctx context.Context, db sqlclient.SqlClient, table string) string {
+	logger.FromContext(ctx).Trace()
+
+	migrateMu.Lock()
+	defer migrateMu.Unlock()
+
+	if _, err := db.ExecContext(ctx, CREATE_SCHEMA_MIGRATIONS_TABLE); err != nil {
+		log.Panicf("CREATE_SCHEMA_MIGRATIONS_TABLE failed: %v", err)
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, SCHEMA_MIGRATIONS_CURRENT).Scan(&current); err != nil {
+		log.Panicf("SCHEMA_MIGRATIONS_CURRENT failed: %v", err)
+	}
+
+	if current >= len(migrations) {
+		return "Table " + table + " ready to go"
+	}
+
+	for version := current + 1; version <= len(migrations); version++ {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			log.Panicf("migration %d begin failed: %v", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, migrations[version-1]); err != nil {
+			tx.Rollback()
+			log.Panicf("migration %d failed: %v", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, SCHEMA_MIGRATIONS_ADD, version, time.Now().UTC()); err != nil {
+			tx.Rollback()
+			log.Panicf("migration %d failed: %v", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			log.Panicf("migration %d commit failed: %v", version, err)
+		}
+		logger.FromContext(ctx).Infof("sqlite migration %d applied", version)
+	}
+
+	return "Table " + table + " created, DB ready to go"
+}
+
+// CheckSchema reports when migrations are still pending.
+func CheckSchema(ctx context.Context, db sqlclient.SqlClient) error {
+	var current int
+	if err := db.QueryRowContext(ctx, SCHEMA_MIGRATIONS_CURRENT).Scan(&current); err != nil {
+		return err
+	}
+	if current < len(migrations) {
+		return fmt.Errorf("schema at version %d, expected %d", current, len(migrations))
+	}
+
+	return nil
+}
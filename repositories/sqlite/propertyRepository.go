@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+)
+
+const (
+	PROPERTY_CREATE    = "INSERT INTO properties (id, name, created, updated, version) VALUES (?1, ?2, ?3, ?4, ?5)"
+	PROPERTIES_FIND    = "SELECT id, name, created, updated, version FROM properties ORDER BY name ASC"
+	PROPERTY_GET_BY_ID = "SELECT id, name, created, updated, version FROM properties WHERE id = ?1"
+	PROPERTY_UPDATE    = "UPDATE properties SET name=?1, updated=?2, version=version+1 WHERE id=?3 AND (?4 = 0 OR version=?4) RETURNING version"
+)
+
+type PropertyRepo interface {
+	CreateTableProperties(context.Context) string
+
+	Add(context.Context, *model.Property) *errs.Error
+	Find(context.Context) ([]*model.Property, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Property, *errs.Error)
+	Update(context.Context, *model.Property) *errs.Error
+}
+
+type property struct {
+	db sqlclient.SqlClient
+}
+
+func NewProperty(db sqlclient.SqlClient) PropertyRepo {
+	return &property{
+		db: db,
+	}
+}
+
+func (r *property) CreateTableProperties(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return migrate(ctx, r.db, "properties")
+}
+
+func (r *property) Add(ctx context.Context, property *model.Property) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "property.add")
+	defer done()
+
+	property.Version = 1
+	_, err := r.db.ExecContext(ctx, PROPERTY_CREATE, property.Id, property.Name, property.Created.UTC(), property.Updated.UTC(), property.Version)
+	if err != nil {
+		logger.FromContext(ctx).Error("PROPERTY_CREATE failed", err)
+		return dbError(ctx, "Failed to create property")
+	}
+
+	return nil
+}
+
+func (r *property) Find(ctx context.Context) ([]*model.Property, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "property.find")
+	defer done()
+
+	rows, err := r.db.QueryContext(ctx, PROPERTIES_FIND)
+	if err != nil {
+		logger.FromContext(ctx).Error("PROPERTIES_FIND failed", err)
+		return nil, dbError(ctx, "Failed to find properties")
+	}
+	defer rows.Close()
+
+	properties := []*model.Property{}
+	for rows.Next() {
+		property := &model.Property{}
+
+		if err := scanProperty(rows, property); err != nil {
+			logger.FromContext(ctx).Error("PROPERTIES_FIND rows.Scan failed", err)
+			return nil, dbError(ctx, "Failed to scan properties")
+		}
+
+		properties = append(properties, property)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.FromContext(ctx).Error("PROPERTIES_FIND rows.Err not nil", err)
+		return nil, dbError(ctx, "Failed to find properties")
+	}
+
+	return properties, nil
+}
+
+func (r *property) GetByID(ctx context.Context, id uuid.UUID) (*model.Property, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "property.get_by_id")
+	defer done()
+
+	property := &model.Property{}
+	if err := scanProperty(r.db.QueryRowContext(ctx, PROPERTY_GET_BY_ID, id), property); err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("PROPERTY_GET_BY_ID property %s not found", id)
+			return nil, errs.NewError("property not found", 404, "Not Found", nil)
+		}
+		logger.FromContext(ctx).Errorf("PROPERTY_GET_BY_ID failed: %v", err)
+		return nil, dbError(ctx, "Failed to get property")
+	}
+
+	return property, nil
+}
+
+func (r *property) Update(ctx context.Context, property *model.Property) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, done := query(ctx, "property.update")
+	defer done()
+
+	updated := time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, PROPERTY_UPDATE, property.Name, updated, property.Id, property.Version).Scan(&property.Version)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			if _, e := r.GetByID(ctx, property.Id); e != nil {
+				return e
+			}
+			logger.FromContext(ctx).Tracef("PROPERTY_UPDATE property %s version %d is stale", property.Id, property.Version)
+			return errs.NewError("property was modified by another request", 412, "Precondition Failed", nil)
+		}
+		logger.FromContext(ctx).Error("PROPERTY_UPDATE failed", err)
+		return dbError(ctx, "Failed to update property")
+	}
+	property.Updated = updated
+
+	return nil
+}
+
+func scanProperty(row scanner, property *model.Property) error {
+	return row.Scan(&property.Id, &property.Name, &property.Created, &property.Updated, &property.Version)
+}
@@ -0,0 +1,509 @@
+package sqlite
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+const (
+	RESERVATION_COLUMNS = "id, property_id, user_id, start_date, end_date, room_id, status, adults, children, created, updated, deleted, deleted_at, version"
+
+	RESERVATION_CREATE            = "INSERT INTO reservations (id, property_id, user_id, start_date, end_date, room_id, status, adults, children, created, updated, deleted, version) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13)"
+	RESERVATION_DELETE            = "UPDATE reservations SET deleted=1, deleted_at=?1, updated=?1, version=version+1 WHERE id = ?2 AND property_id = ?3 AND deleted = 0"
+	RESERVATION_FIND              = "SELECT " + RESERVATION_COLUMNS + " FROM reservations WHERE property_id = ?1 AND deleted = 0 ORDER BY updated DESC"
+	RESERVATION_FIND_DELETED      = "SELECT " + RESERVATION_COLUMNS + " FROM reservations WHERE property_id = ?1 AND deleted = 1 ORDER BY deleted_at DESC"
+	RESERVATION_FIND_BY_ROOM_ID   = "SELECT " + RESERVATION_COLUMNS + " FROM reservations WHERE property_id = ?2 AND deleted = 0 AND room_id = ?1 ORDER BY updated DESC"
+	RESERVATION_GET_BY_ID         = "SELECT " + RESERVATION_COLUMNS + " FROM reservations WHERE property_id = ?2 AND deleted = 0 AND id = ?1"
+	RESERVATION_GET_DELETED_BY_ID = "SELECT " + RESERVATION_COLUMNS + " FROM reservations WHERE property_id = ?2 AND deleted = 1 AND id = ?1"
+	RESERVATION_RESTORE           = "UPDATE reservations SET deleted=0, deleted_at=NULL, updated=?1, version=version+1 WHERE id=?2 AND property_id=?3 AND deleted=1"
+	RESERVATION_PURGE_DELETED     = "DELETE FROM reservations WHERE property_id = ?2 AND deleted = 1 AND deleted_at < ?1"
+	RESERVATION_ANONYMIZE_DELETED = "UPDATE reservations SET user_id=?1, updated=?2, version=version+1 WHERE property_id = ?4 AND deleted = 1 AND deleted_at < ?3 AND user_id <> ?1"
+	RESERVATION_UPDATE            = "UPDATE reservations SET start_date=?1, end_date=?2, room_id=?3, adults=?4, children=?5, updated=?6, version=version+1 WHERE id=?7 AND property_id=?9 AND deleted = 0 AND (?8 = 0 OR version=?8) RETURNING version, status"
+
+	RESERVATION_COUNT_FUTURE_BY_ROOM_ID = "SELECT count(*) FROM reservations WHERE room_id = ?1 AND property_id = ?3 AND deleted = 0 AND end_date > ?2"
+	RESERVATION_COUNT_MOVE_CONFLICTS    = `
+	select
+			count(*)
+		from
+			reservations src
+		join reservations dst
+			on dst.room_id = ?2
+			and dst.property_id = ?4
+			and dst.deleted = 0
+			and src.start_date < dst.end_date
+			and src.end_date > dst.start_date
+		where src.room_id = ?1
+		and src.property_id = ?4
+		and src.deleted = 0
+		and src.end_date > ?3;
+	`
+	RESERVATION_HAS_OVERLAP      = "SELECT EXISTS (SELECT 1 FROM reservations WHERE room_id = ?1 AND property_id = ?5 AND deleted = 0 AND ?2 < end_date AND ?3 > start_date AND id <> ?4)"
+	RESERVATION_MOVE_FUTURE      = "UPDATE reservations SET room_id=?2, updated=?3, version=version+1 WHERE room_id=?1 AND property_id=?4 AND deleted = 0 AND end_date > ?3"
+	RESERVATION_ROOM_IN_PROPERTY = "SELECT EXISTS (SELECT 1 FROM rooms WHERE id = ?1 AND property_id = ?2)"
+)
+
+type ReservationRepo interface {
+	CreateTableReservations(context.Context) string
+
+	Add(context.Context, *model.Reservation) *errs.Error
+	AnonymizeDeleted(context.Context, time.Time) (int64, *errs.Error)
+	CountFutureByRoomID(context.Context, uuid.UUID, time.Time) (int64, *errs.Error)
+	CountMoveConflicts(context.Context, uuid.UUID, uuid.UUID, time.Time) (int64, *errs.Error)
+	Delete(context.Context, string) *errs.Error
+	Find(context.Context) ([]*model.Reservation, *errs.Error)
+	FindByRoomID(context.Context, uuid.UUID) ([]*model.Reservation, *errs.Error)
+	FindDeleted(context.Context) ([]*model.Reservation, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Reservation, *errs.Error)
+	GetDeletedByID(context.Context, uuid.UUID) (*model.Reservation, *errs.Error)
+	HasOverlap(context.Context, uuid.UUID, time.Time, time.Time, uuid.UUID) (bool, *errs.Error)
+	MoveFuture(context.Context, uuid.UUID, uuid.UUID, time.Time) (int64, *errs.Error)
+	PurgeDeleted(context.Context, time.Time) (int64, *errs.Error)
+	Restore(context.Context, uuid.UUID) *errs.Error
+	Update(context.Context, *model.Reservation) *errs.Error
+}
+
+type reservation struct {
+	db sqlclient.SqlClient
+}
+
+func NewReservation(db sqlclient.SqlClient) ReservationRepo {
+	return &reservation{
+		db: db,
+	}
+}
+
+func (r *reservation) CreateTableReservations(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return migrate(ctx, r.db, "reservations")
+}
+
+func (r *reservation) Add(ctx context.Context, reservation *model.Reservation) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "reservation.add")
+	defer done()
+
+	if e := r.checkRoom(ctx, reservation.RoomID, property); e != nil {
+		return e
+	}
+
+	reservation.PropertyID = property
+	reservation.Version = 1
+	_, err := r.db.ExecContext(ctx, RESERVATION_CREATE,
+		&reservation.Id,
+		property,
+		&reservation.UserId,
+		reservation.StartDate.UTC(),
+		reservation.EndDate.UTC(),
+		&reservation.RoomID,
+		&reservation.Status,
+		&reservation.Adults,
+		&reservation.Children,
+		reservation.Created.UTC(),
+		reservation.Updated.UTC(),
+		&reservation.Deleted,
+		&reservation.Version)
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_CREATE failed", err)
+		return dbError(ctx, "Failed to create reservation")
+	}
+
+	return nil
+}
+
+func (r *reservation) AnonymizeDeleted(ctx context.Context, before time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	ctx, done := query(ctx, "reservation.anonymize_deleted")
+	defer done()
+
+	res, err := r.db.ExecContext(ctx, RESERVATION_ANONYMIZE_DELETED, uuid.Nil, time.Now().UTC(), before.UTC(), property)
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_ANONYMIZE_DELETED failed", err)
+		return 0, dbError(ctx, "Failed to anonymize deleted reservations")
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_ANONYMIZE_DELETED RowsAffected failed", err)
+		return 0, dbError(ctx, "Failed to anonymize deleted reservations")
+	}
+
+	return count, nil
+}
+
+func (r *reservation) CountFutureByRoomID(ctx context.Context, roomID uuid.UUID, since time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	ctx, done := query(ctx, "reservation.count_future_by_room_id")
+	defer done()
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, RESERVATION_COUNT_FUTURE_BY_ROOM_ID, roomID, since.UTC(), property).Scan(&count); err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_COUNT_FUTURE_BY_ROOM_ID failed", err)
+		return 0, dbError(ctx, "Failed to count reservations")
+	}
+
+	return count, nil
+}
+
+func (r *reservation) CountMoveConflicts(ctx context.Context, from uuid.UUID, to uuid.UUID, since time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	ctx, done := query(ctx, "reservation.count_move_conflicts")
+	defer done()
+
+	var count int64
+	if err := r.db.QueryRowContext(ctx, RESERVATION_COUNT_MOVE_CONFLICTS, from, to, since.UTC(), property).Scan(&count); err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_COUNT_MOVE_CONFLICTS failed", err)
+		return 0, dbError(ctx, "Failed to count reservation conflicts")
+	}
+
+	return count, nil
+}
+
+func (r *reservation) Delete(ctx context.Context, id string) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "reservation.delete")
+	defer done()
+
+	updated := time.Now().UTC()
+	res, err := r.db.ExecContext(ctx, RESERVATION_DELETE, updated, id, property)
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_DELETE failed", err)
+		return dbError(ctx, "Failed to delete reservation")
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_DELETE RowsAffected failed", err)
+		return dbError(ctx, "Failed to delete reservation")
+	}
+	if count == 0 {
+		return errs.NewError("Reservation not found", 404, "Not Found", nil)
+	}
+
+	return nil
+}
+
+func (r *reservation) Find(ctx context.Context) ([]*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "reservation.find")
+	defer done()
+
+	return r.list(ctx, "RESERVATION_FIND", RESERVATION_FIND, property)
+}
+
+func (r *reservation) FindByRoomID(ctx context.Context, id uuid.UUID) ([]*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "reservation.find_by_room_id")
+	defer done()
+
+	return r.list(ctx, "RESERVATION_FIND_BY_ROOM_ID", RESERVATION_FIND_BY_ROOM_ID, id, property)
+}
+
+func (r *reservation) FindDeleted(ctx context.Context) ([]*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "reservation.find_deleted")
+	defer done()
+
+	return r.list(ctx, "RESERVATION_FIND_DELETED", RESERVATION_FIND_DELETED, property)
+}
+
+func (r *reservation) GetByID(ctx context.Context, id uuid.UUID) (*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "reservation.get_by_id")
+	defer done()
+
+	return r.get(ctx, "RESERVATION_GET_BY_ID", RESERVATION_GET_BY_ID, id, property)
+}
+
+func (r *reservation) GetDeletedByID(ctx context.Context, id uuid.UUID) (*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "reservation.get_deleted_by_id")
+	defer done()
+
+	return r.get(ctx, "RESERVATION_GET_DELETED_BY_ID", RESERVATION_GET_DELETED_BY_ID, id, property)
+}
+
+func (r *reservation) HasOverlap(ctx context.Context, roomID uuid.UUID, start time.Time, end time.Time, exclude uuid.UUID) (bool, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return false, e
+	}
+
+	ctx, done := query(ctx, "reservation.has_overlap")
+	defer done()
+
+	var overlap bool
+	if err := r.db.QueryRowContext(ctx, RESERVATION_HAS_OVERLAP, roomID, start.UTC(), end.UTC(), exclude, property).Scan(&overlap); err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_HAS_OVERLAP failed", err)
+		return false, dbError(ctx, "Failed to check reservation dates")
+	}
+
+	return overlap, nil
+}
+
+func (r *reservation) MoveFuture(ctx context.Context, from uuid.UUID, to uuid.UUID, since time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	ctx, done := query(ctx, "reservation.move_future")
+	defer done()
+
+	if e := r.checkRoom(ctx, to, property); e != nil {
+		return 0, e
+	}
+
+	res, err := r.db.ExecContext(ctx, RESERVATION_MOVE_FUTURE, from, to, since.UTC(), property)
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_MOVE_FUTURE failed", err)
+		return 0, dbError(ctx, "Failed to move reservations")
+	}
+
+	moved, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_MOVE_FUTURE RowsAffected failed", err)
+		return 0, dbError(ctx, "Failed to move reservations")
+	}
+
+	return moved, nil
+}
+
+func (r *reservation) PurgeDeleted(ctx context.Context, before time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return 0, e
+	}
+
+	ctx, done := query(ctx, "reservation.purge_deleted")
+	defer done()
+
+	res, err := r.db.ExecContext(ctx, RESERVATION_PURGE_DELETED, before.UTC(), property)
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_PURGE_DELETED failed", err)
+		return 0, dbError(ctx, "Failed to purge deleted reservations")
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_PURGE_DELETED RowsAffected failed", err)
+		return 0, dbError(ctx, "Failed to purge deleted reservations")
+	}
+
+	return count, nil
+}
+
+func (r *reservation) Restore(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "reservation.restore")
+	defer done()
+
+	res, err := r.db.ExecContext(ctx, RESERVATION_RESTORE, time.Now().UTC(), id, property)
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_RESTORE failed", err)
+		return dbError(ctx, "Failed to restore reservation")
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_RESTORE RowsAffected failed", err)
+		return dbError(ctx, "Failed to restore reservation")
+	}
+	if count == 0 {
+		return errs.NewError("Deleted reservation not found", 404, "Not Found", nil)
+	}
+
+	return nil
+}
+
+func (r *reservation) Update(ctx context.Context, reservation *model.Reservation) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "reservation.update")
+	defer done()
+
+	if e := r.checkRoom(ctx, reservation.RoomID, property); e != nil {
+		return e
+	}
+
+	updated := time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, RESERVATION_UPDATE, reservation.StartDate.UTC(), reservation.EndDate.UTC(), reservation.RoomID, reservation.Adults, reservation.Children, updated, reservation.Id, reservation.Version, property).Scan(&reservation.Version, &reservation.Status)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			if _, e := r.GetByID(ctx, reservation.Id); e != nil {
+				return e
+			}
+			logger.FromContext(ctx).Tracef("RESERVATION_UPDATE %s version %d is stale", reservation.Id, reservation.Version)
+			return errs.NewError("Reservation was modified by another request", 412, "Precondition Failed", nil)
+		}
+		logger.FromContext(ctx).Error("RESERVATION_UPDATE failed", err)
+		return dbError(ctx, "Failed to update reservation")
+	}
+	reservation.PropertyID = property
+	reservation.Updated = updated
+
+	return nil
+}
+
+// checkRoom reports a room of another property as not found, so a
+// reservation never points at a room outside its property.
+func (r *reservation) checkRoom(ctx context.Context, roomID uuid.UUID, property uuid.UUID) *errs.Error {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, RESERVATION_ROOM_IN_PROPERTY, roomID, property).Scan(&exists); err != nil {
+		logger.FromContext(ctx).Error("RESERVATION_ROOM_IN_PROPERTY failed", err)
+		return dbError(ctx, "Failed to check room")
+	}
+	if !exists {
+		logger.FromContext(ctx).Tracef("RESERVATION_ROOM_IN_PROPERTY room %s not found", roomID)
+		return errs.NewError("room not found", 404, "Not Found", nil)
+	}
+
+	return nil
+}
+
+func (r *reservation) get(ctx context.Context, name string, query string, id uuid.UUID, property uuid.UUID) (*model.Reservation, *errs.Error) {
+	reservation := &model.Reservation{}
+
+	err := scanReservation(r.db.QueryRowContext(ctx, query, id, property), reservation)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("%s %s not found", name, id)
+			return nil, errs.NewError("Reservation not found", 404, "Not Found", nil)
+		}
+		logger.FromContext(ctx).Errorf("%s failed: %v", name, err)
+		return nil, dbError(ctx, "Failed to get reservation")
+	}
+
+	return reservation, nil
+}
+
+func (r *reservation) list(ctx context.Context, name string, query string, args ...interface{}) ([]*model.Reservation, *errs.Error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		logger.FromContext(ctx).Error(name+" failed", err)
+		return nil, dbError(ctx, "Failed to find reservations")
+	}
+	defer rows.Close()
+
+	reservations := []*model.Reservation{}
+	for rows.Next() {
+		reservation := &model.Reservation{}
+
+		if err := scanReservation(rows, reservation); err != nil {
+			logger.FromContext(ctx).Error(name+" rows.Scan failed", err)
+			return nil, dbError(ctx, "Failed to scan reservations")
+		}
+
+		reservations = append(reservations, reservation)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.FromContext(ctx).Error(name+" rows.Err not nil", err)
+		return nil, dbError(ctx, "Failed to find reservations")
+	}
+
+	return reservations, nil
+}
+
+func scanReservation(row scanner, reservation *model.Reservation) error {
+	return row.Scan(&reservation.Id,
+		&reservation.PropertyID,
+		&reservation.UserId,
+		&reservation.StartDate,
+		&reservation.EndDate,
+		&reservation.RoomID,
+		&reservation.Status,
+		&reservation.Adults,
+		&reservation.Children,
+		&reservation.Created,
+		&reservation.Updated,
+		&reservation.Deleted,
+		&reservation.DeletedAt,
+		&reservation.Version)
+}
@@ -0,0 +1,350 @@
+package sqlite
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+const (
+	ROOM_CREATE         = "INSERT INTO rooms (id, property_id, name, max_adults, max_children, max_occupancy, created, updated, version) VALUES (?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9)"
+	ROOMS_FIND          = "SELECT id, property_id, name, max_adults, max_children, max_occupancy, created, updated, archived, version FROM rooms WHERE property_id = ?1 AND archived IS NULL ORDER BY name ASC"
+	ROOMS_FIND_AVAILABE = `
+	select
+			r.id, r.property_id, r.name, r.max_adults, r.max_children, r.max_occupancy, r.created, r.updated, r.archived, r.version
+		from
+			rooms r
+		where r.property_id = ?8
+		and r.archived is null
+		and (r.max_adults = 0 or r.max_adults >= ?3)
+		and (r.max_children = 0 or r.max_children >= ?4)
+		and (r.max_occupancy = 0 or r.max_occupancy >= ?5)
+		and r.id not in
+		(select room_id from reservations rr where rr.deleted = 0 and ?1 < rr.end_date and ?2 > rr.start_date)
+		and not exists (
+			select 1 from json_each(?6) f
+			where not exists (` + ROOM_AMENITY_MATCHES + `))
+		order by (
+			select count(*) from json_each(?7) f
+			where exists (` + ROOM_AMENITY_MATCHES + `)) desc, r.name asc;
+	`
+	// ROOM_AMENITY_MATCHES finds the amenity of room r that passes filter f,
+	// through the primary key of room_amenities.
+	ROOM_AMENITY_MATCHES = `
+		select 1 from room_amenities ra
+			where ra.room_id = r.id and ra.amenity_id = json_extract(f.value, '$.AmenityID')
+			and (json_extract(f.value, '$.Value') is null or ra.value = json_extract(f.value, '$.Value'))
+			and (json_extract(f.value, '$.Min') is null or ra.number >= json_extract(f.value, '$.Min'))
+			and (json_extract(f.value, '$.Max') is null or ra.number <= json_extract(f.value, '$.Max'))`
+	ROOM_GET_BY_ID                = "SELECT id, property_id, name, max_adults, max_children, max_occupancy, created, updated, archived, version FROM rooms WHERE id = ?1 AND property_id = ?2"
+	ROOM_CHECK_IF_AVAILABLE_BY_ID = `
+	select
+			r.id, r.property_id, r.name, r.max_adults, r.max_children, r.max_occupancy, r.created, r.updated, r.archived, r.version
+		from
+			rooms r
+		where r.id = ?1
+		and r.property_id = ?4
+		and r.archived is null
+		and r.id not in (select room_id from reservations rr where rr.deleted = 0 and ?2 < rr.end_date and ?3 > rr.start_date);
+	`
+	ROOM_UPDATE = "UPDATE rooms SET name=?1, max_adults=?2, max_children=?3, max_occupancy=?4, updated=?5, version=version+1 WHERE id=?6 AND property_id=?8 AND (?7 = 0 OR version=?7) RETURNING version"
+
+	ROOM_LOCK_ACTIVE = "SELECT id FROM rooms WHERE id = ?1 AND property_id = ?2 AND archived IS NULL"
+	ROOM_ARCHIVE     = "UPDATE rooms SET archived=?1, updated=?1, version=version+1 WHERE id=?2 AND property_id=?3 AND archived IS NULL"
+)
+
+type RoomRepo interface {
+	CreateTableRooms(context.Context) string
+
+	Add(context.Context, *model.Room) *errs.Error
+	Archive(context.Context, uuid.UUID) *errs.Error
+	Find(context.Context) ([]*model.Room, *errs.Error)
+	FindAvailable(context.Context, model.RoomSearch) ([]*model.Room, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Room, *errs.Error)
+	CheckIfAvailableById(context.Context, uuid.UUID, time.Time, time.Time) (bool, *errs.Error)
+	LockActive(context.Context, uuid.UUID) *errs.Error
+	Update(context.Context, *model.Room) *errs.Error
+}
+
+type room struct {
+	db sqlclient.SqlClient
+}
+
+func NewRoom(db sqlclient.SqlClient) RoomRepo {
+	return &room{
+		db: db,
+	}
+}
+
+func (r *room) CreateTableRooms(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return migrate(ctx, r.db, "rooms")
+}
+
+func (r *room) Add(ctx context.Context, room *model.Room) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "room.add")
+	defer done()
+
+	created := time.Now().UTC()
+	updated := created
+	room.PropertyID = property
+	room.Version = 1
+	_, err := r.db.ExecContext(ctx, ROOM_CREATE, &room.Id, property, &room.Name, room.MaxAdults, room.MaxChildren, room.MaxOccupancy, created, updated, room.Version)
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOM_CREATE failed", err)
+		return dbError(ctx, "Failed to create room")
+	}
+
+	return nil
+}
+
+func (r *room) Archive(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "room.archive")
+	defer done()
+
+	now := time.Now().UTC()
+	res, err := r.db.ExecContext(ctx, ROOM_ARCHIVE, now, id, property)
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOM_ARCHIVE failed", err)
+		return dbError(ctx, "Failed to archive room")
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOM_ARCHIVE RowsAffected failed", err)
+		return dbError(ctx, "Failed to archive room")
+	}
+	if affected == 0 {
+		logger.FromContext(ctx).Tracef("ROOM_ARCHIVE room %s not found", id)
+		return errs.NewError("room not found", 404, "Not Found", nil)
+	}
+
+	return nil
+}
+
+func (r *room) Find(ctx context.Context) ([]*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "room.find")
+	defer done()
+
+	rows, err := r.db.QueryContext(ctx, ROOMS_FIND, property)
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND failed", err)
+		return nil, dbError(ctx, "Failed to find rooms")
+	}
+	defer rows.Close()
+
+	rooms := []*model.Room{}
+	for rows.Next() {
+		room := &model.Room{}
+
+		err := scanRoom(rows, room)
+		if err != nil {
+			logger.FromContext(ctx).Error("ROOMS_FIND rows.Scan failed", err)
+			return nil, dbError(ctx, "Failed to scan rooms")
+		}
+
+		rooms = append(rooms, room)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND rows.Err not nil", err)
+		return nil, dbError(ctx, "Failed to find rooms")
+	}
+
+	return rooms, nil
+}
+
+func (r *room) FindAvailable(ctx context.Context, search model.RoomSearch) ([]*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "room.find_available")
+	defer done()
+
+	required, err := json.Marshal(filters(search.Required))
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE json.Marshal failed", err)
+		return nil, dbError(ctx, "Failed to find available rooms")
+	}
+	preferred, err := json.Marshal(filters(search.Preferred))
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE json.Marshal failed", err)
+		return nil, dbError(ctx, "Failed to find available rooms")
+	}
+
+	occupancy := search.Occupancy
+	rows, err := r.db.QueryContext(ctx, ROOMS_FIND_AVAILABE, search.Start.UTC(), search.End.UTC(), occupancy.Adults, occupancy.Children, occupancy.Total(), string(required), string(preferred), property)
+	if err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE failed", err)
+		return nil, dbError(ctx, "Failed to find available rooms")
+	}
+	defer rows.Close()
+
+	rooms := []*model.Room{}
+	for rows.Next() {
+		room := &model.Room{}
+
+		err := scanRoom(rows, room)
+		if err != nil {
+			logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE rows.Scan failed", err)
+			return nil, dbError(ctx, "Failed to scan available rooms")
+		}
+
+		rooms = append(rooms, room)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.FromContext(ctx).Error("ROOMS_FIND_AVAILABE rows.Err not nil", err)
+		return nil, dbError(ctx, "Failed to find available rooms")
+	}
+
+	return rooms, nil
+}
+
+func (r *room) GetByID(ctx context.Context, id uuid.UUID) (*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return nil, e
+	}
+
+	ctx, done := query(ctx, "room.get_by_id")
+	defer done()
+
+	row := r.db.QueryRowContext(ctx, ROOM_GET_BY_ID, id, property)
+	room := &model.Room{}
+
+	err := scanRoom(row, room)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("ROOM_GET_BY_ID room %s not found", id)
+			return nil, errs.NewError("room not found", 404, "Not Found", nil)
+		}
+		logger.FromContext(ctx).Errorf("ROOM_GET_BY_ID failed: %v", err)
+		return nil, dbError(ctx, "Failed to get room")
+	}
+
+	return room, nil
+}
+
+func (r *room) CheckIfAvailableById(ctx context.Context, id uuid.UUID, start time.Time, end time.Time) (bool, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return false, e
+	}
+
+	ctx, done := query(ctx, "room.check_if_available_by_id")
+	defer done()
+
+	row := r.db.QueryRowContext(ctx, ROOM_CHECK_IF_AVAILABLE_BY_ID, id, start.UTC(), end.UTC(), property)
+	room := &model.Room{}
+
+	err := scanRoom(row, room)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("ROOM_CHECK_IF_AVAILABLE_BY_ID %s not found", id)
+			return false, nil
+		}
+		logger.FromContext(ctx).Errorf("ROOM_CHECK_IF_AVAILABLE_BY_ID failed: %v", err)
+		return false, dbError(ctx, "Failed to check if room is available")
+	}
+
+	return true, nil
+}
+
+func (r *room) LockActive(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "room.lock_active")
+	defer done()
+
+	var locked uuid.UUID
+	err := r.db.QueryRowContext(ctx, ROOM_LOCK_ACTIVE, id, property).Scan(&locked)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			logger.FromContext(ctx).Tracef("ROOM_LOCK_ACTIVE room %s not found", id)
+			return errs.NewError("room not found", 404, "Not Found", nil)
+		}
+		logger.FromContext(ctx).Errorf("ROOM_LOCK_ACTIVE failed: %v", err)
+		return dbError(ctx, "Failed to lock room")
+	}
+
+	return nil
+}
+
+func (r *room) Update(ctx context.Context, room *model.Room) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	property, e := tenant.Require(ctx)
+	if e != nil {
+		return e
+	}
+
+	ctx, done := query(ctx, "room.update")
+	defer done()
+
+	updated := time.Now().UTC()
+	err := r.db.QueryRowContext(ctx, ROOM_UPDATE, room.Name, room.MaxAdults, room.MaxChildren, room.MaxOccupancy, updated, room.Id, room.Version, property).Scan(&room.Version)
+	if err != nil {
+		if strings.Contains(err.Error(), "sql: no rows in result set") {
+			if _, e := r.GetByID(ctx, room.Id); e != nil {
+				return e
+			}
+			logger.FromContext(ctx).Tracef("ROOM_UPDATE room %s version %d is stale", room.Id, room.Version)
+			return errs.NewError("room was modified by another request", 412, "Precondition Failed", nil)
+		}
+		logger.FromContext(ctx).Error("ROOM_UPDATE failed", err)
+		return dbError(ctx, "Failed to update room")
+	}
+	room.PropertyID = property
+	room.Updated = updated
+
+	return nil
+}
+
+func scanRoom(row scanner, room *model.Room) error {
+	return row.Scan(&room.Id, &room.PropertyID, &room.Name, &room.MaxAdults, &room.MaxChildren, &room.MaxOccupancy, &room.Created, &room.Updated, &room.Archived, &room.Version)
+}
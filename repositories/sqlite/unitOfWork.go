@@ -0,0 +1,64 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	sqlclient "github.com/demkowo/booking/utils/sql-client"
+	"github.com/demkowo/booking/utils/tracing"
+)
+
+// UnitOfWork runs a function inside a single database transaction. Repository
+// calls made with the context passed to the function join that transaction.
+type UnitOfWork interface {
+	Do(context.Context, func(context.Context) *errs.Error) *errs.Error
+}
+
+type unitOfWork struct {
+	db sqlclient.SqlClient
+}
+
+func NewUnitOfWork(db sqlclient.SqlClient) UnitOfWork {
+	return &unitOfWork{
+		db: db,
+	}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(context.Context) *errs.Error) (e *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	if _, ok := sqlclient.TxFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.Start(ctx, "transaction")
+	defer span.End()
+
+	tx, err := u.db.BeginTx(ctx, nil)
+	if err != nil {
+		logger.FromContext(ctx).Error("BEGIN failed", err)
+		return dbError(ctx, "Failed to start transaction")
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			if err := tx.Rollback(); err != nil {
+				logger.FromContext(ctx).Error("ROLLBACK failed", err)
+			}
+		}
+	}()
+
+	if e = fn(sqlclient.WithTx(ctx, tx)); e != nil {
+		return e
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.FromContext(ctx).Error("COMMIT failed", err)
+		return dbError(ctx, "Failed to commit transaction")
+	}
+	committed = true
+
+	return nil
+}
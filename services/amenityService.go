@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/tracing"
+)
+
+type AmenityRepo interface {
+	CreateTableAmenities(context.Context) string
+
+	Add(context.Context, *model.Amenity) *errs.Error
+	Delete(context.Context, uuid.UUID) *errs.Error
+	Find(context.Context) ([]*model.Amenity, *errs.Error)
+	FindByRoomIDs(context.Context, []uuid.UUID) (map[uuid.UUID][]model.RoomAmenity, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Amenity, *errs.Error)
+	SetRoomAmenities(context.Context, uuid.UUID, []model.RoomAmenity) *errs.Error
+	Update(context.Context, *model.Amenity) *errs.Error
+}
+
+type Amenity interface {
+	CreateTableAmenities(context.Context) string
+
+	Add(context.Context, *model.Amenity) *errs.Error
+	Delete(context.Context, uuid.UUID) *errs.Error
+	Find(context.Context) ([]*model.Amenity, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Amenity, *errs.Error)
+	Update(context.Context, *model.Amenity) *errs.Error
+}
+
+type amenity struct {
+	repo AmenityRepo
+	uow  UnitOfWork
+}
+
+func NewAmenity(repo AmenityRepo, uow UnitOfWork) Amenity {
+	log.Trace()
+
+	return &amenity{
+		repo: repo,
+		uow:  uow,
+	}
+}
+
+func (s *amenity) CreateTableAmenities(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return s.repo.CreateTableAmenities(ctx)
+}
+
+func (s *amenity) Add(ctx context.Context, amenity *model.Amenity) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Amenity.Add")
+	defer span.End()
+
+	if err := checkValues(amenity); err != nil {
+		return err
+	}
+
+	return s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		return s.repo.Add(ctx, amenity)
+	})
+}
+
+// Delete removes the amenity from the catalog and from the rooms that have
+// it.
+func (s *amenity) Delete(ctx context.Context, id uuid.UUID) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Amenity.Delete")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		return s.repo.Delete(ctx, id)
+	})
+}
+
+func (s *amenity) Find(ctx context.Context) ([]*model.Amenity, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Amenity.Find")
+	defer span.End()
+
+	return s.repo.Find(ctx)
+}
+
+func (s *amenity) GetByID(ctx context.Context, id uuid.UUID) (*model.Amenity, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Amenity.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+// Update renames the amenity and changes its enum values. Key and type
+// can't change, and values can only be added since rooms may use them.
+func (s *amenity) Update(ctx context.Context, amenity *model.Amenity) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Amenity.Update")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		stored, err := s.repo.GetByID(ctx, amenity.Id)
+		if err != nil {
+			return err
+		}
+
+		amenity.Key = stored.Key
+		amenity.Type = stored.Type
+		amenity.Created = stored.Created
+		if err := checkValues(amenity); err != nil {
+			return err
+		}
+		for _, value := range stored.Values {
+			if !slices.Contains(amenity.Values, value) {
+				return errs.NewUnprocessableError("Invalid input", errs.Cause{Field: "values", Message: "must keep " + value + ", values can only be added"})
+			}
+		}
+
+		return s.repo.Update(ctx, amenity)
+	})
+}
+
+// checkValues reports enum amenities without values, values of other types
+// and values listed twice.
+func checkValues(amenity *model.Amenity) *errs.Error {
+	switch {
+	case amenity.Type == model.AMENITY_ENUM && len(amenity.Values) == 0:
+		return errs.NewUnprocessableError("Invalid input", errs.Cause{Field: "values", Message: "is required for enum amenities"})
+	case amenity.Type != model.AMENITY_ENUM && len(amenity.Values) > 0:
+		return errs.NewUnprocessableError("Invalid input", errs.Cause{Field: "values", Message: "only applies to enum amenities"})
+	}
+
+	for i, value := range amenity.Values {
+		if slices.Contains(amenity.Values[:i], value) {
+			return errs.NewUnprocessableError("Invalid input", errs.Cause{Field: "values", Message: "must not list " + value + " twice"})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/tracing"
+)
+
+type IdempotencyRepo interface {
+	CreateTableIdempotencyKeys(context.Context) string
+
+	Complete(context.Context, *model.IdempotencyKey) *errs.Error
+	DeleteExpired(context.Context, string, time.Time) *errs.Error
+	Get(context.Context, string) (*model.IdempotencyKey, *errs.Error)
+	Release(context.Context, string) *errs.Error
+	Reserve(context.Context, *model.IdempotencyKey) (bool, *errs.Error)
+}
+
+type Idempotency interface {
+	CreateTableIdempotencyKeys(context.Context) string
+
+	Begin(context.Context, string, string) (*model.IdempotencyKey, *errs.Error)
+	Complete(context.Context, *model.IdempotencyKey) *errs.Error
+	Release(context.Context, string) *errs.Error
+}
+
+type idempotency struct {
+	repo IdempotencyRepo
+	ttl  time.Duration
+}
+
+func NewIdempotency(repo IdempotencyRepo, ttl time.Duration) Idempotency {
+	log.Trace()
+
+	return &idempotency{
+		repo: repo,
+		ttl:  ttl,
+	}
+}
+
+func (s *idempotency) CreateTableIdempotencyKeys(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return s.repo.CreateTableIdempotencyKeys(ctx)
+}
+
+// Begin reserves the key for a new request. It returns nil when the caller
+// owns the key and should process the request, or the stored record when
+// the key has been used before.
+func (s *idempotency) Begin(ctx context.Context, key string, requestHash string) (*model.IdempotencyKey, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Idempotency.Begin")
+	defer span.End()
+
+	now := time.Now()
+	if err := s.repo.DeleteExpired(ctx, key, now.Add(-s.ttl)); err != nil {
+		return nil, err
+	}
+
+	reserved, err := s.repo.Reserve(ctx, &model.IdempotencyKey{
+		Key:         key,
+		RequestHash: requestHash,
+		Created:     now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+
+	record, err := s.repo.Get(ctx, key)
+	if err != nil {
+		if err.Code == 404 {
+			return nil, errs.NewError("Request with this Idempotency-Key is in progress", 409, "Conflict", nil)
+		}
+		return nil, err
+	}
+
+	return record, nil
+}
+
+func (s *idempotency) Complete(ctx context.Context, key *model.IdempotencyKey) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Idempotency.Complete")
+	defer span.End()
+
+	return s.repo.Complete(ctx, key)
+}
+
+func (s *idempotency) Release(ctx context.Context, key string) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Idempotency.Release")
+	defer span.End()
+
+	return s.repo.Release(ctx, key)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/tracing"
+)
+
+type PropertyRepo interface {
+	CreateTableProperties(context.Context) string
+
+	Add(context.Context, *model.Property) *errs.Error
+	Find(context.Context) ([]*model.Property, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Property, *errs.Error)
+	Update(context.Context, *model.Property) *errs.Error
+}
+
+type Property interface {
+	CreateTableProperties(context.Context) string
+
+	Add(context.Context, *model.Property) *errs.Error
+	Find(context.Context) ([]*model.Property, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Property, *errs.Error)
+	Update(context.Context, *model.Property) *errs.Error
+}
+
+type property struct {
+	repo PropertyRepo
+	uow  UnitOfWork
+}
+
+func NewProperty(repo PropertyRepo, uow UnitOfWork) Property {
+	log.Trace()
+
+	return &property{
+		repo: repo,
+		uow:  uow,
+	}
+}
+
+func (s *property) CreateTableProperties(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return s.repo.CreateTableProperties(ctx)
+}
+
+func (s *property) Add(ctx context.Context, property *model.Property) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Property.Add")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		return s.repo.Add(ctx, property)
+	})
+}
+
+func (s *property) Find(ctx context.Context) ([]*model.Property, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Property.Find")
+	defer span.End()
+
+	return s.repo.Find(ctx)
+}
+
+func (s *property) GetByID(ctx context.Context, id uuid.UUID) (*model.Property, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Property.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *property) Update(ctx context.Context, property *model.Property) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Property.Update")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		stored, err := s.repo.GetByID(ctx, property.Id)
+		if err != nil {
+			return err
+		}
+
+		property.Created = stored.Created
+		return s.repo.Update(ctx, property)
+	})
+}
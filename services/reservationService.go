@@ -1,103 +1,268 @@
-package service
-
-import (
-	"github.com/google/uuid"
-	log "github.com/sirupsen/logrus"
-
-	model "github.com/demkowo/booking/models"
-	"github.com/demkowo/booking/utils/errs"
-)
-
-type ReservationRepo interface {
-	CreateTableReservations() string
-
-	Add(*model.Reservation) *errs.Error
-	Delete(string) *errs.Error
-	Find() ([]*model.Reservation, *errs.Error)
-	FindByRoomID(uuid.UUID) ([]*model.Reservation, *errs.Error)
-	GetByID(uuid.UUID) (*model.Reservation, *errs.Error)
-	Update(*model.Reservation) *errs.Error
-}
-
-type Reservation interface {
-	CreateTableReservations() string
-
-	Add(*model.Reservation) *errs.Error
-	Delete(string) *errs.Error
-	Find() ([]*model.Reservation, *errs.Error)
-	FindByRoomID(uuid.UUID) ([]*model.Reservation, *errs.Error)
-	GetByID(uuid.UUID) (*model.Reservation, *errs.Error)
-	Update(*model.Reservation) *errs.Error
-}
-
-type reservation struct {
-	repo ReservationRepo
-}
-
-func NewReservation(repo ReservationRepo) Reservation {
-	log.Trace()
-
-	return &reservation{
-		repo: repo,
-	}
-}
-
-func (s *reservation) CreateTableReservations() string {
-	log.Trace()
-
-	return s.repo.CreateTableReservations()
-}
-
-func (s *reservation) Add(reservation *model.Reservation) *errs.Error {
-	log.Trace()
-
-	if err := s.repo.Add(reservation); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *reservation) Delete(id string) *errs.Error {
-	log.Trace()
-
-	if id == "" {
-		return errs.NewError("ID is required", 400, "Bad Request", nil)
-	}
-
-	return s.repo.Delete(id)
-}
-
-func (s *reservation) Find() ([]*model.Reservation, *errs.Error) {
-	log.Trace()
-
-	res, err := s.repo.Find()
-	if err != nil {
-		return nil, err
-	}
-
-	return res, nil
-}
-
-func (s *reservation) FindByRoomID(id uuid.UUID) ([]*model.Reservation, *errs.Error) {
-	log.Trace()
-
-	res, err := s.repo.FindByRoomID(id)
-	if err != nil {
-		return nil, err
-	}
-
-	return res, nil
-}
-
-func (s *reservation) GetByID(id uuid.UUID) (*model.Reservation, *errs.Error) {
-	log.Trace()
-
-	return s.repo.GetByID(id)
-}
-
-func (s *reservation) Update(reservation *model.Reservation) *errs.Error {
-	log.Trace()
-
-	return s.repo.Update(reservation)
-}
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/metrics"
+	"github.com/demkowo/booking/utils/tracing"
+)
+
+type ReservationRepo interface {
+	CreateTableReservations(context.Context) string
+
+	Add(context.Context, *model.Reservation) *errs.Error
+	AnonymizeDeleted(context.Context, time.Time) (int64, *errs.Error)
+	CountFutureByRoomID(context.Context, uuid.UUID, time.Time) (int64, *errs.Error)
+	CountMoveConflicts(context.Context, uuid.UUID, uuid.UUID, time.Time) (int64, *errs.Error)
+	Delete(context.Context, string) *errs.Error
+	Find(context.Context) ([]*model.Reservation, *errs.Error)
+	FindByRoomID(context.Context, uuid.UUID) ([]*model.Reservation, *errs.Error)
+	FindDeleted(context.Context) ([]*model.Reservation, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Reservation, *errs.Error)
+	GetDeletedByID(context.Context, uuid.UUID) (*model.Reservation, *errs.Error)
+	HasOverlap(context.Context, uuid.UUID, time.Time, time.Time, uuid.UUID) (bool, *errs.Error)
+	MoveFuture(context.Context, uuid.UUID, uuid.UUID, time.Time) (int64, *errs.Error)
+	PurgeDeleted(context.Context, time.Time) (int64, *errs.Error)
+	Restore(context.Context, uuid.UUID) *errs.Error
+	Update(context.Context, *model.Reservation) *errs.Error
+}
+
+type Reservation interface {
+	CreateTableReservations(context.Context) string
+
+	Add(context.Context, *model.Reservation) *errs.Error
+	AnonymizeDeleted(context.Context, time.Time) (int64, *errs.Error)
+	Delete(context.Context, string) *errs.Error
+	Find(context.Context) ([]*model.Reservation, *errs.Error)
+	FindByRoomID(context.Context, uuid.UUID) ([]*model.Reservation, *errs.Error)
+	FindDeleted(context.Context) ([]*model.Reservation, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Reservation, *errs.Error)
+	PurgeDeleted(context.Context, time.Time) (int64, *errs.Error)
+	Restore(context.Context, uuid.UUID) (*model.Reservation, *errs.Error)
+	Update(context.Context, *model.Reservation) *errs.Error
+}
+
+type reservation struct {
+	repo     ReservationRepo
+	roomRepo RoomRepo
+	uow      UnitOfWork
+}
+
+func NewReservation(repo ReservationRepo, roomRepo RoomRepo, uow UnitOfWork) Reservation {
+	log.Trace()
+
+	return &reservation{
+		repo:     repo,
+		roomRepo: roomRepo,
+		uow:      uow,
+	}
+}
+
+func (s *reservation) CreateTableReservations(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return s.repo.CreateTableReservations(ctx)
+}
+
+func (s *reservation) Add(ctx context.Context, reservation *model.Reservation) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.Add")
+	defer span.End()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		if err := s.checkRoom(ctx, reservation); err != nil {
+			return err
+		}
+
+		return s.repo.Add(ctx, reservation)
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.ReservationsCreated.WithLabelValues(model.StatusName(reservation.Status)).Inc()
+	return nil
+}
+
+func (s *reservation) AnonymizeDeleted(ctx context.Context, before time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.AnonymizeDeleted")
+	defer span.End()
+
+	return s.repo.AnonymizeDeleted(ctx, before)
+}
+
+func (s *reservation) Delete(ctx context.Context, id string) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.Delete")
+	defer span.End()
+
+	if id == "" {
+		return errs.NewError("ID is required", 400, "Bad Request", nil)
+	}
+
+	var status int
+	err := s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		reservationID, e := uuid.Parse(id)
+		if e != nil {
+			return errs.NewError("Reservation not found", 404, "Not Found", nil)
+		}
+
+		reservation, err := s.repo.GetByID(ctx, reservationID)
+		if err != nil {
+			return err
+		}
+		status = reservation.Status
+
+		return s.repo.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+
+	metrics.ReservationsCancelled.WithLabelValues(model.StatusName(status)).Inc()
+	return nil
+}
+
+func (s *reservation) Find(ctx context.Context) ([]*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.Find")
+	defer span.End()
+
+	res, err := s.repo.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *reservation) FindByRoomID(ctx context.Context, id uuid.UUID) ([]*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.FindByRoomID")
+	defer span.End()
+
+	res, err := s.repo.FindByRoomID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *reservation) FindDeleted(ctx context.Context) ([]*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.FindDeleted")
+	defer span.End()
+
+	return s.repo.FindDeleted(ctx)
+}
+
+func (s *reservation) GetByID(ctx context.Context, id uuid.UUID) (*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.GetByID")
+	defer span.End()
+
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *reservation) PurgeDeleted(ctx context.Context, before time.Time) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.PurgeDeleted")
+	defer span.End()
+
+	return s.repo.PurgeDeleted(ctx, before)
+}
+
+func (s *reservation) Restore(ctx context.Context, id uuid.UUID) (*model.Reservation, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.Restore")
+	defer span.End()
+
+	var reservation *model.Reservation
+	err := s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		var err *errs.Error
+		if reservation, err = s.repo.GetDeletedByID(ctx, id); err != nil {
+			return err
+		}
+
+		if err := s.checkRoom(ctx, reservation); err != nil {
+			if err.Code == 409 {
+				return errs.NewError("Room is no longer available for the reservation dates", 409, "Conflict", nil)
+			}
+			return err
+		}
+
+		return s.repo.Restore(ctx, id)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	reservation.Deleted = false
+	reservation.DeletedAt = nil
+	reservation.Version++
+	metrics.ReservationsRestored.WithLabelValues(model.StatusName(reservation.Status)).Inc()
+
+	return reservation, nil
+}
+
+func (s *reservation) Update(ctx context.Context, reservation *model.Reservation) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Reservation.Update")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		if err := s.checkRoom(ctx, reservation); err != nil {
+			return err
+		}
+
+		return s.repo.Update(ctx, reservation)
+	})
+}
+
+// checkRoom locks the reservation's room until the surrounding transaction
+// ends, so concurrent writers can't book overlapping dates. It also checks
+// the guests fit the room.
+func (s *reservation) checkRoom(ctx context.Context, reservation *model.Reservation) *errs.Error {
+	if err := s.roomRepo.LockActive(ctx, reservation.RoomID); err != nil {
+		return err
+	}
+
+	room, err := s.roomRepo.GetByID(ctx, reservation.RoomID)
+	if err != nil {
+		return err
+	}
+	if causes := room.Accommodates(reservation.Occupancy); len(causes) > 0 {
+		metrics.ConflictsRejected.WithLabelValues("over_capacity").Inc()
+		return errs.NewUnprocessableError("Occupancy exceeds the room capacity", causes...)
+	}
+
+	overlap, err := s.repo.HasOverlap(ctx, reservation.RoomID, reservation.StartDate, reservation.EndDate, reservation.Id)
+	if err != nil {
+		return err
+	}
+	if overlap {
+		metrics.ConflictsRejected.WithLabelValues("reservation_overlap").Inc()
+		return errs.NewError("Room is not available for the reservation dates", 409, "Conflict", nil)
+	}
+
+	return nil
+}
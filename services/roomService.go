@@ -1,101 +1,476 @@
-package service
-
-import (
-	"time"
-
-	"github.com/google/uuid"
-	log "github.com/sirupsen/logrus"
-
-	model "github.com/demkowo/booking/models"
-	"github.com/demkowo/booking/utils/errs"
-)
-
-type RoomRepo interface {
-	CreateTableRooms() string
-
-	Add(*model.Room) *errs.Error
-	Find() ([]*model.Room, *errs.Error)
-	FindAvailable(time.Time, time.Time) ([]*model.Room, *errs.Error)
-	GetByID(uuid.UUID) (*model.Room, *errs.Error)
-	CheckIfAvailableById(uuid.UUID, time.Time, time.Time) (bool, *errs.Error)
-	Update(*model.Room) *errs.Error
-}
-
-type Room interface {
-	CreateTableRooms() string
-
-	Add(*model.Room) *errs.Error
-	Find() ([]*model.Room, *errs.Error)
-	FindAvailable(time.Time, time.Time) ([]*model.Room, *errs.Error)
-	GetByID(uuid.UUID) (*model.Room, *errs.Error)
-	CheckIfAvailableById(uuid.UUID, time.Time, time.Time) (bool, *errs.Error)
-	Update(*model.Room) *errs.Error
-}
-
-type room struct {
-	repo RoomRepo
-}
-
-func NewRoom(repo RoomRepo) Room {
-	log.Trace()
-
-	return &room{
-		repo: repo,
-	}
-}
-
-func (s *room) CreateTableRooms() string {
-	log.Trace()
-
-	return s.repo.CreateTableRooms()
-}
-
-func (s *room) Add(room *model.Room) *errs.Error {
-	log.Trace()
-
-	if err := s.repo.Add(room); err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (s *room) Find() ([]*model.Room, *errs.Error) {
-	log.Trace()
-
-	rooms, err := s.repo.Find()
-	if err != nil {
-		return nil, err
-	}
-
-	return rooms, nil
-}
-
-func (s *room) FindAvailable(start time.Time, end time.Time) ([]*model.Room, *errs.Error) {
-	log.Trace()
-
-	rooms, err := s.repo.FindAvailable(start, end)
-	if err != nil {
-		return nil, err
-	}
-
-	return rooms, nil
-}
-
-func (s *room) GetByID(id uuid.UUID) (*model.Room, *errs.Error) {
-	log.Trace()
-
-	return s.repo.GetByID(id)
-}
-
-func (s *room) CheckIfAvailableById(id uuid.UUID, start time.Time, end time.Time) (bool, *errs.Error) {
-	log.Trace()
-
-	return s.repo.CheckIfAvailableById(id, start, end)
-}
-
-func (s *room) Update(room *model.Room) *errs.Error {
-	log.Trace()
-
-	return s.repo.Update(room)
-}
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/logger"
+	"github.com/demkowo/booking/utils/metrics"
+	"github.com/demkowo/booking/utils/tracing"
+)
+
+type RoomRepo interface {
+	CreateTableRooms(context.Context) string
+
+	Add(context.Context, *model.Room) *errs.Error
+	Archive(context.Context, uuid.UUID) *errs.Error
+	Find(context.Context) ([]*model.Room, *errs.Error)
+	FindAvailable(context.Context, model.RoomSearch) ([]*model.Room, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Room, *errs.Error)
+	CheckIfAvailableById(context.Context, uuid.UUID, time.Time, time.Time) (bool, *errs.Error)
+	LockActive(context.Context, uuid.UUID) *errs.Error
+	Update(context.Context, *model.Room) *errs.Error
+}
+
+type Room interface {
+	CreateTableRooms(context.Context) string
+
+	Add(context.Context, *model.Room) *errs.Error
+	Archive(context.Context, uuid.UUID, *uuid.UUID) (int64, *errs.Error)
+	Find(context.Context) ([]*model.Room, *errs.Error)
+	FindAvailable(context.Context, model.RoomSearch) ([]*model.Room, *errs.Error)
+	GetByID(context.Context, uuid.UUID) (*model.Room, *errs.Error)
+	CheckIfAvailableById(context.Context, uuid.UUID, time.Time, time.Time) (bool, *errs.Error)
+	MoveReservations(context.Context, uuid.UUID, uuid.UUID) (int64, *errs.Error)
+	SetAmenities(context.Context, uuid.UUID, []model.RoomAmenity) (*model.Room, *errs.Error)
+	Update(context.Context, *model.Room) *errs.Error
+}
+
+type room struct {
+	repo            RoomRepo
+	reservationRepo ReservationRepo
+	amenityRepo     AmenityRepo
+	uow             UnitOfWork
+}
+
+func NewRoom(repo RoomRepo, reservationRepo ReservationRepo, amenityRepo AmenityRepo, uow UnitOfWork) Room {
+	log.Trace()
+
+	return &room{
+		repo:            repo,
+		reservationRepo: reservationRepo,
+		amenityRepo:     amenityRepo,
+		uow:             uow,
+	}
+}
+
+func (s *room) CreateTableRooms(ctx context.Context) string {
+	logger.FromContext(ctx).Trace()
+
+	return s.repo.CreateTableRooms(ctx)
+}
+
+func (s *room) Add(ctx context.Context, room *model.Room) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Room.Add")
+	defer span.End()
+
+	return s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		return s.repo.Add(ctx, room)
+	})
+}
+
+func (s *room) Archive(ctx context.Context, id uuid.UUID, reassignTo *uuid.UUID) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Room.Archive")
+	defer span.End()
+
+	if reassignTo != nil && *reassignTo == id {
+		return 0, errs.NewError("Reservations can't be reassigned to the archived room", 400, "Bad Request", nil)
+	}
+
+	var moved int64
+	err := s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		if reassignTo != nil {
+			if err := s.lockBoth(ctx, id, *reassignTo); err != nil {
+				return err
+			}
+		} else if err := s.repo.LockActive(ctx, id); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if reassignTo != nil {
+			var err *errs.Error
+			if moved, err = s.moveReservations(ctx, id, *reassignTo, now); err != nil {
+				return err
+			}
+		} else {
+			count, err := s.reservationRepo.CountFutureByRoomID(ctx, id, now)
+			if err != nil {
+				return err
+			}
+			if count > 0 {
+				metrics.ConflictsRejected.WithLabelValues("room_has_future_reservations").Inc()
+				return errs.NewError("Room has future reservations, reassign them before archiving", 409, "Conflict", []interface{}{map[string]int64{"future_reservations": count}})
+			}
+		}
+
+		return s.repo.Archive(ctx, id)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
+
+func (s *room) Find(ctx context.Context) ([]*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Room.Find")
+	defer span.End()
+
+	rooms, err := s.repo.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.withAmenities(ctx, rooms...); err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+// FindAvailable looks up the amenities of the search filters by key, and
+// returns the matching rooms ranked by the preferred amenities they have.
+func (s *room) FindAvailable(ctx context.Context, search model.RoomSearch) ([]*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Room.FindAvailable")
+	defer span.End()
+
+	metrics.AvailabilitySearches.WithLabelValues("find_available").Inc()
+
+	if len(search.Required) > 0 || len(search.Preferred) > 0 {
+		catalog, err := s.catalog(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		causes := resolveFilters(catalog, search.Required, "amenities.must_have")
+		causes = append(causes, resolveFilters(catalog, search.Preferred, "amenities.nice_to_have")...)
+		if len(causes) > 0 {
+			return nil, errs.NewUnprocessableError("Invalid input", causes...)
+		}
+	}
+
+	rooms, err := s.repo.FindAvailable(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.withAmenities(ctx, rooms...); err != nil {
+		return nil, err
+	}
+
+	return rooms, nil
+}
+
+func (s *room) GetByID(ctx context.Context, id uuid.UUID) (*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Room.GetByID")
+	defer span.End()
+
+	room, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.withAmenities(ctx, room); err != nil {
+		return nil, err
+	}
+
+	return room, nil
+}
+
+func (s *room) CheckIfAvailableById(ctx context.Context, id uuid.UUID, start time.Time, end time.Time) (bool, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Room.CheckIfAvailableById")
+	defer span.End()
+
+	metrics.AvailabilitySearches.WithLabelValues("room_check").Inc()
+
+	return s.repo.CheckIfAvailableById(ctx, id, start, end)
+}
+
+func (s *room) MoveReservations(ctx context.Context, from uuid.UUID, to uuid.UUID) (int64, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Room.MoveReservations")
+	defer span.End()
+
+	if from == to {
+		return 0, errs.NewError("Source and target room must differ", 400, "Bad Request", nil)
+	}
+
+	var moved int64
+	err := s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		if err := s.lockBoth(ctx, from, to); err != nil {
+			return err
+		}
+
+		var err *errs.Error
+		moved, err = s.moveReservations(ctx, from, to, time.Now())
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return moved, nil
+}
+
+func (s *room) Update(ctx context.Context, room *model.Room) *errs.Error {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Room.Update")
+	defer span.End()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		if err := s.repo.LockActive(ctx, room.Id); err != nil {
+			return err
+		}
+
+		over, err := s.overCapacity(ctx, room.Id, room, time.Now())
+		if err != nil {
+			return err
+		}
+		if over > 0 {
+			metrics.ConflictsRejected.WithLabelValues("over_capacity").Inc()
+			return errs.NewError("Room capacity is too small for its future reservations", 409, "Conflict", []interface{}{map[string]int64{"over_capacity": over}})
+		}
+
+		return s.repo.Update(ctx, room)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.withAmenities(ctx, room)
+}
+
+// SetAmenities replaces the amenities of a room. They are looked up by key
+// and their values have to fit the amenity types.
+func (s *room) SetAmenities(ctx context.Context, id uuid.UUID, amenities []model.RoomAmenity) (*model.Room, *errs.Error) {
+	logger.FromContext(ctx).Trace()
+
+	ctx, span := tracing.Start(ctx, "Room.SetAmenities")
+	defer span.End()
+
+	err := s.uow.Do(ctx, func(ctx context.Context) *errs.Error {
+		if err := s.repo.LockActive(ctx, id); err != nil {
+			return err
+		}
+
+		catalog, err := s.catalog(ctx)
+		if err != nil {
+			return err
+		}
+
+		causes := []errs.Cause{}
+		for i := range amenities {
+			field := fmt.Sprintf("amenities[%d]", i)
+
+			a, exist := catalog[amenities[i].Key]
+			if !exist {
+				causes = append(causes, errs.Cause{Field: field + ".key", Message: "is not an amenity"})
+				continue
+			}
+			for _, previous := range amenities[:i] {
+				if previous.Key == a.Key {
+					causes = append(causes, errs.Cause{Field: field + ".key", Message: "is listed more than once"})
+				}
+			}
+
+			value, valid := a.Parse(amenities[i].Value)
+			if !valid {
+				causes = append(causes, errs.Cause{Field: field + ".value", Message: valueMessage(a)})
+				continue
+			}
+			amenities[i] = model.RoomAmenity{AmenityID: a.Id, Key: a.Key, Type: a.Type, Value: value}
+		}
+		if len(causes) > 0 {
+			return errs.NewUnprocessableError("Invalid input", causes...)
+		}
+
+		return s.amenityRepo.SetRoomAmenities(ctx, id, amenities)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetByID(ctx, id)
+}
+
+// lockBoth locks the source and the target room of a move. The room with the
+// smaller id is always locked first, so moves in opposite directions can't
+// deadlock.
+func (s *room) lockBoth(ctx context.Context, from uuid.UUID, to uuid.UUID) *errs.Error {
+	lockTarget := func() *errs.Error {
+		if err := s.repo.LockActive(ctx, to); err != nil {
+			if err.Code == 404 {
+				return errs.NewError("target room not found", 404, "Not Found", nil)
+			}
+			return err
+		}
+		return nil
+	}
+
+	if bytes.Compare(to[:], from[:]) < 0 {
+		if err := lockTarget(); err != nil {
+			return err
+		}
+		return s.repo.LockActive(ctx, from)
+	}
+
+	if err := s.repo.LockActive(ctx, from); err != nil {
+		return err
+	}
+	return lockTarget()
+}
+
+// moveReservations expects both rooms to be locked by lockBoth.
+func (s *room) moveReservations(ctx context.Context, from uuid.UUID, to uuid.UUID, now time.Time) (int64, *errs.Error) {
+	target, err := s.repo.GetByID(ctx, to)
+	if err != nil {
+		return 0, err
+	}
+	over, err := s.overCapacity(ctx, from, target, now)
+	if err != nil {
+		return 0, err
+	}
+	if over > 0 {
+		metrics.ConflictsRejected.WithLabelValues("over_capacity").Inc()
+		return 0, errs.NewError("Target room can't accommodate all future reservations", 409, "Conflict", []interface{}{map[string]int64{"over_capacity": over}})
+	}
+
+	conflicts, err := s.reservationRepo.CountMoveConflicts(ctx, from, to, now)
+	if err != nil {
+		return 0, err
+	}
+	if conflicts > 0 {
+		metrics.ConflictsRejected.WithLabelValues("move_conflict").Inc()
+		return 0, errs.NewError("Target room is not available for all future reservations", 409, "Conflict", []interface{}{map[string]int64{"conflicts": conflicts}})
+	}
+
+	return s.reservationRepo.MoveFuture(ctx, from, to, now)
+}
+
+// overCapacity counts the future reservations of roomID whose guests don't
+// fit room.
+func (s *room) overCapacity(ctx context.Context, roomID uuid.UUID, room *model.Room, now time.Time) (int64, *errs.Error) {
+	reservations, err := s.reservationRepo.FindByRoomID(ctx, roomID)
+	if err != nil {
+		return 0, err
+	}
+
+	var over int64
+	for _, reservation := range reservations {
+		if reservation.EndDate.After(now) && len(room.Accommodates(reservation.Occupancy)) > 0 {
+			over++
+		}
+	}
+
+	return over, nil
+}
+
+// catalog returns the amenities by key.
+func (s *room) catalog(ctx context.Context) (map[string]*model.Amenity, *errs.Error) {
+	amenities, err := s.amenityRepo.Find(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog := make(map[string]*model.Amenity, len(amenities))
+	for _, a := range amenities {
+		catalog[a.Key] = a
+	}
+
+	return catalog, nil
+}
+
+// withAmenities loads the amenities of the rooms.
+func (s *room) withAmenities(ctx context.Context, rooms ...*model.Room) *errs.Error {
+	ids := make([]uuid.UUID, 0, len(rooms))
+	for _, room := range rooms {
+		ids = append(ids, room.Id)
+	}
+
+	amenities, err := s.amenityRepo.FindByRoomIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, room := range rooms {
+		room.Amenities = amenities[room.Id]
+		if room.Amenities == nil {
+			room.Amenities = []model.RoomAmenity{}
+		}
+	}
+
+	return nil
+}
+
+// resolveFilters looks up the amenity of every filter by key and puts its
+// value in the stored form. A boolean filter without a value matches true.
+func resolveFilters(catalog map[string]*model.Amenity, filters []model.AmenityFilter, field string) []errs.Cause {
+	causes := []errs.Cause{}
+	for i := range filters {
+		filter := &filters[i]
+		prefix := fmt.Sprintf("%s[%d]", field, i)
+
+		a, exist := catalog[filter.Key]
+		if !exist {
+			causes = append(causes, errs.Cause{Field: prefix + ".key", Message: "is not an amenity"})
+			continue
+		}
+		filter.AmenityID = a.Id
+
+		if a.Type != model.AMENITY_NUMBER && filter.Min != nil {
+			causes = append(causes, errs.Cause{Field: prefix + ".min", Message: "only applies to number amenities"})
+		}
+		if a.Type != model.AMENITY_NUMBER && filter.Max != nil {
+			causes = append(causes, errs.Cause{Field: prefix + ".max", Message: "only applies to number amenities"})
+		}
+		if filter.Min != nil && filter.Max != nil && *filter.Max < *filter.Min {
+			causes = append(causes, errs.Cause{Field: prefix + ".max", Message: "must be at least min"})
+		}
+
+		if filter.Value == nil && a.Type == model.AMENITY_BOOLEAN {
+			value := "true"
+			filter.Value = &value
+		}
+		if filter.Value != nil {
+			value, valid := a.Parse(*filter.Value)
+			if !valid {
+				causes = append(causes, errs.Cause{Field: prefix + ".value", Message: valueMessage(a)})
+				continue
+			}
+			filter.Value = &value
+		}
+	}
+
+	return causes
+}
+
+func valueMessage(a *model.Amenity) string {
+	switch a.Type {
+	case model.AMENITY_BOOLEAN:
+		return "must be a boolean"
+	case model.AMENITY_NUMBER:
+		return "must be a number"
+	default:
+		return "must be one of " + strings.Join(a.Values, ", ")
+	}
+}
@@ -0,0 +1,69 @@
+package service_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/repositories/memory"
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+// lockRecorder records the rooms locked by the service.
+type lockRecorder struct {
+	service.RoomRepo
+	locked []uuid.UUID
+}
+
+func (r *lockRecorder) LockActive(ctx context.Context, id uuid.UUID) *errs.Error {
+	r.locked = append(r.locked, id)
+	return r.RoomRepo.LockActive(ctx, id)
+}
+
+func TestRoomMovesLockInIdOrder(t *testing.T) {
+	ctx := tenant.WithProperty(context.Background(), model.DEFAULT_PROPERTY)
+	store := memory.NewStore()
+	rooms := &lockRecorder{RoomRepo: memory.NewRoom(store)}
+	s := service.NewRoom(rooms, memory.NewReservation(store), memory.NewAmenity(store), memory.NewUnitOfWork(store))
+
+	a, b, c := &model.Room{Id: uuid.New(), Name: "A"}, &model.Room{Id: uuid.New(), Name: "B"}, &model.Room{Id: uuid.New(), Name: "C"}
+	for _, room := range []*model.Room{a, b, c} {
+		if err := s.Add(ctx, room); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first, second := a.Id, b.Id
+	if bytes.Compare(second[:], first[:]) < 0 {
+		first, second = second, first
+	}
+
+	tests := []struct {
+		name string
+		move func() *errs.Error
+	}{
+		{name: "move to the larger id", move: func() *errs.Error { _, err := s.MoveReservations(ctx, first, second); return err }},
+		{name: "move to the smaller id", move: func() *errs.Error { _, err := s.MoveReservations(ctx, second, first); return err }},
+		{name: "archive into the smaller id", move: func() *errs.Error { _, err := s.Archive(ctx, second, &first); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rooms.locked = nil
+			if err := tt.move(); err != nil {
+				t.Fatal(err)
+			}
+			if len(rooms.locked) != 2 || rooms.locked[0] != first || rooms.locked[1] != second {
+				t.Fatalf("expected %s to be locked before %s, got %v", first, second, rooms.locked)
+			}
+		})
+	}
+
+	if _, err := s.MoveReservations(ctx, c.Id, uuid.New()); err == nil || err.Code != 404 || err.Message != "target room not found" {
+		t.Fatalf("expected the missing target to be reported, got %+v", err)
+	}
+}
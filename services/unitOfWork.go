@@ -0,0 +1,11 @@
+package service
+
+import (
+	"context"
+
+	"github.com/demkowo/booking/utils/errs"
+)
+
+type UnitOfWork interface {
+	Do(context.Context, func(context.Context) *errs.Error) *errs.Error
+}
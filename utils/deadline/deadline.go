@@ -0,0 +1,77 @@
+package deadline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_TIMEOUT = 5 * time.Second
+)
+
+var (
+	mu             sync.RWMutex
+	defaultTimeout = DEFAULT_TIMEOUT
+	timeouts       = map[string]time.Duration{}
+)
+
+// For derives a context bounded by the timeout configured for operation,
+// falling back to the default timeout.
+func For(ctx context.Context, operation string) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, Get(operation))
+}
+
+func Get(operation string) time.Duration {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if timeout, ok := timeouts[operation]; ok {
+		return timeout
+	}
+
+	return defaultTimeout
+}
+
+func SetDefault(timeout time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	defaultTimeout = timeout
+}
+
+func Set(operation string, timeout time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	timeouts[operation] = timeout
+}
+
+// Parse reads per-operation timeouts in the "operation=duration" format,
+// separated by commas, e.g. "room.find_available=2s,reservation.find=10s".
+func Parse(value string) (map[string]time.Duration, error) {
+	parsed := map[string]time.Duration{}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		operation, duration, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid timeout %q, expected operation=duration", pair)
+		}
+
+		timeout, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid timeout for %s: %q", operation, duration)
+		}
+
+		parsed[strings.TrimSpace(operation)] = timeout
+	}
+
+	return parsed, nil
+}
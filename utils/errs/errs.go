@@ -1,35 +1,69 @@
-package errs
-
-import (
-	"encoding/json"
-	"log"
-)
-
-type Error struct {
-	Message string        `json:"message"`
-	Code    int           `json:"code"`
-	Status  string        `json:"status"`
-	Causes  []interface{} `json:"causes"`
-}
-
-const (
-	NotFoundErrorCode = 404
-)
-
-func NewError(message string, code int, status string, causes []interface{}) *Error {
-	log.Println("--- errs/NewError() ---")
-
-	return &Error{
-		Message: message,
-		Code:    code,
-		Status:  status,
-		Causes:  causes,
-	}
-}
-
-func Err(err *Error) string {
-	log.Println("--- errs/Err() ---")
-
-	jsonBytes, _ := json.Marshal(err)
-	return string(jsonBytes)
-}
+package errs
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type Error struct {
+	Message string        `json:"message"`
+	Code    int           `json:"code"`
+	Status  string        `json:"status"`
+	Causes  []interface{} `json:"causes"`
+}
+
+type Cause struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+const (
+	NotFoundErrorCode = 404
+)
+
+func NewError(message string, code int, status string, causes []interface{}) *Error {
+	return &Error{
+		Message: message,
+		Code:    code,
+		Status:  status,
+		Causes:  causes,
+	}
+}
+
+func NewValidationError(message string, causes ...Cause) *Error {
+	list := make([]interface{}, 0, len(causes))
+	for _, cause := range causes {
+		list = append(list, cause)
+	}
+
+	return NewError(message, 400, "Bad Request", list)
+}
+
+// NewUnprocessableError reports well-formed input that breaks a rule, e.g.
+// an end date before the start date.
+func NewUnprocessableError(message string, causes ...Cause) *Error {
+	list := make([]interface{}, 0, len(causes))
+	for _, cause := range causes {
+		list = append(list, cause)
+	}
+
+	return NewError(message, 422, "Unprocessable Entity", list)
+}
+
+func Err(err *Error) string {
+	jsonBytes, _ := json.Marshal(err)
+	return string(jsonBytes)
+}
+
+// FromContext maps a cancelled or timed out context to an error. It returns
+// nil while ctx is still active.
+func FromContext(ctx context.Context) *Error {
+	switch ctx.Err() {
+	case nil:
+		return nil
+	case context.DeadlineExceeded:
+		return NewError("Request timed out", 504, "Gateway Timeout", nil)
+	default:
+		return NewError("Request was cancelled", 503, "Service Unavailable", nil)
+	}
+}
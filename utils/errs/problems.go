@@ -0,0 +1,111 @@
+package errs
+
+import "net/http"
+
+//go:generate go run ../../cmd/problemsdoc -o ../../docs/problems.md
+
+const (
+	PROBLEM_TYPE_BASE        = "/api/v1/problems/"
+	PROBLEM_TYPE_ABOUT_BLANK = "about:blank"
+)
+
+// ProblemType describes one kind of error the API reports. Type is the
+// stable URI sent in the "type" member of RFC 7807 problem documents.
+type ProblemType struct {
+	Kind        string `json:"kind"`
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+// Problem is an RFC 7807 problem details document. Causes is an extension
+// member carrying the field-level causes of the error.
+type Problem struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Instance string        `json:"instance,omitempty"`
+	Causes   []interface{} `json:"causes,omitempty"`
+}
+
+var Catalog = []ProblemType{
+	newProblemType("validation", "Validation Failed", http.StatusBadRequest,
+		"The request is malformed, or a field is missing or can't be read, e.g. a date that isn't YYYY-MM-DD. The causes member lists every invalid field."),
+	newProblemType("unauthorized", "Unauthorized", http.StatusUnauthorized,
+		"The request lacks valid credentials."),
+	newProblemType("forbidden", "Forbidden", http.StatusForbidden,
+		"The credentials are valid but don't grant access to the resource."),
+	newProblemType("not-found", "Not Found", http.StatusNotFound,
+		"The requested route or resource doesn't exist."),
+	newProblemType("method-not-allowed", "Method Not Allowed", http.StatusMethodNotAllowed,
+		"The route exists but doesn't support the request method."),
+	newProblemType("conflict", "Conflict", http.StatusConflict,
+		"The request conflicts with the current state, e.g. the room is already booked for the dates."),
+	newProblemType("precondition-failed", "Precondition Failed", http.StatusPreconditionFailed,
+		"The If-Match header doesn't match the current version of the resource."),
+	newProblemType("payload-too-large", "Request Entity Too Large", http.StatusRequestEntityTooLarge,
+		"The request body is larger than the service accepts, e.g. over IDEMPOTENCY_MAX_BODY_BYTES for a request with an Idempotency-Key."),
+	newProblemType("unprocessable-entity", "Unprocessable Entity", http.StatusUnprocessableEntity,
+		"The request is well formed but can't be processed: a field breaks a rule, e.g. the end date is before the start date or the guests exceed the room capacity, or an Idempotency-Key is reused with a different body. The causes member lists the fields."),
+	newProblemType("precondition-required", "Precondition Required", http.StatusPreconditionRequired,
+		"The request must be conditional, send the If-Match header."),
+	newProblemType("rate-limited", "Too Many Requests", http.StatusTooManyRequests,
+		"The client sent too many requests. Retry after the number of seconds in the Retry-After header."),
+	newProblemType("internal", "Internal Server Error", http.StatusInternalServerError,
+		"An unexpected error occurred on the server."),
+	newProblemType("unavailable", "Service Unavailable", http.StatusServiceUnavailable,
+		"The request was cancelled before it completed, or the service isn't ready or is shutting down. It can be retried."),
+	newProblemType("timeout", "Gateway Timeout", http.StatusGatewayTimeout,
+		"The database didn't answer within the configured timeout, the request can be retried."),
+}
+
+func newProblemType(kind string, title string, status int, description string) ProblemType {
+	return ProblemType{
+		Kind:        kind,
+		Type:        PROBLEM_TYPE_BASE + kind,
+		Title:       title,
+		Status:      status,
+		Description: description,
+	}
+}
+
+// ProblemTypeFor returns the catalog entry for an HTTP status code. Codes
+// without a dedicated entry map to "about:blank" as RFC 7807 prescribes.
+func ProblemTypeFor(code int) ProblemType {
+	for _, problemType := range Catalog {
+		if problemType.Status == code {
+			return problemType
+		}
+	}
+
+	return ProblemType{
+		Type:   PROBLEM_TYPE_ABOUT_BLANK,
+		Title:  http.StatusText(code),
+		Status: code,
+	}
+}
+
+func GetProblemType(kind string) (*ProblemType, *Error) {
+	for _, problemType := range Catalog {
+		if problemType.Kind == kind {
+			return &problemType, nil
+		}
+	}
+
+	return nil, NewError("problem type not found", 404, "Not Found", nil)
+}
+
+func (e *Error) Problem(instance string) *Problem {
+	problemType := ProblemTypeFor(e.Code)
+
+	return &Problem{
+		Type:     problemType.Type,
+		Title:    problemType.Title,
+		Status:   e.Code,
+		Detail:   e.Message,
+		Instance: instance,
+		Causes:   e.Causes,
+	}
+}
@@ -0,0 +1,112 @@
+// Package health keeps the checks /readyz runs. Subsystems register a check
+// when they start, so readiness reports every dependency the service has.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/demkowo/booking/utils/deadline"
+)
+
+const (
+	STATUS_UP   = "up"
+	STATUS_DOWN = "down"
+)
+
+// Check reports why a dependency isn't usable, or nil when it is.
+type Check func(context.Context) error
+
+type Result struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+type entry struct {
+	name  string
+	check Check
+}
+
+var (
+	mu      sync.RWMutex
+	entries []entry
+)
+
+// Register adds a check, replacing the check registered under the same
+// name. Each check runs with the timeout configured for the "health.<name>"
+// operation.
+func Register(name string, check Check) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i := range entries {
+		if entries[i].name == name {
+			entries[i].check = check
+			return
+		}
+	}
+	entries = append(entries, entry{name: name, check: check})
+}
+
+func Unregister(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for i := range entries {
+		if entries[i].name == name {
+			entries = append(entries[:i], entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// Run executes all checks concurrently and reports them in the order they
+// were registered. The report is down when any check fails.
+func Run(ctx context.Context) Report {
+	mu.RLock()
+	registered := append([]entry{}, entries...)
+	mu.RUnlock()
+
+	report := Report{Status: STATUS_UP, Checks: make([]Result, len(registered))}
+
+	var wg sync.WaitGroup
+	for i, e := range registered {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = run(ctx, e)
+		}()
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != STATUS_UP {
+			report.Status = STATUS_DOWN
+		}
+	}
+
+	return report
+}
+
+func run(ctx context.Context, e entry) Result {
+	ctx, cancel := deadline.For(ctx, "health."+e.name)
+	defer cancel()
+
+	start := time.Now()
+	err := e.check(ctx)
+	result := Result{Name: e.name, Status: STATUS_UP, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = STATUS_DOWN
+		result.Error = err.Error()
+	}
+
+	return result
+}
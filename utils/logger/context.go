@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	PROPERTY_FIELD   = "property"
+	REQUEST_ID_FIELD = "request_id"
+	TRACE_ID_FIELD   = "trace_id"
+	USER_FIELD       = "user"
+)
+
+type entryKey struct{}
+
+// WithEntry stores a log entry carrying request fields, such as the request
+// ID, in ctx.
+func WithEntry(ctx context.Context, entry *log.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext returns the entry stored by WithEntry, or an entry without
+// fields when ctx doesn't belong to a request.
+func FromContext(ctx context.Context) *log.Entry {
+	if entry, ok := ctx.Value(entryKey{}).(*log.Entry); ok {
+		return entry
+	}
+
+	return log.NewEntry(log.StandardLogger())
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	MODULE_PATH = "github.com/demkowo/booking/"
+)
+
+var (
+	levelsMu      sync.RWMutex
+	globalLevel   = log.InfoLevel
+	packageLevels = map[string]log.Level{}
+)
+
+// levelFilter drops entries logged below the level of the package they come
+// from. logrus only knows one level, so it is set to the most verbose of the
+// global and the package levels and the rest is filtered here.
+type levelFilter struct {
+	log.Formatter
+}
+
+func (f *levelFilter) Format(entry *log.Entry) ([]byte, error) {
+	if !enabled(entry) {
+		return nil, nil
+	}
+
+	return f.Formatter.Format(entry)
+}
+
+// SetLevels replaces the global level and the package levels. Packages are
+// paths relative to the module, e.g. "repositories" or "repositories/sqlite",
+// and the longest matching path wins.
+func SetLevels(global log.Level, packages map[string]log.Level) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+
+	globalLevel = global
+	packageLevels = map[string]log.Level{}
+	verbose := global
+	for pkg, level := range packages {
+		packageLevels[strings.Trim(pkg, "/")] = level
+		if level > verbose {
+			verbose = level
+		}
+	}
+
+	log.SetLevel(verbose)
+}
+
+func Levels() (log.Level, map[string]log.Level) {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	packages := make(map[string]log.Level, len(packageLevels))
+	for pkg, level := range packageLevels {
+		packages[pkg] = level
+	}
+
+	return globalLevel, packages
+}
+
+func enabled(entry *log.Entry) bool {
+	levelsMu.RLock()
+	defer levelsMu.RUnlock()
+
+	level := globalLevel
+	if entry.Caller != nil && len(packageLevels) > 0 {
+		pkg := packageOf(entry.Caller.Function)
+		match := -1
+		for prefix, l := range packageLevels {
+			if (pkg == prefix || strings.HasPrefix(pkg, prefix+"/")) && len(prefix) > match {
+				level = l
+				match = len(prefix)
+			}
+		}
+	}
+
+	return entry.Level <= level
+}
+
+// packageOf returns the package path of a function relative to the module,
+// e.g. "repositories/postgres" for
+// "github.com/demkowo/booking/repositories/postgres.(*room).Add".
+func packageOf(function string) string {
+	function = strings.TrimPrefix(function, MODULE_PATH)
+
+	dir := ""
+	if slash := strings.LastIndex(function, "/"); slash >= 0 {
+		dir, function = function[:slash+1], function[slash+1:]
+	}
+	name, _, _ := strings.Cut(function, ".")
+
+	return dir + name
+}
@@ -1,134 +1,169 @@
-package logger
-
-import (
-	"io"
-	"os"
-
-	model "github.com/demkowo/booking/models"
-	log "github.com/sirupsen/logrus"
-)
-
-var (
-	Start loggerInterface = &loggerStruct{}
-	m     *model.ConfigStruct
-)
-
-type loggerInterface interface {
-	BasicConfig()
-	YamlConfig()
-}
-
-type loggerStruct struct {
-}
-
-func (c *loggerStruct) BasicConfig() {
-	log.SetLevel(log.TraceLevel)
-	log.SetFormatter(&CustomFormatter{})
-	log.SetReportCaller(true)
-
-	f, err := os.OpenFile("log.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err != nil {
-		log.Panicf("os.OpenFile failed\n[%s]\n", err)
-	}
-	multi := io.MultiWriter(os.Stdout, f)
-	log.SetOutput(multi)
-
-}
-
-func (c *loggerStruct) YamlConfig() {
-	log.Trace()
-
-	m = model.Config.Get()
-
-	setLevel()
-	setFormat()
-	setReporter()
-	setStdout()
-}
-
-func setLevel() {
-	switch m.Logrus.Level {
-	case 0:
-		log.SetLevel(log.PanicLevel)
-		log.Info("PanicLevel")
-	case 1:
-		log.SetLevel(log.FatalLevel)
-		log.Info("FatalLevel")
-	case 2:
-		log.SetLevel(log.ErrorLevel)
-		log.Info("ErrorLevel")
-	case 3:
-		log.SetLevel(log.WarnLevel)
-		log.Info("WarnLevel")
-	case 4:
-		log.SetLevel(log.InfoLevel)
-		log.Info("InfoLevel")
-	case 5:
-		log.SetLevel(log.DebugLevel)
-		log.Info("DebugLevel")
-	case 6:
-		log.SetLevel(log.TraceLevel)
-		log.Info("TraceLevel")
-	default:
-		log.SetLevel(log.WarnLevel)
-		log.Info("WarnLevel")
-	}
-}
-
-func setFormat() {
-
-	switch m.Logrus.Format {
-	case "text":
-		log.SetFormatter(&log.TextFormatter{})
-		log.Info("TextFormatter")
-	case "json":
-		log.SetFormatter(&log.JSONFormatter{})
-		log.Info("JSONFormatter")
-	case "custom":
-		log.SetFormatter(&CustomFormatter{})
-		log.Info("CustomFormatter")
-	default:
-		log.SetFormatter(&log.TextFormatter{})
-		log.Info("TextFormatter")
-	}
-}
-
-func setReporter() {
-
-	if m.Logrus.Reporter {
-		log.SetReportCaller(m.Logrus.Reporter)
-		log.Info(m.Logrus.Reporter)
-	}
-
-}
-
-func setStdout() {
-
-	var writers []io.Writer
-	var err error
-
-	for _, out := range m.Logrus.Output {
-		if out == "stdout" {
-			writer := io.Writer(os.Stdout)
-			writers = append(writers, writer)
-			log.Info("add writer:   os.Stdout")
-		}
-		if out == "file" {
-			m.Logrus.LogFile, err = os.OpenFile(m.Logrus.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-			if err != nil {
-				log.Panicf("os.OpenFile failed\n[%s]\n", err)
-			}
-			writer := io.Writer(m.Logrus.LogFile)
-			writers = append(writers, writer)
-			log.Info("add writer:   file")
-		}
-	}
-
-	if len(writers) < 1 {
-		log.SetOutput(os.Stdout)
-	}
-
-	multi := io.MultiWriter(writers...)
-	log.SetOutput(multi)
-
-}
+package logger
+
+import (
+	"io"
+	"os"
+	"time"
+
+	model "github.com/demkowo/booking/models"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+var (
+	Start loggerInterface = &loggerStruct{}
+	m     *model.ConfigStruct
+)
+
+type loggerInterface interface {
+	BasicConfig()
+	YamlConfig()
+	Close()
+}
+
+type loggerStruct struct {
+	stopRotation chan struct{}
+}
+
+// BasicConfig logs to stdout until YamlConfig applies the configuration.
+func (c *loggerStruct) BasicConfig() {
+	SetLevels(log.InfoLevel, nil)
+	log.SetFormatter(&levelFilter{&CustomFormatter{}})
+	log.SetReportCaller(true)
+	log.SetOutput(os.Stdout)
+}
+
+func (c *loggerStruct) YamlConfig() {
+	log.Trace()
+
+	m = model.Config.Get()
+
+	setLevel()
+	setFormat()
+	setReporter()
+	setStdout(c)
+	log.AddHook(newRedactHook(m.Logrus.Redact))
+}
+
+// Close stops the scheduled rotation and closes the log file.
+func (c *loggerStruct) Close() {
+	if c.stopRotation != nil {
+		close(c.stopRotation)
+		c.stopRotation = nil
+	}
+	if m != nil && m.Logrus.LogFile != nil {
+		m.Logrus.LogFile.Close()
+	}
+}
+
+func setLevel() {
+	global := log.Level(m.Logrus.Level)
+	if global > log.TraceLevel {
+		global = log.WarnLevel
+	}
+
+	packages := map[string]log.Level{}
+	for pkg, name := range m.Logrus.Levels {
+		level, err := ParseLevel(name)
+		if err != nil {
+			log.Warnf("invalid level %q for %s: %v", name, pkg, err)
+			continue
+		}
+		packages[pkg] = level
+	}
+
+	SetLevels(global, packages)
+	log.Info(global.String())
+}
+
+// ParseLevel reads a level given by its number, 0 (panic) to 6 (trace), or by
+// its name.
+func ParseLevel(value string) (log.Level, error) {
+	if len(value) == 1 && value[0] >= '0' && value[0] <= '6' {
+		return log.Level(value[0] - '0'), nil
+	}
+
+	return log.ParseLevel(value)
+}
+
+func setFormat() {
+
+	switch m.Logrus.Format {
+	case "text":
+		log.SetFormatter(&levelFilter{&log.TextFormatter{}})
+		log.Info("TextFormatter")
+	case "json":
+		log.SetFormatter(&levelFilter{&log.JSONFormatter{}})
+		log.Info("JSONFormatter")
+	case "custom":
+		log.SetFormatter(&levelFilter{&CustomFormatter{}})
+		log.Info("CustomFormatter")
+	default:
+		log.SetFormatter(&levelFilter{&log.TextFormatter{}})
+		log.Info("TextFormatter")
+	}
+}
+
+func setReporter() {
+
+	log.SetReportCaller(m.Logrus.Reporter)
+	log.Info(m.Logrus.Reporter)
+
+}
+
+func setStdout(c *loggerStruct) {
+
+	var writers []io.Writer
+
+	for _, out := range m.Logrus.Output {
+		if out == "stdout" {
+			writer := io.Writer(os.Stdout)
+			writers = append(writers, writer)
+			log.Info("add writer:   os.Stdout")
+		}
+		if out == "file" {
+			file := &lumberjack.Logger{
+				Filename:   m.Logrus.Path,
+				MaxSize:    m.Logrus.MaxSizeMB,
+				MaxAge:     m.Logrus.MaxAgeDays,
+				MaxBackups: m.Logrus.MaxBackups,
+				Compress:   m.Logrus.Compress,
+			}
+			m.Logrus.LogFile = file
+			writers = append(writers, file)
+			log.Info("add writer:   file")
+
+			if m.Logrus.RotateEvery > 0 {
+				c.stopRotation = make(chan struct{})
+				go rotate(file, m.Logrus.RotateEvery, c.stopRotation)
+			}
+		}
+	}
+
+	if len(writers) < 1 {
+		log.SetOutput(os.Stdout)
+		return
+	}
+
+	multi := io.MultiWriter(writers...)
+	log.SetOutput(multi)
+
+}
+
+// rotate starts a new log file every interval, on top of the rotation by
+// size lumberjack does on its own.
+func rotate(file *lumberjack.Logger, interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := file.Rotate(); err != nil {
+				log.Errorf("log rotation failed: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
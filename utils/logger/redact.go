@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	REDACTED = "[REDACTED]"
+)
+
+var DEFAULT_REDACT = []string{"password", "secret", "jwt_secret", "token", "access_token", "refresh_token", "api_key", "apikey", "authorization"}
+
+var bearerToken = regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`)
+
+// redactHook hides the values of sensitive fields, and of key=value or
+// "key": "value" pairs and bearer tokens written into messages.
+type redactHook struct {
+	keys    map[string]bool
+	message *regexp.Regexp
+}
+
+func newRedactHook(keys []string) *redactHook {
+	h := &redactHook{keys: map[string]bool{}}
+
+	quoted := []string{}
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		h.keys[key] = true
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	if len(quoted) > 0 {
+		h.message = regexp.MustCompile(`(?i)((?:` + strings.Join(quoted, "|") + `)["']?\s*[:=]\s*["']?(?:(?:bearer|basic)\s+)?)[^"'\s,&}]+`)
+	}
+
+	return h
+}
+
+func (h *redactHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (h *redactHook) Fire(entry *log.Entry) error {
+	for key := range entry.Data {
+		if h.keys[strings.ToLower(key)] {
+			entry.Data[key] = REDACTED
+		}
+	}
+
+	if h.message != nil {
+		entry.Message = h.message.ReplaceAllString(entry.Message, "${1}"+REDACTED)
+	}
+	entry.Message = bearerToken.ReplaceAllString(entry.Message, "${1}"+REDACTED)
+
+	return nil
+}
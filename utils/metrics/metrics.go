@@ -0,0 +1,114 @@
+// Package metrics holds the Prometheus collectors of the service. They are
+// registered on Registry, which /metrics exposes together with the Go
+// runtime and process collectors.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	NAMESPACE = "booking"
+
+	// ROUTE_UNMATCHED labels requests that didn't match a route, so scanners
+	// probing random paths don't create a series per path.
+	ROUTE_UNMATCHED = "unmatched"
+)
+
+var (
+	Registry = prometheus.NewRegistry()
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route template.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "code"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: NAMESPACE,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests being served.",
+	})
+
+	QueryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: NAMESPACE,
+		Name:      "db_query_duration_seconds",
+		Help:      "Repository operation latency, including reading the rows.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"backend", "operation"})
+
+	QueryErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "db_query_errors_total",
+		Help:      "Failed repository operations by reason: error, timeout or canceled.",
+	}, []string{"backend", "operation", "reason"})
+
+	ReservationsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "reservations_created_total",
+		Help:      "Reservations created by status.",
+	}, []string{"status"})
+
+	ReservationsCancelled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "reservations_cancelled_total",
+		Help:      "Reservations deleted by status.",
+	}, []string{"status"})
+
+	ReservationsRestored = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "reservations_restored_total",
+		Help:      "Deleted reservations restored by status.",
+	}, []string{"status"})
+
+	AvailabilitySearches = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "availability_searches_total",
+		Help:      "Availability searches by kind: find_available or room_check.",
+	}, []string{"kind"})
+
+	ConflictsRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "conflicts_rejected_total",
+		Help:      "Writes rejected with 409 Conflict by reason.",
+	}, []string{"reason"})
+
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: NAMESPACE,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected with 429 Too Many Requests by route group.",
+	}, []string{"group"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		QueryDuration,
+		QueryErrors,
+		ReservationsCreated,
+		ReservationsCancelled,
+		ReservationsRestored,
+		AvailabilitySearches,
+		ConflictsRejected,
+		RateLimited,
+	)
+}
+
+// RegisterDBStats exposes the connection pool statistics of a database under
+// the go_sql_* names, labelled with dbName.
+func RegisterDBStats(dbName string, stats func() sql.DBStats) error {
+	return Registry.Register(newDBStatsCollector(dbName, stats))
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+type queryKey struct{}
+
+type query struct {
+	backend   string
+	operation string
+}
+
+// StartQuery times a repository operation. The returned context carries the
+// operation, so a failure reported with QueryFailed gets the same labels.
+// Call the returned func when the operation is done.
+func StartQuery(ctx context.Context, backend string, operation string) (context.Context, func()) {
+	start := time.Now()
+	ctx = context.WithValue(ctx, queryKey{}, query{backend: backend, operation: operation})
+
+	return ctx, func() {
+		QueryDuration.WithLabelValues(backend, operation).Observe(time.Since(start).Seconds())
+	}
+}
+
+// QueryFailed counts a failed operation started with StartQuery. It does
+// nothing for a context without one.
+func QueryFailed(ctx context.Context, reason string) {
+	q, ok := ctx.Value(queryKey{}).(query)
+	if !ok {
+		return
+	}
+
+	QueryErrors.WithLabelValues(q.backend, q.operation, reason).Inc()
+}
@@ -0,0 +1,225 @@
+// Package openapi builds the OpenAPI 3 document of the API. Schemas are
+// generated from Go types, named structs become components.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/demkowo/booking/utils/errs"
+	"github.com/demkowo/booking/utils/resp"
+)
+
+const (
+	VERSION = "3.0.3"
+
+	MIME_JSON = "application/json"
+
+	SECURITY_BEARER = "bearerAuth"
+)
+
+var pathParam = regexp.MustCompile(`[:*](\w+)`)
+
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security,omitempty"`
+
+	names map[reflect.Type]string
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem holds the operations of a path by lower case method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Headers     map[string]Header    `json:"headers,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	Responses       map[string]*Response      `json:"responses"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Endpoint describes one route. Body is the JSON request body and Data the
+// data member of the response envelope, both nil when there is none.
+type Endpoint struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	Description string
+	Tag         string
+	Parameters  []Parameter
+	Body        interface{}
+	Data        interface{}
+	ETag        bool
+	Errors      []int
+}
+
+// New creates a document with the bearer token security scheme and an error
+// response for each problem type. The token is optional, whether it is
+// required depends on the configuration.
+func New(title string, version string, description string) *Document {
+	d := &Document{
+		OpenAPI: VERSION,
+		Info: Info{
+			Title:       title,
+			Version:     version,
+			Description: description,
+		},
+		Paths: map[string]PathItem{},
+		Components: Components{
+			Schemas:   map[string]*Schema{},
+			Responses: map[string]*Response{},
+			SecuritySchemes: map[string]SecurityScheme{
+				SECURITY_BEARER: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{}, {SECURITY_BEARER: {}}},
+		names:    map[reflect.Type]string{},
+	}
+
+	for _, problemType := range errs.Catalog {
+		d.Components.Responses[problemType.Kind] = &Response{
+			Description: problemType.Title,
+			Content: map[string]MediaType{
+				MIME_JSON:              {Schema: d.Schema(resp.Response{})},
+				resp.MIME_PROBLEM_JSON: {Schema: d.Schema(errs.Problem{})},
+			},
+		}
+	}
+
+	return d
+}
+
+// Describe adds the operation of an endpoint. Gin path parameters become
+// OpenAPI ones, those named *_id are UUIDs.
+func (d *Document) Describe(e Endpoint) {
+	operation := &Operation{
+		OperationID: e.OperationID,
+		Summary:     e.Summary,
+		Description: e.Description,
+		Responses:   map[string]*Response{},
+	}
+	if e.Tag != "" {
+		operation.Tags = []string{e.Tag}
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(e.Path, -1) {
+		schema := &Schema{Type: "string"}
+		if strings.HasSuffix(match[1], "_id") {
+			schema.Format = "uuid"
+		}
+		operation.Parameters = append(operation.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	operation.Parameters = append(operation.Parameters, e.Parameters...)
+
+	if e.Body != nil {
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{MIME_JSON: {Schema: d.Schema(e.Body)}},
+		}
+	}
+
+	envelope := d.Schema(resp.Response{})
+	if e.Data != nil {
+		envelope = &Schema{AllOf: []*Schema{envelope, {
+			Type:       "object",
+			Properties: map[string]*Schema{"data": d.Schema(e.Data)},
+		}}}
+	}
+	success := &Response{
+		Description: http.StatusText(http.StatusOK),
+		Content:     map[string]MediaType{MIME_JSON: {Schema: envelope}},
+	}
+	if e.ETag {
+		success.Headers = map[string]Header{"ETag": {Description: "Version of the resource, send it in If-Match to update it.", Schema: &Schema{Type: "string"}}}
+	}
+	operation.Responses[strconv.Itoa(http.StatusOK)] = success
+
+	for _, code := range e.Errors {
+		operation.Responses[strconv.Itoa(code)] = &Response{Ref: "#/components/responses/" + errs.ProblemTypeFor(code).Kind}
+	}
+
+	path := pathParam.ReplaceAllString(e.Path, "{$1}")
+	if d.Paths[path] == nil {
+		d.Paths[path] = PathItem{}
+	}
+	d.Paths[path][strings.ToLower(e.Method)] = operation
+}
+
+// Undocumented lists the routes under prefix without an operation, as
+// "METHOD path". OPTIONS routes are only there for CORS and are skipped.
+func (d *Document) Undocumented(routes gin.RoutesInfo, prefix string) []string {
+	missing := []string{}
+
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, prefix) || route.Method == http.MethodOptions {
+			continue
+		}
+
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		if _, exist := d.Paths[path][strings.ToLower(route.Method)]; !exist {
+			missing = append(missing, route.Method+" "+route.Path)
+		}
+	}
+	sort.Strings(missing)
+
+	return missing
+}
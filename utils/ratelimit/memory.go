@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	model "github.com/demkowo/booking/models"
+)
+
+const (
+	SWEEP_INTERVAL = time.Minute
+)
+
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	swept   time.Time
+}
+
+type memoryBucket struct {
+	Bucket
+	full time.Time
+}
+
+// NewMemoryStore keeps the buckets in process memory, so every instance of
+// the service has its own limits.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets: map[string]*memoryBucket{},
+		swept:   time.Now(),
+	}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, rule model.RateLimitRule) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.swept) >= SWEEP_INTERVAL {
+		s.sweep(now)
+	}
+
+	bucket, exist := s.buckets[key]
+	if !exist {
+		bucket = &memoryBucket{}
+		s.buckets[key] = bucket
+	}
+
+	result := bucket.Take(rule, now)
+	bucket.full = now.Add(result.Reset)
+
+	return result, nil
+}
+
+// sweep drops the buckets that have refilled, a new bucket starts full so
+// forgetting them changes nothing.
+func (s *memoryStore) sweep(now time.Time) {
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.full) {
+			delete(s.buckets, key)
+		}
+	}
+	s.swept = now
+}
@@ -0,0 +1,118 @@
+// Package ratelimit implements token bucket rate limiting. Buckets are kept
+// in a Store, in process memory by default. A shared Store lets several
+// instances of the service enforce one limit.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	model "github.com/demkowo/booking/models"
+)
+
+const (
+	GROUP_API    = "api"
+	GROUP_SEARCH = "search"
+	GROUP_ADMIN  = "admin"
+)
+
+var Groups = []string{GROUP_API, GROUP_SEARCH, GROUP_ADMIN}
+
+// Store keeps one bucket per key. Take refills the bucket of key according
+// to rule and takes a token from it when one is left.
+type Store interface {
+	Take(ctx context.Context, key string, rule model.RateLimitRule) (Result, error)
+}
+
+// Result is the state of a bucket after a request. Reset is the time until
+// the bucket is full again, RetryAfter the time until the next token when
+// the request isn't allowed.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Bucket holds the tokens left at Updated. Stores persist it between
+// requests and call Take on it.
+type Bucket struct {
+	Tokens  float64
+	Updated time.Time
+}
+
+func (b *Bucket) Take(rule model.RateLimitRule, now time.Time) Result {
+	burst := float64(Burst(rule))
+	interval := float64(rule.Period) / float64(rule.Requests)
+
+	if b.Updated.IsZero() {
+		b.Tokens = burst
+	} else if elapsed := now.Sub(b.Updated); elapsed > 0 {
+		b.Tokens = math.Min(burst, b.Tokens+float64(elapsed)/interval)
+	}
+	if now.After(b.Updated) {
+		b.Updated = now
+	}
+
+	result := Result{Limit: int(burst)}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - b.Tokens) * interval)
+	}
+	result.Remaining = int(b.Tokens)
+	result.Reset = time.Duration((burst - b.Tokens) * interval)
+
+	return result
+}
+
+// Burst is the size of the bucket, Requests when the rule doesn't set it.
+func Burst(rule model.RateLimitRule) int {
+	if rule.Burst > 0 {
+		return rule.Burst
+	}
+
+	return rule.Requests
+}
+
+// Parse reads per-group limits in the "group=requests/period" format,
+// separated by commas, e.g. "api=300/1m,search=30/1m".
+func Parse(value string) (map[string]model.RateLimitRule, error) {
+	parsed := map[string]model.RateLimitRule{}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		group, limit, found := strings.Cut(pair, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid limit %q, expected group=requests/period", pair)
+		}
+
+		requests, period, found := strings.Cut(strings.TrimSpace(limit), "/")
+		if !found {
+			return nil, fmt.Errorf("invalid limit for %s: %q, expected requests/period", group, limit)
+		}
+
+		rule := model.RateLimitRule{}
+		var err error
+		if rule.Requests, err = strconv.Atoi(requests); err != nil || rule.Requests < 0 {
+			return nil, fmt.Errorf("invalid number of requests for %s: %q", group, requests)
+		}
+		if rule.Period, err = time.ParseDuration(period); err != nil || rule.Period <= 0 {
+			return nil, fmt.Errorf("invalid period for %s: %q", group, period)
+		}
+
+		parsed[strings.TrimSpace(group)] = rule
+	}
+
+	return parsed, nil
+}
@@ -1,46 +1,116 @@
-package resp
-
-import (
-	"encoding/json"
-	"log"
-)
-
-type Response struct {
-	Success bool          `json:"success"`
-	Message string        `json:"message"`
-	Code    int           `json:"code"`
-	Status  string        `json:"status"`
-	Data    interface{}   `json:"data,omitempty"`
-	Causes  []interface{} `json:"causes,omitempty"`
-}
-
-func Error(message string, code int, status string, causes []interface{}) *Response {
-	log.Println("--- resp/Error() ---")
-	return &Response{
-		Success: false,
-		Message: message,
-		Code:    code,
-		Status:  status,
-		Causes:  causes,
-	}
-}
-
-func Success(message string, code int, status string, data interface{}) *Response {
-	log.Println("--- resp/Success() ---")
-	return &Response{
-		Success: true,
-		Message: message,
-		Code:    code,
-		Status:  status,
-		Data:    data,
-	}
-}
-
-func JSON(r *Response) string {
-	jsonBytes, err := json.Marshal(r)
-	if err != nil {
-		log.Println("Error marshaling response:", err)
-		return ""
-	}
-	return string(jsonBytes)
-}
+package resp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/demkowo/booking/utils/errs"
+)
+
+const (
+	ERROR_FORMAT_ENVELOPE  = "envelope"
+	ERROR_FORMAT_PROBLEM   = "problem"
+	ERROR_FORMAT_NEGOTIATE = "negotiate"
+
+	MIME_PROBLEM_JSON = "application/problem+json"
+)
+
+var (
+	errorFormat = ERROR_FORMAT_NEGOTIATE
+)
+
+type Response struct {
+	Success bool          `json:"success"`
+	Message string        `json:"message"`
+	Code    int           `json:"code"`
+	Status  string        `json:"status"`
+	Data    interface{}   `json:"data,omitempty"`
+	Causes  []interface{} `json:"causes,omitempty"`
+}
+
+func Error(message string, code int, status string, causes []interface{}) *Response {
+	return &Response{
+		Success: false,
+		Message: message,
+		Code:    code,
+		Status:  status,
+		Causes:  causes,
+	}
+}
+
+func Success(message string, code int, status string, data interface{}) *Response {
+	return &Response{
+		Success: true,
+		Message: message,
+		Code:    code,
+		Status:  status,
+		Data:    data,
+	}
+}
+
+func JSON(r *Response) string {
+	jsonBytes, err := json.Marshal(r)
+	if err != nil {
+		log.Errorf("Failed to marshal the response: %v", err)
+		return ""
+	}
+	return string(jsonBytes)
+}
+
+// Send writes a successful response envelope with the given HTTP code.
+func Send(c *gin.Context, code int, message string, data interface{}) {
+	c.JSON(code, Success(message, code, http.StatusText(code), data))
+}
+
+// SetErrorFormat selects how Fail renders errors: always as the response
+// envelope, always as RFC 7807 problem documents, or negotiated from the
+// Accept header.
+func SetErrorFormat(format string) error {
+	switch format {
+	case ERROR_FORMAT_ENVELOPE, ERROR_FORMAT_PROBLEM, ERROR_FORMAT_NEGOTIATE:
+		errorFormat = format
+		return nil
+	default:
+		return fmt.Errorf("invalid error format %q", format)
+	}
+}
+
+// Fail writes err as an error response and aborts the request. The HTTP
+// status is taken from err.Code, anything that isn't a valid status is
+// reported as 500.
+func Fail(c *gin.Context, err *errs.Error) {
+	code := err.Code
+	if code < 100 || code > 599 {
+		code = http.StatusInternalServerError
+	}
+
+	status := err.Status
+	if status == "" {
+		status = http.StatusText(code)
+	}
+
+	if wantsProblem(c) {
+		problem := errs.NewError(err.Message, code, status, err.Causes).Problem(c.Request.URL.Path)
+		c.Header("Content-Type", MIME_PROBLEM_JSON)
+		c.AbortWithStatusJSON(code, problem)
+		return
+	}
+
+	c.AbortWithStatusJSON(code, Error(err.Message, code, status, err.Causes))
+}
+
+func wantsProblem(c *gin.Context) bool {
+	switch errorFormat {
+	case ERROR_FORMAT_PROBLEM:
+		return true
+	case ERROR_FORMAT_ENVELOPE:
+		return false
+	default:
+		return c.NegotiateFormat(binding.MIMEJSON, MIME_PROBLEM_JSON) == MIME_PROBLEM_JSON
+	}
+}
@@ -0,0 +1,156 @@
+package resp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/demkowo/booking/utils/errs"
+)
+
+func TestFail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, expire := context.WithTimeout(context.Background(), 0)
+	defer expire()
+
+	tests := []struct {
+		name   string
+		err    *errs.Error
+		code   int
+		status string
+		causes []errs.Cause
+	}{
+		{name: "error", err: errs.NewError("room not found", 404, "Not Found", nil), code: 404, status: "Not Found"},
+		{name: "error without status", err: errs.NewError("conflict", 409, "", nil), code: 409, status: "Conflict"},
+		{name: "error with invalid code", err: errs.NewError("broken", 0, "", nil), code: 500, status: "Internal Server Error"},
+		{
+			name:   "validation error",
+			err:    errs.NewValidationError("Invalid input", errs.Cause{Field: "name", Message: "is required"}, errs.Cause{Field: "room_id", Message: "must be a valid UUID"}),
+			code:   400,
+			status: "Bad Request",
+			causes: []errs.Cause{{Field: "name", Message: "is required"}, {Field: "room_id", Message: "must be a valid UUID"}},
+		},
+		{
+			name:   "unprocessable error",
+			err:    errs.NewUnprocessableError("Invalid input", errs.Cause{Field: "end_date", Message: "must be after start_date"}),
+			code:   422,
+			status: "Unprocessable Entity",
+			causes: []errs.Cause{{Field: "end_date", Message: "must be after start_date"}},
+		},
+		{name: "cancelled context", err: errs.FromContext(cancelled), code: 503, status: "Service Unavailable"},
+		{name: "expired context", err: errs.FromContext(expired), code: 504, status: "Gateway Timeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(func(c *gin.Context) { Fail(c, tt.err) }, "")
+
+			if w.Code != tt.code {
+				t.Fatalf("expected status %d, got %d", tt.code, w.Code)
+			}
+
+			var body struct {
+				Success bool         `json:"success"`
+				Message string       `json:"message"`
+				Code    int          `json:"code"`
+				Status  string       `json:"status"`
+				Data    interface{}  `json:"data"`
+				Causes  []errs.Cause `json:"causes"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+			}
+			if body.Success || body.Message != tt.err.Message || body.Code != tt.code || body.Status != tt.status || body.Data != nil {
+				t.Fatalf("unexpected envelope %s", w.Body.String())
+			}
+			if len(body.Causes) != len(tt.causes) {
+				t.Fatalf("expected causes %v, got %s", tt.causes, w.Body.String())
+			}
+			for i, cause := range tt.causes {
+				if body.Causes[i] != cause {
+					t.Fatalf("expected cause %v, got %v", cause, body.Causes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFailWithoutCausesOmitsThem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := serve(func(c *gin.Context) { Fail(c, errs.NewError("room not found", 404, "Not Found", nil)) }, "")
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if _, exist := body["causes"]; exist {
+		t.Fatalf("expected no causes, got %s", w.Body.String())
+	}
+}
+
+func TestFailAsProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := serve(func(c *gin.Context) {
+		Fail(c, errs.NewValidationError("Invalid input", errs.Cause{Field: "name", Message: "is required"}))
+	}, MIME_PROBLEM_JSON)
+
+	if w.Code != 400 || w.Header().Get("Content-Type") != MIME_PROBLEM_JSON {
+		t.Fatalf("expected a 400 problem, got %d %s", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	var problem struct {
+		Status   int          `json:"status"`
+		Detail   string       `json:"detail"`
+		Instance string       `json:"instance"`
+		Causes   []errs.Cause `json:"causes"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if problem.Status != 400 || problem.Instance != "/test" || len(problem.Causes) != 1 || problem.Causes[0].Field != "name" {
+		t.Fatalf("unexpected problem %s", w.Body.String())
+	}
+}
+
+func TestSend(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := serve(func(c *gin.Context) { Send(c, http.StatusOK, "room found", map[string]string{"name": "Blue"}) }, "")
+
+	var body struct {
+		Success bool              `json:"success"`
+		Message string            `json:"message"`
+		Code    int               `json:"code"`
+		Status  string            `json:"status"`
+		Data    map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON %q: %v", w.Body.String(), err)
+	}
+	if w.Code != 200 || !body.Success || body.Message != "room found" || body.Code != 200 || body.Status != "OK" || body.Data["name"] != "Blue" {
+		t.Fatalf("unexpected envelope %d %s", w.Code, w.Body.String())
+	}
+}
+
+func serve(handle gin.HandlerFunc, accept string) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.GET("/test", handle)
+
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w
+}
@@ -1,91 +1,189 @@
-package sqlclient
-
-import (
-	"database/sql"
-	"errors"
-	"log"
-)
-
-const (
-	env        = "ENV"
-	production = "prod"
-)
-
-var (
-	dbClient SqlClient
-)
-
-type client struct {
-	db *sql.DB
-}
-
-type SqlClient interface {
-	Close()
-	Exec(string, ...interface{}) (sql.Result, error)
-	Query(query string, args ...interface{}) (rows, error)
-	QueryRow(query string, args ...interface{}) row
-}
-
-func (c *client) Close() {
-	c.db.Close()
-}
-
-func (c *client) Exec(query string, args ...interface{}) (sql.Result, error) {
-	log.Println("--- client/sqlclient/Exec() ---")
-
-	res, err := c.db.Exec(query, args...)
-	if err != nil {
-		return nil, err
-	}
-
-	return res, nil
-}
-
-func (c *client) Query(query string, args ...interface{}) (rows, error) {
-	log.Println("--- client/sqlclient/Query() ---")
-
-	res, err := c.db.Query(query, args...)
-	if err != nil {
-		return nil, err
-	}
-
-	rows := sqlRows{
-		rows: res,
-	}
-
-	return &rows, nil
-}
-
-func (c *client) QueryRow(query string, args ...interface{}) row {
-	log.Println("--- client/sqlclient/QueryRow() ---")
-	res := c.db.QueryRow(query, args...)
-
-	row := sqlRow{
-		row: res,
-	}
-
-	return &row
-}
-
-func Open(driverName, dataSourceName string) (SqlClient, error) {
-	log.Println("--- client/sqlclient/Open() ---")
-
-	if !isProduction() && isMocked {
-		dbClient = &clientMock{}
-		return dbClient, nil
-	}
-	if driverName == "" {
-		return nil, errors.New("invalid driver name")
-	}
-
-	database, err := sql.Open(driverName, dataSourceName)
-	if driverName == "" {
-		return nil, err
-	}
-
-	dbClient := &client{
-		db: database,
-	}
-
-	return dbClient, nil
-}
+package sqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	env        = "ENV"
+	production = "prod"
+)
+
+var (
+	dbClient SqlClient
+)
+
+type client struct {
+	db     *sql.DB
+	driver string
+}
+
+// SqlClient wraps *sql.DB. The context aware methods run on the transaction
+// stored in the context by WithTx when there is one, so repositories take
+// part in a unit of work without knowing about it.
+type SqlClient interface {
+	Close()
+	Exec(string, ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (rows, error)
+	QueryRow(query string, args ...interface{}) row
+
+	Begin() (Tx, error)
+	BeginTx(context.Context, *sql.TxOptions) (Tx, error)
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	PingContext(context.Context) error
+	QueryContext(ctx context.Context, query string, args ...interface{}) (rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) row
+
+	SetConnMaxLifetime(time.Duration)
+	SetMaxIdleConns(int)
+	SetMaxOpenConns(int)
+	Stats() sql.DBStats
+}
+
+func (c *client) Close() {
+	c.db.Close()
+}
+
+func (c *client) Begin() (Tx, error) {
+	return c.BeginTx(context.Background(), nil)
+}
+
+func (c *client) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	tx, err := c.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlTx{tx: tx}, nil
+}
+
+func (c *client) Exec(query string, args ...interface{}) (sql.Result, error) {
+	res, err := c.db.Exec(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *client) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx = startSpan(ctx, c.driver, query)
+
+	var (
+		res sql.Result
+		err error
+	)
+	if tx, ok := TxFromContext(ctx); ok {
+		res, err = tx.ExecContext(ctx, query, args...)
+	} else {
+		res, err = c.db.ExecContext(ctx, query, args...)
+	}
+	endSpan(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (c *client) PingContext(ctx context.Context) error {
+	return c.db.PingContext(ctx)
+}
+
+func (c *client) Query(query string, args ...interface{}) (rows, error) {
+	res, err := c.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := sqlRows{
+		rows: res,
+	}
+
+	return &rows, nil
+}
+
+func (c *client) QueryContext(ctx context.Context, query string, args ...interface{}) (rows, error) {
+	ctx = startSpan(ctx, c.driver, query)
+
+	if tx, ok := TxFromContext(ctx); ok {
+		rows, err := tx.QueryContext(ctx, query, args...)
+		endSpan(ctx, err)
+		return rows, err
+	}
+
+	res, err := c.db.QueryContext(ctx, query, args...)
+	endSpan(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := sqlRows{
+		rows: res,
+	}
+
+	return &rows, nil
+}
+
+func (c *client) QueryRow(query string, args ...interface{}) row {
+	res := c.db.QueryRow(query, args...)
+
+	row := sqlRow{
+		row: res,
+	}
+
+	return &row
+}
+
+func (c *client) QueryRowContext(ctx context.Context, query string, args ...interface{}) row {
+	ctx = startSpan(ctx, c.driver, query)
+
+	if tx, ok := TxFromContext(ctx); ok {
+		return &tracedRow{row: tx.QueryRowContext(ctx, query, args...), ctx: ctx}
+	}
+
+	res := c.db.QueryRowContext(ctx, query, args...)
+
+	return &tracedRow{row: &sqlRow{row: res}, ctx: ctx}
+}
+
+func (c *client) SetConnMaxLifetime(d time.Duration) {
+	c.db.SetConnMaxLifetime(d)
+}
+
+func (c *client) SetMaxIdleConns(n int) {
+	c.db.SetMaxIdleConns(n)
+}
+
+func (c *client) SetMaxOpenConns(n int) {
+	c.db.SetMaxOpenConns(n)
+}
+
+func (c *client) Stats() sql.DBStats {
+	return c.db.Stats()
+}
+
+func Open(driverName, dataSourceName string) (SqlClient, error) {
+	if !isProduction() && isMocked {
+		dbClient = &clientMock{}
+		return dbClient, nil
+	}
+	if driverName == "" {
+		return nil, errors.New("invalid driver name")
+	}
+
+	database, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	dbClient := &client{
+		db:     database,
+		driver: driverName,
+	}
+
+	return dbClient, nil
+}
@@ -1,89 +1,313 @@
-package sqlclient
-
-import (
-	"database/sql"
-	"errors"
-	"log"
-	"os"
-)
-
-var (
-	isMocked bool
-)
-
-type clientMock struct {
-	mocks map[string]Mock
-}
-
-type Mock struct {
-	Query   string
-	Args    []interface{}
-	Error   error
-	Columns []string
-	Rows    [][]interface{}
-}
-
-func (c *clientMock) Close() {
-}
-
-func (c *clientMock) Query(query string, args ...interface{}) (rows, error) {
-	mock, exist := c.mocks[query]
-	if !exist {
-		return nil, errors.New("mock not found")
-	}
-
-	if mock.Error != nil {
-		return nil, mock.Error
-	}
-
-	rows := rowsMock{
-		Columns: mock.Columns,
-		Rows:    mock.Rows,
-	}
-
-	return &rows, nil
-}
-
-func (c *clientMock) Exec(query string, args ...interface{}) (sql.Result, error) {
-	log.Println("--- client_mock/sqlclient/Exec() ---")
-
-	return nil, nil
-}
-
-func (c *clientMock) QueryRow(query string, args ...interface{}) row {
-	mock := c.mocks[query]
-
-	row := rowsMock{
-		Columns: mock.Columns,
-		Rows:    mock.Rows,
-	}
-
-	return &row
-}
-
-func isProduction() bool {
-	log.Println("--- client/sqlclient/isProduction() ---")
-	return os.Getenv(env) == production
-}
-
-func StartMockServer() {
-	isMocked = true
-}
-
-func StopMockServer() {
-	isMocked = false
-}
-
-func AddMock(mock Mock) {
-	if dbClient == nil {
-		return
-	}
-	client, expectedType := dbClient.(*clientMock)
-	if !expectedType {
-		return
-	}
-	if client.mocks == nil {
-		client.mocks = make(map[string]Mock, 0)
-	}
-	client.mocks[mock.Query] = mock
-}
+package sqlclient
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	MOCK_BEGIN    = "BEGIN"
+	MOCK_COMMIT   = "COMMIT"
+	MOCK_ROLLBACK = "ROLLBACK"
+)
+
+var (
+	isMocked bool
+
+	// AnyArg matches any value at its position in Mock.Args.
+	AnyArg = anyArg{}
+)
+
+type anyArg struct{}
+
+type clientMock struct {
+	mu         sync.Mutex
+	mocks      []*Mock
+	unexpected []string
+}
+
+// Mock is a single expected statement. Mocks are matched in the order they
+// were added and each one is used once, so the same query can be mocked
+// several times with different results. A nil Args matches any arguments.
+//
+// For QueryRow an empty Rows makes Scan return sql.ErrNoRows. Error is
+// returned as is, which also allows injecting sql.ErrNoRows directly.
+// RowsError is returned by Err of the rows of a Query, after they were read.
+// BEGIN, COMMIT and ROLLBACK can be mocked to inject transaction errors, they
+// succeed when no mock is set.
+type Mock struct {
+	Query        string
+	Args         []interface{}
+	Error        error
+	Columns      []string
+	Rows         [][]interface{}
+	RowsError    error
+	RowsAffected int64
+	LastInsertId int64
+
+	used bool
+}
+
+type resultMock struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (r resultMock) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+
+func (r resultMock) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+func (c *clientMock) Close() {
+}
+
+func (c *clientMock) Begin() (Tx, error) {
+	return c.BeginTx(context.Background(), nil)
+}
+
+func (c *clientMock) BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error) {
+	if mock := c.optional(MOCK_BEGIN); mock != nil && mock.Error != nil {
+		return nil, mock.Error
+	}
+
+	return &sqlTxMock{client: c}, nil
+}
+
+func (c *clientMock) Exec(query string, args ...interface{}) (sql.Result, error) {
+	mock, err := c.match(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if mock.Error != nil {
+		return nil, mock.Error
+	}
+
+	return resultMock{lastInsertId: mock.LastInsertId, rowsAffected: mock.RowsAffected}, nil
+}
+
+func (c *clientMock) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.Exec(query, args...)
+}
+
+func (c *clientMock) PingContext(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (c *clientMock) Query(query string, args ...interface{}) (rows, error) {
+	mock, err := c.match(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if mock.Error != nil {
+		return nil, mock.Error
+	}
+
+	return &rowsMock{
+		Columns: mock.Columns,
+		Rows:    mock.Rows,
+		Error:   mock.RowsError,
+	}, nil
+}
+
+func (c *clientMock) QueryContext(ctx context.Context, query string, args ...interface{}) (rows, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return c.Query(query, args...)
+}
+
+func (c *clientMock) QueryRow(query string, args ...interface{}) row {
+	mock, err := c.match(query, args)
+	if err != nil {
+		return &rowMock{Error: err}
+	}
+	if mock.Error != nil {
+		return &rowMock{Error: mock.Error}
+	}
+
+	return &rowMock{
+		Columns: mock.Columns,
+		Rows:    mock.Rows,
+	}
+}
+
+func (c *clientMock) QueryRowContext(ctx context.Context, query string, args ...interface{}) row {
+	if err := ctx.Err(); err != nil {
+		return &rowMock{Error: err}
+	}
+
+	return c.QueryRow(query, args...)
+}
+
+func (c *clientMock) SetConnMaxLifetime(d time.Duration) {
+}
+
+func (c *clientMock) SetMaxIdleConns(n int) {
+}
+
+func (c *clientMock) SetMaxOpenConns(n int) {
+}
+
+func (c *clientMock) Stats() sql.DBStats {
+	return sql.DBStats{}
+}
+
+func (c *clientMock) add(mock Mock) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mock.used = false
+	c.mocks = append(c.mocks, &mock)
+}
+
+func (c *clientMock) match(query string, args []interface{}) (*Mock, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, mock := range c.mocks {
+		if mock.used || !sameQuery(mock.Query, query) {
+			continue
+		}
+		if mock.Args != nil && !sameArgs(mock.Args, args) {
+			continue
+		}
+		mock.used = true
+		return mock, nil
+	}
+
+	call := fmt.Sprintf("%s %v", normalizeQuery(query), args)
+	c.unexpected = append(c.unexpected, call)
+
+	return nil, fmt.Errorf("mock not found for %s", call)
+}
+
+// optional consumes the next mock of a statement that doesn't have to be
+// mocked, such as BEGIN or COMMIT.
+func (c *clientMock) optional(query string) *Mock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, mock := range c.mocks {
+		if !mock.used && sameQuery(mock.Query, query) {
+			mock.used = true
+			return mock
+		}
+	}
+
+	return nil
+}
+
+func (c *clientMock) unmet() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var problems []string
+	for _, mock := range c.mocks {
+		if !mock.used {
+			problems = append(problems, fmt.Sprintf("expected %s %v was not called", normalizeQuery(mock.Query), mock.Args))
+		}
+	}
+	for _, call := range c.unexpected {
+		problems = append(problems, fmt.Sprintf("unexpected %s", call))
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return errors.New(strings.Join(problems, "\n"))
+}
+
+func sameQuery(expected string, actual string) bool {
+	return normalizeQuery(expected) == normalizeQuery(actual)
+}
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+func sameArgs(expected []interface{}, actual []interface{}) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	for i := range expected {
+		if expected[i] == AnyArg {
+			continue
+		}
+		if !reflect.DeepEqual(normalizeArg(expected[i]), normalizeArg(actual[i])) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeArg converts an argument the way database/sql would before sending
+// it to the driver, so a uuid.UUID matches its pointer and an int matches an
+// int64.
+func normalizeArg(arg interface{}) interface{} {
+	value, err := driver.DefaultParameterConverter.ConvertValue(arg)
+	if err != nil {
+		return arg
+	}
+
+	if t, ok := value.(time.Time); ok {
+		return t.UTC().Round(0)
+	}
+
+	return value
+}
+
+func isProduction() bool {
+	return os.Getenv(env) == production
+}
+
+func StartMockServer() {
+	isMocked = true
+}
+
+func StopMockServer() {
+	isMocked = false
+}
+
+func AddMock(mock Mock) {
+	if dbClient == nil {
+		return
+	}
+	client, expectedType := dbClient.(*clientMock)
+	if !expectedType {
+		return
+	}
+	client.add(mock)
+}
+
+// ExpectationsWereMet reports mocks that were never used and statements that
+// had no matching mock.
+func ExpectationsWereMet() error {
+	if dbClient == nil {
+		return nil
+	}
+	client, expectedType := dbClient.(*clientMock)
+	if !expectedType {
+		return nil
+	}
+
+	return client.unmet()
+}
@@ -1,36 +1,25 @@
-package sqlclient
-
-import "errors"
-
-type rowMock struct {
-	Columns []string
-	Row     [][]interface{}
-
-	Error error
-	Index int
-}
-
-func (r *rowMock) Next() bool {
-	return r.Index < len(r.Row)
-}
-
-func (r *rowMock) Close() error {
-	return nil
-}
-
-func (r *rowMock) Scan(destinations ...interface{}) error {
-	row := r.Row[r.Index]
-	if len(row) != len(destinations) {
-		return errors.New("invalid destination length")
-	}
-
-	for index, value := range row {
-		destinations[index] = value
-	}
-
-	return nil
-}
-
-func (r *rowMock) Err() error {
-	return r.Error
-}
+package sqlclient
+
+import "database/sql"
+
+type rowMock struct {
+	Columns []string
+	Rows    [][]interface{}
+
+	Error error
+}
+
+func (r *rowMock) Scan(destinations ...interface{}) error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if len(r.Rows) == 0 {
+		return sql.ErrNoRows
+	}
+
+	return scanInto(r.Rows[0], destinations)
+}
+
+func (r *rowMock) Err() error {
+	return r.Error
+}
@@ -1,36 +1,97 @@
-package sqlclient
-
-import "errors"
-
-type rowsMock struct {
-	Columns []string
-	Rows    [][]interface{}
-
-	Error error
-	Index int
-}
-
-func (r *rowsMock) Next() bool {
-	return r.Index < len(r.Rows)
-}
-
-func (r *rowsMock) Close() error {
-	return nil
-}
-
-func (r *rowsMock) Scan(destinations ...interface{}) error {
-	row := r.Rows[r.Index]
-	if len(row) != len(destinations) {
-		return errors.New("invalid destination length")
-	}
-
-	for index, value := range row {
-		destinations[index] = value
-	}
-
-	return nil
-}
-
-func (r *rowsMock) Err() error {
-	return r.Error
-}
+package sqlclient
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+type rowsMock struct {
+	Columns []string
+	Rows    [][]interface{}
+
+	Error error
+	Index int
+}
+
+func (r *rowsMock) Next() bool {
+	if r.Index >= len(r.Rows) {
+		return false
+	}
+	r.Index++
+
+	return true
+}
+
+func (r *rowsMock) Close() error {
+	return nil
+}
+
+func (r *rowsMock) Scan(destinations ...interface{}) error {
+	if r.Index == 0 || r.Index > len(r.Rows) {
+		return errors.New("Scan called without calling Next")
+	}
+
+	return scanInto(r.Rows[r.Index-1], destinations)
+}
+
+func (r *rowsMock) Err() error {
+	return r.Error
+}
+
+func scanInto(row []interface{}, destinations []interface{}) error {
+	if len(row) != len(destinations) {
+		return fmt.Errorf("expected %d destination arguments in Scan, not %d", len(row), len(destinations))
+	}
+
+	for index, value := range row {
+		if err := assign(destinations[index], value); err != nil {
+			return fmt.Errorf("Scan error on column index %d: %w", index, err)
+		}
+	}
+
+	return nil
+}
+
+// assign stores value through the destination pointer, the same way
+// database/sql does for the types the repositories scan into.
+func assign(destination interface{}, value interface{}) error {
+	pointer := reflect.ValueOf(destination)
+	if pointer.Kind() != reflect.Ptr || pointer.IsNil() {
+		return errors.New("destination not a pointer")
+	}
+	target := pointer.Elem()
+
+	if value == nil {
+		if scanner, ok := destination.(sql.Scanner); ok {
+			return scanner.Scan(nil)
+		}
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	source := reflect.ValueOf(value)
+	if source.Type().AssignableTo(target.Type()) {
+		target.Set(source)
+		return nil
+	}
+
+	if scanner, ok := destination.(sql.Scanner); ok {
+		return scanner.Scan(value)
+	}
+
+	if target.Kind() == reflect.Ptr && source.Type().AssignableTo(target.Type().Elem()) {
+		allocated := reflect.New(target.Type().Elem())
+		allocated.Elem().Set(source)
+		target.Set(allocated)
+		return nil
+	}
+
+	if source.Type().ConvertibleTo(target.Type()) {
+		target.Set(source.Convert(target.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("unsupported Scan, storing %T into %T", value, destination)
+}
@@ -0,0 +1,76 @@
+package sqlclient
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/demkowo/booking/utils/tracing"
+)
+
+// startSpan starts a client span for one statement, named after its first
+// keyword, e.g. "SELECT".
+func startSpan(ctx context.Context, system string, query string) context.Context {
+	statement := tracing.Statement(query)
+	name, _, _ := strings.Cut(statement, " ")
+
+	ctx, _ = tracing.Start(ctx, strings.ToUpper(name),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemKey.String(system),
+			semconv.DBQueryText(statement),
+		),
+	)
+
+	return ctx
+}
+
+// endSpan ends the span of the statement in ctx, recording err on it.
+func endSpan(ctx context.Context, err error) {
+	span := trace.SpanFromContext(ctx)
+	if err != nil {
+		span.RecordError(err)
+		tracing.Fail(ctx, err.Error())
+	}
+	span.End()
+}
+
+// tracedRow ends the span of a single row query once the row is scanned,
+// because database/sql only runs the query then. No rows isn't a failure.
+type tracedRow struct {
+	row
+	ctx   context.Context
+	ended bool
+}
+
+func (r *tracedRow) Scan(destinations ...interface{}) error {
+	err := r.row.Scan(destinations...)
+	r.end(err)
+
+	return err
+}
+
+func (r *tracedRow) Err() error {
+	err := r.row.Err()
+	if err != nil {
+		r.end(err)
+	}
+
+	return err
+}
+
+func (r *tracedRow) end(err error) {
+	if r.ended {
+		return
+	}
+	r.ended = true
+
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	endSpan(r.ctx, err)
+}
@@ -0,0 +1,67 @@
+package sqlclient
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestQueryRowContextSpan(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	db, err := Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		query  string
+		err    bool
+		status codes.Code
+	}{
+		{name: "row", query: "SELECT 1"},
+		{name: "no rows", query: "SELECT 1 WHERE 1 = 0", err: true},
+		{name: "failed", query: "SELECT id FROM missing", err: true, status: codes.Error},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(recorder.Ended())
+
+			row := db.QueryRowContext(ctx, tt.query)
+			if len(recorder.Ended()) != before {
+				t.Fatal("span ended before the row was scanned")
+			}
+
+			var value int
+			err := row.Scan(&value)
+			if (err != nil) != tt.err {
+				t.Fatalf("unexpected Scan error %v", err)
+			}
+
+			ended := recorder.Ended()
+			if len(ended) != before+1 {
+				t.Fatalf("expected the span to end after Scan, %d spans ended", len(ended)-before)
+			}
+			span := ended[len(ended)-1]
+			if span.Name() != "SELECT" || span.Status().Code != tt.status {
+				t.Fatalf("unexpected span %s with status %v", span.Name(), span.Status())
+			}
+			if tt.status == codes.Error && (len(span.Events()) == 0 || span.Events()[0].Name != "exception") {
+				t.Fatal("expected the error to be recorded on the span")
+			}
+		})
+	}
+}
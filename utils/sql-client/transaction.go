@@ -1,31 +1,62 @@
-package sqlclient
-
-import (
-	"database/sql"
-	"log"
-)
-
-type sqlTx struct {
-	tx *sql.Tx
-}
-
-type Tx interface {
-	Commit() error
-	Exec(string, ...interface{}) (sql.Result, error)
-	Rollback() error
-}
-
-func (t *sqlTx) Commit() error {
-	log.Println("--- transactions/sqlclient/Commit() ---")
-	return t.tx.Commit()
-}
-
-func (t *sqlTx) Exec(query string, args ...interface{}) (sql.Result, error) {
-	log.Println("--- transactions/sqlclient/Exec() ---")
-
-	return t.tx.Exec(query, args...)
-}
-
-func (t *sqlTx) Rollback() error {
-	return t.tx.Rollback()
-}
+package sqlclient
+
+import (
+	"context"
+	"database/sql"
+)
+
+type sqlTx struct {
+	tx *sql.Tx
+}
+
+type Tx interface {
+	Commit() error
+	Exec(string, ...interface{}) (sql.Result, error)
+	Rollback() error
+
+	ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) row
+}
+
+type txKey struct{}
+
+// WithTx returns a copy of ctx carrying tx. SqlClient calls made with the
+// returned context run inside tx.
+func WithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(Tx)
+	return tx, ok
+}
+
+func (t *sqlTx) Commit() error {
+	return t.tx.Commit()
+}
+
+func (t *sqlTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.Exec(query, args...)
+}
+
+func (t *sqlTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return t.tx.ExecContext(ctx, query, args...)
+}
+
+func (t *sqlTx) QueryContext(ctx context.Context, query string, args ...interface{}) (rows, error) {
+	res, err := t.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqlRows{rows: res}, nil
+}
+
+func (t *sqlTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) row {
+	return &sqlRow{row: t.tx.QueryRowContext(ctx, query, args...)}
+}
+
+func (t *sqlTx) Rollback() error {
+	return t.tx.Rollback()
+}
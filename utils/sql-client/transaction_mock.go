@@ -1,27 +1,69 @@
-package sqlclient
-
-import (
-	"database/sql"
-	"log"
-)
-
-type sqlTxMock struct {
-	Tx    [][]interface{}
-	Error error
-	tx    *sql.Tx
-}
-
-func (t *sqlTxMock) Commit() error {
-	log.Println("--- transactions/sqlclient/Commit() ---")
-	return t.tx.Commit()
-}
-
-func (t *sqlTxMock) Exec(query string, args ...interface{}) (sql.Result, error) {
-	log.Println("--- transactions/sqlclient/Exec() ---")
-
-	return t.tx.Exec(query, args...)
-}
-
-func (t *sqlTxMock) Rollback() error {
-	return t.tx.Rollback()
-}
+package sqlclient
+
+import (
+	"context"
+	"database/sql"
+)
+
+type sqlTxMock struct {
+	client *clientMock
+	done   bool
+}
+
+func (t *sqlTxMock) Commit() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+
+	if mock := t.client.optional(MOCK_COMMIT); mock != nil {
+		return mock.Error
+	}
+
+	return nil
+}
+
+func (t *sqlTxMock) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if t.done {
+		return nil, sql.ErrTxDone
+	}
+
+	return t.client.Exec(query, args...)
+}
+
+func (t *sqlTxMock) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if t.done {
+		return nil, sql.ErrTxDone
+	}
+
+	return t.client.ExecContext(ctx, query, args...)
+}
+
+func (t *sqlTxMock) QueryContext(ctx context.Context, query string, args ...interface{}) (rows, error) {
+	if t.done {
+		return nil, sql.ErrTxDone
+	}
+
+	return t.client.QueryContext(ctx, query, args...)
+}
+
+func (t *sqlTxMock) QueryRowContext(ctx context.Context, query string, args ...interface{}) row {
+	if t.done {
+		return &rowMock{Error: sql.ErrTxDone}
+	}
+
+	return t.client.QueryRowContext(ctx, query, args...)
+}
+
+func (t *sqlTxMock) Rollback() error {
+	if t.done {
+		return sql.ErrTxDone
+	}
+	t.done = true
+
+	if mock := t.client.optional(MOCK_ROLLBACK); mock != nil {
+		return mock.Error
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package tenant
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/demkowo/booking/utils/errs"
+)
+
+type propertyKey struct{}
+
+// WithProperty scopes ctx to a property. Room and reservation repositories
+// only see the rows of the property ctx is scoped to.
+func WithProperty(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, propertyKey{}, id)
+}
+
+func Property(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(propertyKey{}).(uuid.UUID)
+
+	return id, ok && id != uuid.Nil
+}
+
+// Require returns the property of ctx, or an error when ctx isn't scoped to
+// one, so a repository never runs a query across properties.
+func Require(ctx context.Context) (uuid.UUID, *errs.Error) {
+	id, ok := Property(ctx)
+	if !ok {
+		return uuid.Nil, errs.NewError("Request isn't scoped to a property", 500, "Internal Server Error", []interface{}{})
+	}
+
+	return id, nil
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	model "github.com/demkowo/booking/models"
+)
+
+func TestFileExporterWritesOTLPJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Setup(model.TracingConfig{Exporter: EXPORTER_FILE, Path: path, SampleRatio: 1, ServiceName: "booking-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := Start(context.Background(), "Room.Add")
+	Fail(ctx, "room not found")
+	span.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	lines := bufio.NewScanner(file)
+	if !lines.Scan() {
+		t.Fatal("no spans were written")
+	}
+	var request coltracepb.ExportTraceServiceRequest
+	if err := protojson.Unmarshal(lines.Bytes(), &request); err != nil {
+		t.Fatalf("line isn't an OTLP JSON request: %v", err)
+	}
+
+	resource := request.ResourceSpans[0]
+	found := false
+	for _, attribute := range resource.Resource.Attributes {
+		found = found || attribute.Key == "service.name" && attribute.Value.GetStringValue() == "booking-test"
+	}
+	if !found {
+		t.Fatalf("service.name missing from %v", resource.Resource.Attributes)
+	}
+
+	exported := resource.ScopeSpans[0].Spans[0]
+	if exported.Name != "Room.Add" || exported.Status.Message != "room not found" {
+		t.Fatalf("unexpected span %v", exported)
+	}
+}
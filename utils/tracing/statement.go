@@ -0,0 +1,30 @@
+package tracing
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	MAX_STATEMENT_LENGTH = 2000
+)
+
+var (
+	stringLiteral  = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numericLiteral = regexp.MustCompile(`([^\w$?.])-?\d+(?:\.\d+)?\b`)
+)
+
+// Statement prepares a SQL statement for the db.query.text attribute.
+// Repositories pass values as parameters, but literals written into a
+// statement are replaced with ? anyway, so no data ends up in a span.
+func Statement(query string) string {
+	statement := strings.Join(strings.Fields(query), " ")
+	statement = stringLiteral.ReplaceAllString(statement, "?")
+	statement = numericLiteral.ReplaceAllString(statement, "${1}?")
+
+	if len(statement) > MAX_STATEMENT_LENGTH {
+		statement = statement[:MAX_STATEMENT_LENGTH]
+	}
+
+	return statement
+}
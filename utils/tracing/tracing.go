@@ -0,0 +1,87 @@
+// Package tracing sets up OpenTelemetry. Spans are started by the HTTP
+// middleware, the services, the repositories and sqlclient, and trace context
+// is read from and written to W3C traceparent headers.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+
+	model "github.com/demkowo/booking/models"
+	"github.com/demkowo/booking/utils/version"
+)
+
+const (
+	EXPORTER_NONE   = "none"
+	EXPORTER_STDOUT = "stdout"
+	EXPORTER_FILE   = "file"
+	EXPORTER_OTLP   = "otlp"
+
+	TRACER_NAME = "github.com/demkowo/booking"
+)
+
+// Setup installs the W3C propagators and, unless the exporter is none, a
+// tracer provider exporting the spans. otlp sends them to an OTLP/HTTP
+// collector, file appends them to a file in the OTLP JSON format collectors
+// can read back, and stdout prints them for development. The returned func
+// flushes the pending spans and closes the exporter.
+func Setup(cfg model.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	switch cfg.Exporter {
+	case EXPORTER_NONE:
+		return func(context.Context) error { return nil }, nil
+	case EXPORTER_OTLP:
+		exporter, err = otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(cfg.Endpoint))
+	case EXPORTER_FILE:
+		exporter, err = otlptrace.New(context.Background(), newFileClient(cfg.Path))
+	default:
+		exporter, err = stdouttrace.New()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.New(context.Background(),
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(
+			semconv.ServiceName(cfg.ServiceName),
+			semconv.ServiceVersion(version.Get().Version),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(TRACER_NAME).Start(ctx, name, opts...)
+}
+
+// Fail marks the span in ctx as failed.
+func Fail(ctx context.Context, message string) {
+	trace.SpanFromContext(ctx).SetStatus(codes.Error, message)
+}
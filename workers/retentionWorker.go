@@ -0,0 +1,123 @@
+package worker
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	service "github.com/demkowo/booking/services"
+	"github.com/demkowo/booking/utils/tenant"
+)
+
+const (
+	RETENTION_DELETE    = "delete"
+	RETENTION_ANONYMIZE = "anonymize"
+)
+
+type Retention interface {
+	Running() bool
+	Start()
+	Stop()
+}
+
+type retention struct {
+	service    service.Reservation
+	properties service.Property
+	period     time.Duration
+	interval   time.Duration
+	mode       string
+	ctx        context.Context
+	cancel     context.CancelFunc
+	done       chan struct{}
+	running    atomic.Bool
+}
+
+// NewRetention cleans up the deleted reservations of every property on each
+// run.
+func NewRetention(service service.Reservation, properties service.Property, period time.Duration, interval time.Duration, mode string) Retention {
+	log.Trace()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &retention{
+		service:    service,
+		properties: properties,
+		period:     period,
+		interval:   interval,
+		mode:       mode,
+		ctx:        ctx,
+		cancel:     cancel,
+		done:       make(chan struct{}),
+	}
+}
+
+func (w *retention) Start() {
+	log.Trace()
+
+	w.running.Store(true)
+	go func() {
+		defer close(w.done)
+		defer w.running.Store(false)
+
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			w.run()
+
+			select {
+			case <-ticker.C:
+			case <-w.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Running reports whether the worker has started and its loop hasn't
+// exited.
+func (w *retention) Running() bool {
+	return w.running.Load()
+}
+
+func (w *retention) Stop() {
+	log.Trace()
+
+	w.cancel()
+	<-w.done
+}
+
+func (w *retention) run() {
+	log.Trace()
+
+	before := time.Now().Add(-w.period)
+
+	properties, err := w.properties.Find(w.ctx)
+	if err != nil {
+		log.Errorf("finding properties failed: %s", err.Message)
+		return
+	}
+
+	for _, property := range properties {
+		ctx := tenant.WithProperty(w.ctx, property.Id)
+
+		switch w.mode {
+		case RETENTION_DELETE:
+			count, err := w.service.PurgeDeleted(ctx, before)
+			if err != nil {
+				log.Errorf("purging deleted reservations of property %s failed: %s", property.Id, err.Message)
+				continue
+			}
+			log.Infof("purged %d reservations of property %s deleted before %s", count, property.Id, before.Format(time.RFC3339))
+		case RETENTION_ANONYMIZE:
+			count, err := w.service.AnonymizeDeleted(ctx, before)
+			if err != nil {
+				log.Errorf("anonymizing deleted reservations of property %s failed: %s", property.Id, err.Message)
+				continue
+			}
+			log.Infof("anonymized %d reservations of property %s deleted before %s", count, property.Id, before.Format(time.RFC3339))
+		}
+	}
+}